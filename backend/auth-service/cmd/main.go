@@ -87,6 +87,13 @@ func main() {
 			secured.DELETE("/addresses/:id", handler.DeleteAddress)
 			secured.PUT("/addresses/:id/default", handler.SetDefaultAddress)
 		}
+
+		// 管理員路由
+		admin := api.Group("/admin")
+		admin.Use(middleware.AuthMiddleware(cfg.JWT.Secret), middleware.RequireRole("admin"))
+		{
+			admin.GET("/users/:id", handler.GetUserByID)
+		}
 	}
 
 	// 啟動服務器
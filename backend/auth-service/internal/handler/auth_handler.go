@@ -236,6 +236,24 @@ func (h *Handler) GetUser(c *gin.Context) {
 	c.JSON(http.StatusOK, user)
 }
 
+// GetUserByID 管理員依ID獲取用戶資訊
+func (h *Handler) GetUserByID(c *gin.Context) {
+	user, err := h.authService.GetUserByID(c.Request.Context(), c.Param("id"))
+	if err == nil && user.ID == "" {
+		err = service.ErrUserNotFound
+	}
+	if err != nil {
+		if err == service.ErrUserNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, user)
+}
+
 // CreateAddress 添加新的處理方法
 func (h *Handler) CreateAddress(c *gin.Context) {
 	var req model.AddressRequest
@@ -48,7 +48,27 @@ func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
 			return
 		}
 
+		// role 由 auth-service 簽發，舊 token 可能沒有此欄位
+		role, _ := claims["role"].(string)
+
 		c.Set("userID", userID)
+		c.Set("role", role)
 		c.Next()
 	}
 }
+
+// RequireRole 限制只有指定角色可以訪問，需在 AuthMiddleware 之後使用
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := c.GetString("role")
+		for _, r := range roles {
+			if role == r {
+				c.Next()
+				return
+			}
+		}
+
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+		c.Abort()
+	}
+}
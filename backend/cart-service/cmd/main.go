@@ -9,16 +9,22 @@ import (
 	"os/signal"
 	"syscall"
 	"time"
+	_ "time/tzdata" // 報表時區在未安裝時區資料的容器中也能載入
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/carrier"
 	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/client"
 	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/config"
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/einvoice"
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/export"
 	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/handler"
 	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/infrastructure/firebase"
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/invoice"
 	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/middleware"
 	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/repository"
 	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/service"
+	"github.com/kevinsuu/OrderManagerSystem/pkg/idempotency"
 )
 
 // 新增這個函數
@@ -37,9 +43,124 @@ func checkServiceHealth(serviceURL, serviceName string) error {
 	return nil
 }
 
+// startAbandonedCartScanner 定期掃描棄置購物車
+func startAbandonedCartScanner(abandonedCartService service.AbandonedCartService, interval time.Duration) {
+	// 間隔設為 0 時停用掃描
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	for range ticker.C {
+		if err := abandonedCartService.ScanAbandonedCarts(context.Background()); err != nil {
+			log.Printf("Failed to scan abandoned carts: %v", err)
+		}
+	}
+}
+
+// startOrderExpiryScanner 定期取消庫存預留逾時的未付款訂單
+func startOrderExpiryScanner(orderService service.OrderService, interval time.Duration) {
+	// 間隔設為 0 時停用掃描
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	for range ticker.C {
+		expired, err := orderService.ExpirePendingOrders(context.Background())
+		if err != nil {
+			log.Printf("Failed to expire pending orders: %v", err)
+			continue
+		}
+		if expired > 0 {
+			log.Printf("Cancelled %d orders with expired stock reservations", expired)
+		}
+	}
+}
+
+// startCheckoutSagaResumer 定期續跑中斷的結帳流程
+func startCheckoutSagaResumer(checkoutService service.CheckoutSagaService, interval time.Duration) {
+	// 間隔設為 0 時停用續跑
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	for range ticker.C {
+		resumed, err := checkoutService.ResumeSagas(context.Background())
+		if err != nil {
+			log.Printf("Failed to resume checkout sagas: %v", err)
+			continue
+		}
+		if resumed > 0 {
+			log.Printf("Resumed %d checkout sagas", resumed)
+		}
+	}
+}
+
+// startShipmentSync 定期向物流商同步貨件追蹤狀態
+func startShipmentSync(shipmentService service.ShipmentService, interval time.Duration) {
+	// 間隔設為 0 時停用同步
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	for range ticker.C {
+		updated, err := shipmentService.SyncShipments(context.Background())
+		if err != nil {
+			log.Printf("Failed to sync shipments: %v", err)
+			continue
+		}
+		if updated > 0 {
+			log.Printf("Updated %d shipments from carriers", updated)
+		}
+	}
+}
+
+// startOrderExporter 定期將上次匯出之後建立的訂單寫入輸出目錄
+func startOrderExporter(exportService service.ExportService, interval time.Duration) {
+	// 間隔設為 0 時停用匯出
+	if interval <= 0 {
+		return
+	}
+	from := time.Now().Add(-interval)
+	ticker := time.NewTicker(interval)
+	for now := range ticker.C {
+		path, count, err := exportService.ExportToDir(context.Background(), from, now)
+		if err != nil {
+			// 保留起點，下次匯出時涵蓋這次的範圍
+			log.Printf("Failed to export orders: %v", err)
+			continue
+		}
+		log.Printf("Exported %d orders to %s", count, path)
+		from = now
+	}
+}
+
+func startSubscriptionScheduler(subscriptionService service.SubscriptionService, interval time.Duration) {
+	// 間隔設為 0 時停用排程
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	for range ticker.C {
+		ran, err := subscriptionService.RunDue(context.Background())
+		if err != nil {
+			log.Printf("Failed to run due subscriptions: %v", err)
+			continue
+		}
+		if ran > 0 {
+			log.Printf("Ran %d due subscriptions", ran)
+		}
+	}
+}
+
 func main() {
 	// 加載配置
 	cfg := config.LoadConfig()
+	// 服務 token 帶有 service 角色，不可與用戶 token 共用密鑰
+	if cfg.JWT.ServiceSecret == "" {
+		log.Printf("Warning: SERVICE_JWT_SECRET not set, stock reservations and background jobs cannot call other services")
+	} else if cfg.JWT.ServiceSecret == cfg.JWT.Secret {
+		log.Fatalf("SERVICE_JWT_SECRET must differ from JWT_SECRET")
+	}
 	if err := checkServiceHealth(cfg.ProductService.BaseURL, "Product Service"); err != nil {
 		fmt.Printf("Warning: %v", err)
 	}
@@ -55,22 +176,144 @@ func main() {
 	cartRepo := repository.NewCartRepository(fb.Database)
 	orderRepo := repository.NewOrderRepository(fb.Database)
 	wishlistRepo := repository.NewWishlistRepository(fb.Database)
+	wishlistListRepo := repository.NewWishlistListRepository(fb.Database)
+	abandonedCartRepo := repository.NewAbandonedCartRepository(fb.Database)
+	checkoutSagaRepo := repository.NewCheckoutSagaRepository(fb.Database)
+	idempotencyStore := idempotency.NewStore(fb.Database)
+	shipmentRepo := repository.NewShipmentRepository(fb.Database)
+	returnRepo := repository.NewReturnRepository(fb.Database)
+	invoiceRepo := repository.NewInvoiceRepository(fb.Database)
+	einvoiceRangeRepo := repository.NewEInvoiceRangeRepository(fb.Database)
+	subscriptionRepo := repository.NewSubscriptionRepository(fb.Database)
+	analyticsRepo := repository.NewAnalyticsRepository(fb.Database)
+	orderMessageRepo := repository.NewOrderMessageRepository(fb.Database)
+
+	// 初始化客戶端（共用具備逾時、重試與熔斷的 HTTP 客戶端）
+	httpClient := client.NewResilientClient(client.ResilientClientConfig{
+		Timeout:          cfg.HTTPClient.Timeout,
+		MaxRetries:       cfg.HTTPClient.MaxRetries,
+		BaseBackoff:      cfg.HTTPClient.BaseBackoff,
+		MaxBackoff:       cfg.HTTPClient.MaxBackoff,
+		FailureThreshold: cfg.HTTPClient.FailureThreshold,
+		OpenTimeout:      cfg.HTTPClient.OpenTimeout,
+		Metrics:          client.LogMetrics{},
+	})
+	productClient := client.NewProductClient(cfg.ProductService.BaseURL, cfg.JWT.ServiceSecret, httpClient, cfg.ProductService.CacheTTL)
+	authClient := client.NewAuthClient(cfg.AuthService.BaseURL, httpClient)
+	notificationClient := client.NewNotificationClient(cfg.NotificationService.BaseURL, cfg.JWT.ServiceSecret, httpClient)
+	paymentClient := client.NewPaymentClient(cfg.PaymentService.BaseURL, httpClient)
 
-	// 初始化客戶端
-	productClient := client.NewProductClient(cfg.ProductService.BaseURL)
-	orderClient := client.NewOrderClient(cfg.OrderService.BaseURL)
+	// 物流商，新增物流商時在此註冊
+	var carriers []carrier.Carrier
+	if cfg.Shipment.FakeCarrier {
+		carriers = append(carriers, carrier.NewFakeCarrier(cfg.Shipment.FakeCarrierStep))
+	}
+	carrierRegistry := carrier.NewRegistry(carriers...)
+
+	// 電子發票開立平台，串接加值中心時在此新增
+	var einvoiceIssuer einvoice.Issuer
+	switch cfg.EInvoice.Issuer {
+	case "":
+	case einvoice.FakeIssuerName:
+		einvoiceIssuer = einvoice.NewFakeIssuer()
+	default:
+		log.Fatalf("Unknown e-invoice issuer: %s", cfg.EInvoice.Issuer)
+	}
 
 	// 初始化服務層
-	orderService := service.NewOrderService(orderRepo)
-	cartService := service.NewCartService(cartRepo, productClient, orderClient, &service.CartServiceConfig{
+	pricingService := service.NewPricingService(&service.PricingServiceConfig{
+		DefaultShippingMethod: cfg.Pricing.DefaultShippingMethod,
+		DefaultTaxRegion:      cfg.Pricing.DefaultTaxRegion,
+		ShippingRules:         cfg.Pricing.ShippingRules,
+		TaxRules:              cfg.Pricing.TaxRules,
+		DiscountRules:         cfg.Pricing.DiscountRules,
+	})
+	analyticsLocation, err := time.LoadLocation(cfg.Analytics.Timezone)
+	if err != nil {
+		log.Fatalf("Invalid analytics timezone %s: %v", cfg.Analytics.Timezone, err)
+	}
+	analyticsService := service.NewAnalyticsService(analyticsRepo, &service.AnalyticsServiceConfig{
+		Location: analyticsLocation,
+	})
+	orderService := service.NewOrderService(orderRepo, cartRepo, productClient, authClient, pricingService, analyticsService)
+	cartService := service.NewCartService(cartRepo, wishlistRepo, productClient, pricingService, &service.CartServiceConfig{
 		ProductServiceBaseURL: cfg.ProductService.BaseURL,
 	})
 	wishlistService := service.NewWishlistService(wishlistRepo, productClient)
+	wishlistListService := service.NewWishlistListService(wishlistListRepo, productClient, cartService)
+	abandonedCartService := service.NewAbandonedCartService(cartRepo, abandonedCartRepo, notificationClient, &service.AbandonedCartServiceConfig{
+		IdleTimeout:        cfg.AbandonedCart.IdleTimeout,
+		ReminderTemplateID: cfg.AbandonedCart.ReminderTemplateID,
+	})
+	adminOrderService := service.NewAdminOrderService(orderRepo, orderService, paymentClient, authClient)
+	shipmentService := service.NewShipmentService(shipmentRepo, orderRepo, orderService, carrierRegistry)
+	returnService := service.NewReturnService(returnRepo, orderRepo, orderService, productClient, paymentClient, analyticsService, &service.ReturnServiceConfig{
+		Window:        cfg.Return.Window,
+		ServiceSecret: cfg.JWT.ServiceSecret,
+	})
+	// 發票含中文商品名稱與地址，未設定可用的中文字型時拒絕啟動
+	invoiceRenderer, err := invoice.NewRenderer(invoice.Seller{
+		Name:    cfg.Invoice.SellerName,
+		Address: cfg.Invoice.SellerAddress,
+		TaxID:   cfg.Invoice.SellerTaxID,
+	}, cfg.Invoice.FontPath)
+	if err != nil {
+		log.Fatalf("Failed to load invoice font (set INVOICE_FONT_PATH to a CJK TrueType font): %v", err)
+	}
+	invoiceService := service.NewInvoiceService(invoiceRepo, orderRepo, returnRepo, paymentClient, invoiceRenderer)
+	einvoiceService := service.NewEInvoiceService(einvoiceRangeRepo, orderRepo, einvoiceIssuer, &service.EInvoiceServiceConfig{
+		SellerTaxID: cfg.Invoice.SellerTaxID,
+	})
+	checkoutService := service.NewCheckoutSagaService(checkoutSagaRepo, orderRepo, orderService, cartService, productClient, paymentClient, notificationClient, pricingService, einvoiceService, &service.CheckoutSagaServiceConfig{
+		ServiceSecret:          cfg.JWT.ServiceSecret,
+		DefaultCurrency:        cfg.Checkout.DefaultCurrency,
+		ReservationTTL:         cfg.Checkout.ReservationTTL,
+		ConfirmationTemplateID: cfg.Checkout.ConfirmationTemplateID,
+		ResumeAfter:            cfg.Checkout.SagaResumeAfter,
+		MaxAttempts:            cfg.Checkout.SagaMaxAttempts,
+	})
+
+	subscriptionService := service.NewSubscriptionService(subscriptionRepo, orderService, checkoutService, productClient, notificationClient, &service.SubscriptionServiceConfig{
+		ServiceSecret:      cfg.JWT.ServiceSecret,
+		RemindBefore:       cfg.Subscription.RemindBefore,
+		RetryAfter:         cfg.Subscription.RetryAfter,
+		MaxFailures:        cfg.Subscription.MaxFailures,
+		ReminderTemplateID: cfg.Subscription.ReminderTemplateID,
+		FailureTemplateID:  cfg.Subscription.FailureTemplateID,
+	})
+
+	exportFormat := export.Format(cfg.Export.Format)
+	if exportFormat != export.FormatCSV && exportFormat != export.FormatXLSX {
+		log.Fatalf("Unknown export format: %s", cfg.Export.Format)
+	}
+	exportService := service.NewExportService(orderRepo, paymentClient, &service.ExportServiceConfig{
+		Dir:           cfg.Export.Dir,
+		Format:        exportFormat,
+		PageSize:      cfg.Export.PageSize,
+		ServiceSecret: cfg.JWT.ServiceSecret,
+	})
+
+	orderMessageService := service.NewOrderMessageService(orderMessageRepo, orderRepo, notificationClient, &service.OrderMessageServiceConfig{
+		TemplateID:    cfg.OrderMessage.TemplateID,
+		SupportUserID: cfg.OrderMessage.SupportUserID,
+	})
 
 	// 初始化 HTTP 處理器
 	cartHandler := handler.NewCartHandler(cartService)
 	orderHandler := handler.NewOrderHandler(orderService, cartService)
 	wishlistHandler := handler.NewWishlistHandler(wishlistService)
+	wishlistListHandler := handler.NewWishlistListHandler(wishlistListService)
+	abandonedCartHandler := handler.NewAbandonedCartHandler(abandonedCartService)
+	adminOrderHandler := handler.NewAdminOrderHandler(adminOrderService)
+	shipmentHandler := handler.NewShipmentHandler(shipmentService)
+	returnHandler := handler.NewReturnHandler(returnService)
+	invoiceHandler := handler.NewInvoiceHandler(invoiceService)
+	einvoiceHandler := handler.NewEInvoiceHandler(einvoiceService)
+	subscriptionHandler := handler.NewSubscriptionHandler(subscriptionService)
+	exportHandler := handler.NewExportHandler(exportService)
+	analyticsHandler := handler.NewAnalyticsHandler(analyticsService, analyticsLocation)
+	orderMessageHandler := handler.NewOrderMessageHandler(orderMessageService)
+	checkoutHandler := handler.NewCheckoutHandler(checkoutService, abandonedCartService, cfg.Checkout.SagaResumeAfter)
 
 	// 設置 Gin 路由
 	router := gin.Default()
@@ -83,8 +326,8 @@ func main() {
 	router.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"*"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Length", "Content-Type", "Authorization", "Accept"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowHeaders:     []string{"Origin", "Content-Length", "Content-Type", "Authorization", "Accept", idempotency.KeyHeader},
+		ExposeHeaders:    []string{"Content-Length", "Idempotent-Replayed"},
 		AllowCredentials: false,
 		MaxAge:           12 * time.Hour,
 	}))
@@ -94,11 +337,18 @@ func main() {
 		c.JSON(200, gin.H{"status": "ok"})
 	})
 
+	// 分享的收藏清單，不需登入即可檢視；加入購物車需登入，見 wishlist 群組
+	shared := router.Group("/api/v1/shared")
+	{
+		shared.GET("/wishlists/:token", wishlistListHandler.GetSharedList)
+	}
+
 	// API 路由
 	api := router.Group("/api/v1")
 	{
 		// 添加 auth middleware
 		api.Use(middleware.AuthMiddleware(cfg.JWT.Secret))
+		idempotent := idempotency.Middleware(idempotencyStore, cfg.Idempotency.TTL)
 
 		// 購物車路由
 		cart := api.Group("/cart")
@@ -108,32 +358,148 @@ func main() {
 			cart.DELETE("/items/:productId", cartHandler.RemoveFromCart)
 			cart.PUT("/items", cartHandler.UpdateQuantity)
 			cart.POST("/items/select", cartHandler.SelectItems)
+			cart.POST("/items/batch", cartHandler.BatchUpdate)
 			cart.DELETE("/", cartHandler.ClearCart)
-			// TODO 訂單服務尚未完成服務
-			// cart.POST("/checkout", cartHandler.CreateOrder)
+			cart.POST("/items/:productId/save-for-later", cartHandler.SaveForLater)
+			cart.POST("/items/:productId/move-to-wishlist", cartHandler.MoveToWishlist)
+			cart.POST("/saved/:productId/move-to-cart", cartHandler.MoveToCart)
+			cart.DELETE("/saved/:productId", cartHandler.RemoveSavedItem)
+			cart.POST("/checkout", idempotent, checkoutHandler.Checkout)
+			cart.GET("/checkout/:id", checkoutHandler.GetCheckout)
 		}
 
 		// 訂單路由
 		orders := api.Group("/orders")
 		{
-			orders.POST("/", orderHandler.CreateOrder)
+			// 與 /cart/checkout 相同，經由結帳流程建立訂單、付款並開立電子發票
+			orders.POST("/", idempotent, checkoutHandler.Checkout)
 			orders.GET("/", orderHandler.ListOrders)
 			orders.GET("/:id", orderHandler.GetOrder)
-			orders.PUT("/:id", orderHandler.UpdateOrder)
 			orders.DELETE("/:id", orderHandler.DeleteOrder)
 			orders.POST("/:id/cancel", orderHandler.CancelOrder)
+			orders.POST("/:id/reorder", orderHandler.Reorder)
+			orders.GET("/:id/tracking", shipmentHandler.GetTracking)
+			orders.POST("/:id/returns", idempotent, returnHandler.CreateReturn)
+			orders.GET("/:id/returns", returnHandler.ListReturns)
+			orders.GET("/:id/returns/:returnId/credit-note", invoiceHandler.GetCreditNote)
+			orders.GET("/:id/invoice", invoiceHandler.GetInvoice)
+			orders.GET("/:id/messages", orderMessageHandler.GetMessages)
+			orders.POST("/:id/messages", orderMessageHandler.PostMessage)
+			orders.GET("/messages", orderMessageHandler.ListThreads)
 			orders.GET("/status/:status", orderHandler.GetOrdersByStatus)
 		}
 
-		// 收藏清單路由
+		// 收藏清單路由，未指定清單的端點操作預設收藏清單
 		wishlist := api.Group("/wishlist")
 		{
 			wishlist.GET("/", wishlistHandler.GetWishlist)
+			wishlist.GET("/ids", wishlistHandler.GetWishlistIds)
 			wishlist.POST("/", wishlistHandler.AddToWishlist)
 			wishlist.DELETE("/:productId", wishlistHandler.RemoveFromWishlist)
+
+			// 具名收藏清單
+			wishlist.GET("/lists", wishlistListHandler.GetLists)
+			wishlist.POST("/lists", wishlistListHandler.CreateList)
+			wishlist.GET("/lists/:listId", wishlistListHandler.GetList)
+			wishlist.PUT("/lists/:listId", wishlistListHandler.UpdateList)
+			wishlist.DELETE("/lists/:listId", wishlistListHandler.DeleteList)
+			wishlist.POST("/lists/:listId/items", wishlistListHandler.AddItem)
+			wishlist.PUT("/lists/:listId/items/:productId", wishlistListHandler.UpdateItem)
+			wishlist.DELETE("/lists/:listId/items/:productId", wishlistListHandler.RemoveItem)
+			wishlist.POST("/lists/:listId/share", wishlistListHandler.RotateShareToken)
+			// 沒有訪客購物車，分享清單加入購物車須以登入用戶的購物車為對象
+			wishlist.POST("/shared/:token/cart", wishlistListHandler.AddSharedListToCart)
+		}
+
+		// 定期訂購路由
+		subscriptions := api.Group("/subscriptions")
+		{
+			subscriptions.POST("/", idempotent, subscriptionHandler.CreateSubscription)
+			subscriptions.GET("/", subscriptionHandler.ListSubscriptions)
+			subscriptions.GET("/:id", subscriptionHandler.GetSubscription)
+			subscriptions.PUT("/:id", subscriptionHandler.UpdateSubscription)
+			subscriptions.DELETE("/:id", subscriptionHandler.CancelSubscription)
+			subscriptions.POST("/:id/skip", subscriptionHandler.SkipNextRun)
+			subscriptions.POST("/:id/pause", subscriptionHandler.PauseSubscription)
+			subscriptions.POST("/:id/resume", subscriptionHandler.ResumeSubscription)
+		}
+
+		// 管理員路由
+		admin := api.Group("/admin")
+		admin.Use(middleware.RequireRole("admin"))
+		{
+			admin.GET("/carts/abandoned/stats", abandonedCartHandler.GetStats)
+			admin.GET("/checkout/sagas/stuck", checkoutHandler.ListStuckSagas)
+			admin.POST("/checkout/sagas/:id/retry", checkoutHandler.RetrySaga)
+			admin.GET("/orders", adminOrderHandler.ListOrders)
+			admin.GET("/orders/export", exportHandler.ExportOrders)
+			admin.POST("/orders/status/bulk", adminOrderHandler.BulkUpdateStatus)
+			admin.GET("/orders/:id", adminOrderHandler.GetOrder)
+			admin.POST("/orders/:id/notes", adminOrderHandler.AddNote)
+			admin.GET("/orders/:id/messages", orderMessageHandler.GetStaffMessages)
+			admin.POST("/orders/:id/messages", orderMessageHandler.PostStaffMessage)
+			admin.GET("/messages/unread", orderMessageHandler.ListThreadsAwaitingStaff)
+			admin.POST("/orders/:id/status", adminOrderHandler.UpdateOrderStatus)
+			admin.GET("/orders/:id/shipments", shipmentHandler.ListShipments)
+			admin.POST("/orders/:id/shipments", shipmentHandler.CreateShipment)
+			admin.GET("/orders/:id/returns", returnHandler.ListOrderReturns)
+			admin.GET("/orders/:id/invoice", invoiceHandler.GetOrderInvoice)
+			admin.POST("/orders/:id/einvoice", einvoiceHandler.IssueOrderEInvoice)
+			admin.GET("/returns", returnHandler.ListReturnsByStatus)
+			admin.POST("/returns/:id/review", returnHandler.ReviewReturn)
+			admin.POST("/returns/:id/receive", returnHandler.ReceiveReturn)
+			admin.POST("/returns/:id/refund", returnHandler.RefundReturn)
+			admin.GET("/returns/:id/credit-note", invoiceHandler.GetReturnCreditNote)
+			admin.GET("/einvoice/ranges", einvoiceHandler.ListNumberRanges)
+			admin.GET("/analytics/sales", analyticsHandler.GetSalesReport)
+			admin.GET("/analytics/products/top", analyticsHandler.GetTopProducts)
+			admin.GET("/analytics/categories/top", analyticsHandler.GetTopCategories)
+			admin.POST("/einvoice/ranges", einvoiceHandler.AddNumberRange)
 		}
 	}
 
+	// 補建舊訂單的查詢索引，完成一次後不再掃描
+	go func() {
+		fixed, err := orderRepo.BackfillIndex(context.Background())
+		if err != nil {
+			log.Printf("Failed to backfill order index: %v", err)
+			return
+		}
+		if fixed > 0 {
+			log.Printf("Backfilled %d order index entries", fixed)
+		}
+	}()
+
+	// 搬移舊格式的收藏清單項目至各用戶的預設收藏清單
+	go func() {
+		migrated, err := wishlistRepo.MigrateLegacyItems(context.Background())
+		if err != nil {
+			log.Printf("Failed to migrate wishlist items: %v", err)
+			return
+		}
+		if migrated > 0 {
+			log.Printf("Migrated %d wishlist items", migrated)
+		}
+	}()
+
+	// 啟動棄置購物車掃描
+	go startAbandonedCartScanner(abandonedCartService, cfg.AbandonedCart.ScanInterval)
+
+	// 啟動逾時訂單掃描
+	go startOrderExpiryScanner(orderService, cfg.Checkout.ExpiryScanInterval)
+
+	// 啟動中斷結帳流程續跑
+	go startCheckoutSagaResumer(checkoutService, cfg.Checkout.SagaScanInterval)
+
+	// 啟動物流狀態同步
+	go startShipmentSync(shipmentService, cfg.Shipment.SyncInterval)
+
+	// 啟動定期訂購排程
+	go startSubscriptionScheduler(subscriptionService, cfg.Subscription.ScanInterval)
+
+	// 啟動訂單排程匯出
+	go startOrderExporter(exportService, cfg.Export.Interval)
+
 	// 啟動服務器
 	go func() {
 		if err := router.Run(cfg.Server.Address); err != nil {
@@ -0,0 +1,58 @@
+package carrier
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/model"
+)
+
+var (
+	ErrUnknownCarrier  = errors.New("unknown carrier")
+	ErrUnknownTracking = errors.New("unknown tracking number")
+)
+
+// Carrier 物流商介面，新增物流商時實作此介面並註冊到 Registry
+type Carrier interface {
+	// Name 物流商代碼，對應 Shipment.Carrier
+	Name() string
+	// Register 向物流商登記貨件，返回追蹤號碼；已有追蹤號碼時驗證後沿用，
+	// 不屬於該物流商的追蹤號碼返回 ErrUnknownTracking
+	Register(ctx context.Context, shipment *model.Shipment) (string, error)
+	// Track 查詢追蹤事件，依發生時間排序
+	Track(ctx context.Context, trackingNumber string) ([]model.TrackingEvent, error)
+}
+
+// Registry 已註冊的物流商
+type Registry struct {
+	carriers map[string]Carrier
+}
+
+// NewRegistry 創建物流商註冊表
+func NewRegistry(carriers ...Carrier) *Registry {
+	r := &Registry{carriers: make(map[string]Carrier)}
+	for _, c := range carriers {
+		r.carriers[c.Name()] = c
+	}
+	return r
+}
+
+// Get 依代碼獲取物流商
+func (r *Registry) Get(name string) (Carrier, error) {
+	c, ok := r.carriers[name]
+	if !ok {
+		return nil, ErrUnknownCarrier
+	}
+	return c, nil
+}
+
+// Names 已註冊的物流商代碼
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.carriers))
+	for name := range r.carriers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,91 @@
+package carrier
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/model"
+)
+
+// FakeCarrierName 本地模擬物流商代碼
+const FakeCarrierName = "fake"
+
+// fakeProgress 模擬物流商依序推進的狀態
+var fakeProgress = []model.TrackingEvent{
+	{Status: model.ShipmentStatusLabelCreated, Description: "Shipping label created", Location: "Warehouse"},
+	{Status: model.ShipmentStatusInTransit, Description: "Package in transit", Location: "Sorting center"},
+	{Status: model.ShipmentStatusOutForDelivery, Description: "Out for delivery", Location: "Local depot"},
+	{Status: model.ShipmentStatusDelivered, Description: "Delivered", Location: "Recipient address"},
+}
+
+// FakeCarrier 本地模擬物流商，供開發與測試使用。
+// 追蹤號碼包含建立時間，每經過 step 推進一個狀態，因此不需保存狀態、重啟後仍可查詢
+type FakeCarrier struct {
+	step time.Duration
+	now  func() time.Time
+}
+
+// NewFakeCarrier 創建模擬物流商，step 為每個狀態之間的間隔
+func NewFakeCarrier(step time.Duration) *FakeCarrier {
+	if step <= 0 {
+		step = time.Minute
+	}
+	return &FakeCarrier{
+		step: step,
+		now:  time.Now,
+	}
+}
+
+// Name 物流商代碼
+func (c *FakeCarrier) Name() string {
+	return FakeCarrierName
+}
+
+// Register 產生包含建立時間的追蹤號碼，指定的追蹤號碼須為此物流商的格式
+func (c *FakeCarrier) Register(ctx context.Context, shipment *model.Shipment) (string, error) {
+	if shipment.TrackingNumber != "" {
+		if _, err := parseFakeTracking(shipment.TrackingNumber); err != nil {
+			return "", err
+		}
+		return shipment.TrackingNumber, nil
+	}
+	suffix := strings.ToUpper(strings.ReplaceAll(uuid.New().String(), "-", "")[:8])
+	return fmt.Sprintf("FAKE-%d-%s", c.now().UnixMilli(), suffix), nil
+}
+
+// Track 依追蹤號碼中的建立時間計算目前已發生的事件
+func (c *FakeCarrier) Track(ctx context.Context, trackingNumber string) ([]model.TrackingEvent, error) {
+	createdAt, err := parseFakeTracking(trackingNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	elapsed := c.now().Sub(createdAt)
+	events := make([]model.TrackingEvent, 0, len(fakeProgress))
+	for i, event := range fakeProgress {
+		occurredAt := createdAt.Add(time.Duration(i) * c.step)
+		if time.Duration(i)*c.step > elapsed {
+			break
+		}
+		event.OccurredAt = occurredAt
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// parseFakeTracking 解析 FAKE-{建立時間毫秒}-{隨機碼} 格式的追蹤號碼
+func parseFakeTracking(trackingNumber string) (time.Time, error) {
+	parts := strings.Split(trackingNumber, "-")
+	if len(parts) != 3 || parts[0] != "FAKE" || parts[2] == "" {
+		return time.Time{}, ErrUnknownTracking
+	}
+	millis, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || millis <= 0 {
+		return time.Time{}, ErrUnknownTracking
+	}
+	return time.UnixMilli(millis), nil
+}
@@ -0,0 +1,145 @@
+package carrier
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/model"
+)
+
+// fakeAt 建立時間固定於 start、可手動推進的模擬物流商
+func fakeAt(start time.Time, step time.Duration) (*FakeCarrier, *time.Time) {
+	now := start
+	c := NewFakeCarrier(step)
+	c.now = func() time.Time { return now }
+	return c, &now
+}
+
+func TestFakeCarrierRegisterGeneratesTrackingNumber(t *testing.T) {
+	start := time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC)
+	c, _ := fakeAt(start, time.Minute)
+
+	trackingNumber, err := c.Register(context.Background(), &model.Shipment{})
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if !strings.HasPrefix(trackingNumber, "FAKE-") {
+		t.Fatalf("tracking number = %q, want FAKE- prefix", trackingNumber)
+	}
+	createdAt, err := parseFakeTracking(trackingNumber)
+	if err != nil {
+		t.Fatalf("parseFakeTracking(%q): %v", trackingNumber, err)
+	}
+	if !createdAt.Equal(start) {
+		t.Errorf("created at = %s, want %s", createdAt, start)
+	}
+
+	other, err := c.Register(context.Background(), &model.Shipment{})
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if other == trackingNumber {
+		t.Errorf("two registrations returned the same tracking number %q", other)
+	}
+}
+
+func TestFakeCarrierRegisterKeepsValidTrackingNumber(t *testing.T) {
+	c, _ := fakeAt(time.Now(), time.Minute)
+	const trackingNumber = "FAKE-1704096000000-ABCD1234"
+
+	got, err := c.Register(context.Background(), &model.Shipment{TrackingNumber: trackingNumber})
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if got != trackingNumber {
+		t.Errorf("tracking number = %q, want %q", got, trackingNumber)
+	}
+}
+
+func TestFakeCarrierRejectsForeignTrackingNumber(t *testing.T) {
+	c, _ := fakeAt(time.Now(), time.Minute)
+	for _, trackingNumber := range []string{
+		"1Z999AA10123456784",
+		"FAKE",
+		"FAKE-abc-ABCD1234",
+		"FAKE-0-ABCD1234",
+		"FAKE-1704096000000-",
+		"FAKE-1704096000000-ABCD-1234",
+		"fake-1704096000000-ABCD1234",
+	} {
+		if _, err := c.Register(context.Background(), &model.Shipment{TrackingNumber: trackingNumber}); !errors.Is(err, ErrUnknownTracking) {
+			t.Errorf("Register(%q) error = %v, want ErrUnknownTracking", trackingNumber, err)
+		}
+		if _, err := c.Track(context.Background(), trackingNumber); !errors.Is(err, ErrUnknownTracking) {
+			t.Errorf("Track(%q) error = %v, want ErrUnknownTracking", trackingNumber, err)
+		}
+	}
+}
+
+func TestFakeCarrierTrackProgressesEachStep(t *testing.T) {
+	start := time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC)
+	step := 10 * time.Minute
+	c, now := fakeAt(start, step)
+
+	trackingNumber, err := c.Register(context.Background(), &model.Shipment{})
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	for _, tc := range []struct {
+		elapsed time.Duration
+		want    []model.ShipmentStatus
+	}{
+		{0, []model.ShipmentStatus{model.ShipmentStatusLabelCreated}},
+		{step - time.Second, []model.ShipmentStatus{model.ShipmentStatusLabelCreated}},
+		{step, []model.ShipmentStatus{model.ShipmentStatusLabelCreated, model.ShipmentStatusInTransit}},
+		{2 * step, []model.ShipmentStatus{model.ShipmentStatusLabelCreated, model.ShipmentStatusInTransit, model.ShipmentStatusOutForDelivery}},
+		{3 * step, []model.ShipmentStatus{model.ShipmentStatusLabelCreated, model.ShipmentStatusInTransit, model.ShipmentStatusOutForDelivery, model.ShipmentStatusDelivered}},
+		{24 * time.Hour, []model.ShipmentStatus{model.ShipmentStatusLabelCreated, model.ShipmentStatusInTransit, model.ShipmentStatusOutForDelivery, model.ShipmentStatusDelivered}},
+	} {
+		*now = start.Add(tc.elapsed)
+		events, err := c.Track(context.Background(), trackingNumber)
+		if err != nil {
+			t.Fatalf("Track after %s: %v", tc.elapsed, err)
+		}
+		if len(events) != len(tc.want) {
+			t.Fatalf("after %s got %d events, want %d", tc.elapsed, len(events), len(tc.want))
+		}
+		for i, event := range events {
+			if event.Status != tc.want[i] {
+				t.Errorf("after %s event %d status = %s, want %s", tc.elapsed, i, event.Status, tc.want[i])
+			}
+			if want := start.Add(time.Duration(i) * step); !event.OccurredAt.Equal(want) {
+				t.Errorf("after %s event %d occurred at %s, want %s", tc.elapsed, i, event.OccurredAt, want)
+			}
+		}
+	}
+}
+
+func TestFakeCarrierTrackBeforeCreation(t *testing.T) {
+	start := time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC)
+	c, now := fakeAt(start, time.Minute)
+
+	trackingNumber, err := c.Register(context.Background(), &model.Shipment{})
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	// 時鐘回撥時不應返回任何事件
+	*now = start.Add(-time.Minute)
+	events, err := c.Track(context.Background(), trackingNumber)
+	if err != nil {
+		t.Fatalf("Track: %v", err)
+	}
+	if len(events) != 0 {
+		t.Errorf("got %d events before creation, want 0", len(events))
+	}
+}
+
+func TestNewFakeCarrierDefaultsStep(t *testing.T) {
+	if c := NewFakeCarrier(0); c.step != time.Minute {
+		t.Errorf("step = %s, want %s", c.step, time.Minute)
+	}
+}
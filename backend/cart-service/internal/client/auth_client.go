@@ -0,0 +1,136 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+var (
+	ErrNoDefaultAddress = errors.New("no default address")
+	ErrUserNotFound     = errors.New("user not found")
+)
+
+// AuthClient 提供與 auth service 交互的功能
+type AuthClient interface {
+	GetDefaultAddress(ctx context.Context) (*UserAddress, error)
+	GetUser(ctx context.Context, userID string) (*UserInfo, error)
+}
+
+// UserInfo auth service 的用戶資訊
+type UserInfo struct {
+	ID        string    `json:"id"`
+	Username  string    `json:"username"`
+	Email     string    `json:"email"`
+	Role      string    `json:"role"`
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// UserAddress auth service 的用戶地址
+type UserAddress struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	Phone      string `json:"phone"`
+	Street     string `json:"street"`
+	City       string `json:"city"`
+	District   string `json:"district"`
+	PostalCode string `json:"postal_code"`
+	IsDefault  bool   `json:"is_default"`
+}
+
+type authClient struct {
+	baseURL    string
+	httpClient HTTPDoer
+}
+
+// NewAuthClient 創建一個新的 auth service 客戶端
+func NewAuthClient(baseURL string, httpClient HTTPDoer) AuthClient {
+	if httpClient == nil {
+		httpClient = NewResilientClient(ResilientClientConfig{})
+	}
+	return &authClient{
+		baseURL:    baseURL,
+		httpClient: httpClient,
+	}
+}
+
+// GetDefaultAddress 獲取 token 所屬用戶的預設地址，未設定時返回 ErrNoDefaultAddress
+func (c *authClient) GetDefaultAddress(ctx context.Context) (*UserAddress, error) {
+	url := fmt.Sprintf("%s/api/v1/user/addresses", c.baseURL)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request failed: %w", err)
+	}
+	if token, _ := ctx.Value(TokenKey).(string); token != "" {
+		req.Header.Set("Authorization", token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read response body failed: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d, body: %s", resp.StatusCode, string(body))
+	}
+
+	var addresses []UserAddress
+	if err := json.Unmarshal(body, &addresses); err != nil {
+		return nil, fmt.Errorf("unmarshal response failed: %w", err)
+	}
+	for _, address := range addresses {
+		if address.IsDefault {
+			address := address
+			return &address, nil
+		}
+	}
+	return nil, ErrNoDefaultAddress
+}
+
+// GetUser 獲取指定用戶資訊，需使用管理員 token
+func (c *authClient) GetUser(ctx context.Context, userID string) (*UserInfo, error) {
+	url := fmt.Sprintf("%s/api/v1/admin/users/%s", c.baseURL, userID)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request failed: %w", err)
+	}
+	if token, _ := ctx.Value(TokenKey).(string); token != "" {
+		req.Header.Set("Authorization", token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read response body failed: %w", err)
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, ErrUserNotFound
+	default:
+		return nil, fmt.Errorf("unexpected status code: %d, body: %s", resp.StatusCode, string(body))
+	}
+
+	var user UserInfo
+	if err := json.Unmarshal(body, &user); err != nil {
+		return nil, fmt.Errorf("unmarshal response failed: %w", err)
+	}
+	return &user, nil
+}
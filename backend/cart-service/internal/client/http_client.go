@@ -0,0 +1,371 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrCircuitOpen 上游服務熔斷中，請求直接失敗
+	ErrCircuitOpen = errors.New("circuit breaker is open")
+)
+
+type idempotentKey struct{}
+
+// HTTPDoer 發送 HTTP 請求的介面，*http.Client 與 ResilientClient 皆實作此介面
+type HTTPDoer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// BreakerState 熔斷器狀態
+type BreakerState string
+
+const (
+	BreakerClosed   BreakerState = "closed"
+	BreakerOpen     BreakerState = "open"
+	BreakerHalfOpen BreakerState = "half_open"
+)
+
+// MetricsHook 請求指標回呼，可接入日誌或監控系統
+type MetricsHook interface {
+	// ObserveRequest 每次嘗試結束時呼叫，err 為 nil 時 status 為 HTTP 狀態碼
+	ObserveRequest(upstream, method string, attempt, status int, err error, duration time.Duration)
+	// ObserveBreakerState 熔斷器狀態改變時呼叫
+	ObserveBreakerState(upstream string, state BreakerState)
+}
+
+type noopMetrics struct{}
+
+func (noopMetrics) ObserveRequest(string, string, int, int, error, time.Duration) {}
+func (noopMetrics) ObserveBreakerState(string, BreakerState)                      {}
+
+// ResilientClientConfig 彈性 HTTP 客戶端配置
+type ResilientClientConfig struct {
+	Timeout          time.Duration // 單次嘗試的逾時時間
+	MaxRetries       int           // 冪等請求的最大重試次數
+	BaseBackoff      time.Duration // 重試的基礎退避時間
+	MaxBackoff       time.Duration // 重試的最大退避時間
+	FailureThreshold int           // 連續失敗幾次後熔斷
+	OpenTimeout      time.Duration // 熔斷後多久允許試探請求
+	Metrics          MetricsHook
+}
+
+// ResilientClient 具備逾時、重試與熔斷的 HTTP 客戶端，熔斷器依上游主機區分
+type ResilientClient struct {
+	config     ResilientClientConfig
+	httpClient *http.Client
+
+	mu       sync.Mutex
+	breakers map[string]*circuitBreaker
+}
+
+// NewResilientClient 創建彈性 HTTP 客戶端
+func NewResilientClient(config ResilientClientConfig) *ResilientClient {
+	if config.Timeout <= 0 {
+		config.Timeout = 10 * time.Second
+	}
+	if config.MaxRetries < 0 {
+		config.MaxRetries = 0
+	}
+	if config.BaseBackoff <= 0 {
+		config.BaseBackoff = 200 * time.Millisecond
+	}
+	if config.MaxBackoff <= 0 {
+		config.MaxBackoff = 2 * time.Second
+	}
+	if config.FailureThreshold <= 0 {
+		config.FailureThreshold = 5
+	}
+	if config.OpenTimeout <= 0 {
+		config.OpenTimeout = 30 * time.Second
+	}
+	if config.Metrics == nil {
+		config.Metrics = noopMetrics{}
+	}
+
+	return &ResilientClient{
+		config:     config,
+		httpClient: &http.Client{},
+		breakers:   make(map[string]*circuitBreaker),
+	}
+}
+
+// WithIdempotent 標記請求可安全重試，用於語意上為讀取的 POST 請求
+func WithIdempotent(ctx context.Context) context.Context {
+	return context.WithValue(ctx, idempotentKey{}, true)
+}
+
+// WithNonIdempotent 標記請求不可重試，用於語意上為增量的 PUT 請求（如調整庫存）
+func WithNonIdempotent(ctx context.Context) context.Context {
+	return context.WithValue(ctx, idempotentKey{}, false)
+}
+
+// Do 發送請求；冪等請求在網路錯誤、429 或 5xx 時以抖動退避重試，
+// 響應帶有 Retry-After 時改等待該時間，超過 MaxBackoff 則直接返回響應不再重試
+func (c *ResilientClient) Do(req *http.Request) (*http.Response, error) {
+	upstream := req.URL.Host
+	breaker := c.breaker(upstream)
+
+	maxAttempts := 1
+	if isRetryable(req) {
+		maxAttempts += c.config.MaxRetries
+	}
+
+	var lastErr error
+	var retryAfter time.Duration
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		if attempt > 1 {
+			wait := c.backoff(attempt - 1)
+			if retryAfter > 0 {
+				wait, retryAfter = retryAfter, 0
+			}
+			if err := sleepContext(req.Context(), wait); err != nil {
+				return nil, err
+			}
+		}
+
+		if !breaker.allow() {
+			c.config.Metrics.ObserveRequest(upstream, req.Method, attempt, 0, ErrCircuitOpen, 0)
+			return nil, fmt.Errorf("%s: %w", upstream, ErrCircuitOpen)
+		}
+
+		resp, err := c.attempt(req, attempt)
+		if err != nil {
+			breaker.failure()
+			lastErr = err
+			// 呼叫端取消或逾時時不再重試
+			if req.Context().Err() != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if resp.StatusCode >= http.StatusInternalServerError {
+			breaker.failure()
+		} else {
+			breaker.success()
+		}
+
+		if attempt < maxAttempts && shouldRetryStatus(resp.StatusCode) {
+			retryAfter = parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+			if retryAfter > c.config.MaxBackoff {
+				return resp, nil
+			}
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+			lastErr = fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+			continue
+		}
+		return resp, nil
+	}
+
+	return nil, lastErr
+}
+
+// attempt 以單次逾時發送一次請求
+func (c *ResilientClient) attempt(req *http.Request, attempt int) (*http.Response, error) {
+	ctx, cancel := context.WithTimeout(req.Context(), c.config.Timeout)
+	attemptReq := req.Clone(ctx)
+	if req.Body != nil && req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			cancel()
+			return nil, fmt.Errorf("reset request body failed: %w", err)
+		}
+		attemptReq.Body = body
+	}
+
+	start := time.Now()
+	resp, err := c.httpClient.Do(attemptReq)
+	duration := time.Since(start)
+	if err != nil {
+		cancel()
+		c.config.Metrics.ObserveRequest(req.URL.Host, req.Method, attempt, 0, err, duration)
+		return nil, err
+	}
+	c.config.Metrics.ObserveRequest(req.URL.Host, req.Method, attempt, resp.StatusCode, nil, duration)
+
+	// 讀完響應內容後才釋放逾時 context
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// backoff 計算第 n 次重試的退避時間（full jitter）
+func (c *ResilientClient) backoff(retry int) time.Duration {
+	max := c.config.BaseBackoff << uint(retry-1)
+	if max <= 0 || max > c.config.MaxBackoff {
+		max = c.config.MaxBackoff
+	}
+	return time.Duration(rand.Int63n(int64(max)) + 1)
+}
+
+// breaker 取得上游對應的熔斷器
+func (c *ResilientClient) breaker(upstream string) *circuitBreaker {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	b, ok := c.breakers[upstream]
+	if !ok {
+		b = &circuitBreaker{
+			upstream:  upstream,
+			threshold: c.config.FailureThreshold,
+			timeout:   c.config.OpenTimeout,
+			metrics:   c.config.Metrics,
+			state:     BreakerClosed,
+		}
+		c.breakers[upstream] = b
+	}
+	return b
+}
+
+// isRetryable 判斷請求是否可安全重試
+func isRetryable(req *http.Request) bool {
+	if req.Body != nil && req.GetBody == nil {
+		return false
+	}
+	if idempotent, ok := req.Context().Value(idempotentKey{}).(bool); ok {
+		return idempotent
+	}
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return req.Header.Get("Idempotency-Key") != ""
+}
+
+// shouldRetryStatus 判斷狀態碼是否值得重試
+func shouldRetryStatus(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// parseRetryAfter 解析 Retry-After（秒數或 HTTP 日期），無法解析或已過期時返回 0
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds <= 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil && t.After(now) {
+		return t.Sub(now)
+	}
+	return 0
+}
+
+// sleepContext 等待指定時間，context 結束時提前返回
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// cancelOnClose 關閉響應時一併釋放逾時 context
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// circuitBreaker 連續失敗達門檻時熔斷，逾時後允許單一試探請求
+type circuitBreaker struct {
+	upstream  string
+	threshold int
+	timeout   time.Duration
+	metrics   MetricsHook
+
+	mu       sync.Mutex
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+func (b *circuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		if time.Since(b.openedAt) < b.timeout {
+			return false
+		}
+		b.setState(BreakerHalfOpen)
+		b.probing = true
+		return true
+	case BreakerHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+func (b *circuitBreaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = 0
+	b.probing = false
+	if b.state != BreakerClosed {
+		b.setState(BreakerClosed)
+	}
+}
+
+func (b *circuitBreaker) failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.probing = false
+	if b.state == BreakerHalfOpen || (b.state == BreakerClosed && b.failures >= b.threshold) {
+		b.openedAt = time.Now()
+		b.setState(BreakerOpen)
+	}
+}
+
+// setState 需在持有鎖時呼叫
+func (b *circuitBreaker) setState(state BreakerState) {
+	b.state = state
+	b.metrics.ObserveBreakerState(b.upstream, state)
+}
+
+// LogMetrics 以日誌記錄失敗請求與熔斷器狀態變化
+type LogMetrics struct{}
+
+func (LogMetrics) ObserveRequest(upstream, method string, attempt, status int, err error, duration time.Duration) {
+	if err != nil || status >= http.StatusInternalServerError {
+		log.Printf("Upstream %s %s attempt %d failed: status=%d err=%v duration=%s", method, upstream, attempt, status, err, duration)
+	}
+}
+
+func (LogMetrics) ObserveBreakerState(upstream string, state BreakerState) {
+	log.Printf("Circuit breaker for %s is now %s", upstream, state)
+}
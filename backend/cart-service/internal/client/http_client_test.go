@@ -0,0 +1,411 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// recordingMetrics 記錄熔斷器狀態變化
+type recordingMetrics struct {
+	mu     sync.Mutex
+	states []BreakerState
+}
+
+func (m *recordingMetrics) ObserveRequest(string, string, int, int, error, time.Duration) {}
+
+func (m *recordingMetrics) ObserveBreakerState(_ string, state BreakerState) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.states = append(m.states, state)
+}
+
+func (m *recordingMetrics) snapshot() []BreakerState {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]BreakerState(nil), m.states...)
+}
+
+// failingServer 前 failures 次請求以 status 回應，之後回應 200
+func failingServer(t *testing.T, failures int32, status int) (*httptest.Server, *int32) {
+	t.Helper()
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) <= failures {
+			w.WriteHeader(status)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+func testClient(config ResilientClientConfig) *ResilientClient {
+	if config.BaseBackoff == 0 {
+		config.BaseBackoff = time.Millisecond
+	}
+	if config.MaxBackoff == 0 {
+		config.MaxBackoff = 5 * time.Millisecond
+	}
+	return NewResilientClient(config)
+}
+
+func TestResilientClientRetriesIdempotentOn5xx(t *testing.T) {
+	server, calls := failingServer(t, 2, http.StatusServiceUnavailable)
+	client := testClient(ResilientClientConfig{MaxRetries: 3})
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Do returned error: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status = %d, want 200", resp.StatusCode)
+	}
+	if got := atomic.LoadInt32(calls); got != 3 {
+		t.Errorf("calls = %d, want 3", got)
+	}
+}
+
+func TestResilientClientReturnsLastResponseWhenRetriesExhausted(t *testing.T) {
+	server, calls := failingServer(t, 10, http.StatusBadGateway)
+	client := testClient(ResilientClientConfig{MaxRetries: 2})
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Do returned error: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusBadGateway {
+		t.Errorf("status = %d, want 502", resp.StatusCode)
+	}
+	if got := atomic.LoadInt32(calls); got != 3 {
+		t.Errorf("calls = %d, want 3", got)
+	}
+}
+
+func TestResilientClientRetriesIdempotentOnTimeout(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(time.Second):
+			}
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+	client := testClient(ResilientClientConfig{MaxRetries: 1, Timeout: 50 * time.Millisecond})
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Do returned error: %v", err)
+	}
+	resp.Body.Close()
+
+	if got := atomic.LoadInt32(&calls); got != 2 {
+		t.Errorf("calls = %d, want 2", got)
+	}
+}
+
+func TestResilientClientRetriesPostMarkedIdempotent(t *testing.T) {
+	var mu sync.Mutex
+	var bodies []string
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		bodies = append(bodies, string(body))
+		mu.Unlock()
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+	client := testClient(ResilientClientConfig{MaxRetries: 2})
+
+	req, _ := http.NewRequestWithContext(WithIdempotent(context.Background()), http.MethodPost, server.URL, strings.NewReader(`{"ids":["p1"]}`))
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Do returned error: %v", err)
+	}
+	resp.Body.Close()
+
+	if len(bodies) != 2 || bodies[1] != `{"ids":["p1"]}` {
+		t.Errorf("bodies = %q, want the body resent on retry", bodies)
+	}
+}
+
+func TestResilientClientDoesNotRetryNonIdempotentPost(t *testing.T) {
+	server, calls := failingServer(t, 10, http.StatusServiceUnavailable)
+	client := testClient(ResilientClientConfig{MaxRetries: 3})
+
+	req, _ := http.NewRequest(http.MethodPost, server.URL, strings.NewReader(`{}`))
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Do returned error: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want 503", resp.StatusCode)
+	}
+	if got := atomic.LoadInt32(calls); got != 1 {
+		t.Errorf("calls = %d, want 1", got)
+	}
+}
+
+func TestResilientClientDoesNotRetryNonIdempotentPostOnTimeout(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer server.Close()
+	client := testClient(ResilientClientConfig{MaxRetries: 3, Timeout: 50 * time.Millisecond})
+
+	req, _ := http.NewRequest(http.MethodPost, server.URL, strings.NewReader(`{}`))
+	if _, err := client.Do(req); err == nil {
+		t.Fatal("Do returned nil error, want timeout")
+	}
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Errorf("calls = %d, want 1", got)
+	}
+}
+
+func TestResilientClientDoesNotRetryPutMarkedNonIdempotent(t *testing.T) {
+	server, calls := failingServer(t, 10, http.StatusBadGateway)
+	client := testClient(ResilientClientConfig{MaxRetries: 3})
+
+	req, _ := http.NewRequestWithContext(WithNonIdempotent(context.Background()), http.MethodPut, server.URL, strings.NewReader(`{"quantity":1}`))
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Do returned error: %v", err)
+	}
+	resp.Body.Close()
+
+	if got := atomic.LoadInt32(calls); got != 1 {
+		t.Errorf("calls = %d, want 1", got)
+	}
+}
+
+func TestCircuitBreakerOpensAndHalfOpens(t *testing.T) {
+	var failing atomic.Bool
+	failing.Store(true)
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if failing.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+	metrics := &recordingMetrics{}
+	client := testClient(ResilientClientConfig{FailureThreshold: 3, OpenTimeout: 50 * time.Millisecond, Metrics: metrics})
+
+	get := func() (*http.Response, error) {
+		req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+		resp, err := client.Do(req)
+		if resp != nil {
+			resp.Body.Close()
+		}
+		return resp, err
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, err := get(); err != nil {
+			t.Fatalf("request %d returned error: %v", i+1, err)
+		}
+	}
+	if _, err := get(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("err = %v, want ErrCircuitOpen after 3 failures", err)
+	}
+	if got := atomic.LoadInt32(&calls); got != 3 {
+		t.Errorf("calls = %d, want 3; open breaker must not reach upstream", got)
+	}
+
+	// 熔斷逾時後允許一個試探請求，試探失敗時重新熔斷
+	time.Sleep(60 * time.Millisecond)
+	if _, err := get(); err != nil {
+		t.Fatalf("half-open probe returned error: %v", err)
+	}
+	if _, err := get(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("err = %v, want ErrCircuitOpen after failed probe", err)
+	}
+
+	// 試探成功時關閉熔斷器
+	failing.Store(false)
+	time.Sleep(60 * time.Millisecond)
+	resp, err := get()
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("probe = %v, %v; want 200", resp, err)
+	}
+	if _, err := get(); err != nil {
+		t.Fatalf("request after recovery returned error: %v", err)
+	}
+
+	want := []BreakerState{BreakerOpen, BreakerHalfOpen, BreakerOpen, BreakerHalfOpen, BreakerClosed}
+	got := metrics.snapshot()
+	if len(got) != len(want) {
+		t.Fatalf("states = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("states = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestCircuitBreakerAllowsSingleHalfOpenProbe(t *testing.T) {
+	b := &circuitBreaker{threshold: 1, timeout: 10 * time.Millisecond, metrics: noopMetrics{}, state: BreakerClosed}
+	b.failure()
+	if b.allow() {
+		t.Fatal("allow() = true while open")
+	}
+	time.Sleep(15 * time.Millisecond)
+	if !b.allow() {
+		t.Fatal("allow() = false after open timeout, want a probe")
+	}
+	if b.allow() {
+		t.Fatal("allow() = true for a second concurrent probe")
+	}
+}
+
+func TestResilientClientHonorsRetryAfter(t *testing.T) {
+	var mu sync.Mutex
+	var times []time.Time
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		times = append(times, time.Now())
+		first := len(times) == 1
+		mu.Unlock()
+		if first {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+	client := testClient(ResilientClientConfig{MaxRetries: 1, MaxBackoff: 2 * time.Second})
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Do returned error: %v", err)
+	}
+	resp.Body.Close()
+
+	if len(times) != 2 {
+		t.Fatalf("calls = %d, want 2", len(times))
+	}
+	if waited := times[1].Sub(times[0]); waited < 900*time.Millisecond {
+		t.Errorf("waited %s before retry, want Retry-After of 1s", waited)
+	}
+}
+
+func TestResilientClientReturnsResponseWhenRetryAfterExceedsMaxBackoff(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.Header().Set("Retry-After", "120")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	client := testClient(ResilientClientConfig{MaxRetries: 3})
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Do returned error: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want 503", resp.StatusCode)
+	}
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Errorf("calls = %d, want 1", got)
+	}
+}
+
+func TestResilientClientBackoffStopsOnContextCancel(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		retryAfter string
+	}{
+		{name: "retry-after", retryAfter: "30"},
+		{name: "backoff"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var calls int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&calls, 1)
+				if tc.retryAfter != "" {
+					w.Header().Set("Retry-After", tc.retryAfter)
+				}
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}))
+			defer server.Close()
+			client := testClient(ResilientClientConfig{MaxRetries: 3, BaseBackoff: 30 * time.Second, MaxBackoff: time.Minute})
+
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+			req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+
+			start := time.Now()
+			_, err := client.Do(req)
+			if !errors.Is(err, context.DeadlineExceeded) {
+				t.Fatalf("err = %v, want context.DeadlineExceeded", err)
+			}
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Errorf("Do took %s, want it to stop waiting when the context ends", elapsed)
+			}
+			if got := atomic.LoadInt32(&calls); got != 1 {
+				t.Errorf("calls = %d, want 1", got)
+			}
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"5", 5 * time.Second},
+		{"0", 0},
+		{"-1", 0},
+		{"soon", 0},
+		{now.Add(10 * time.Second).Format(http.TimeFormat), 10 * time.Second},
+		{now.Add(-10 * time.Second).Format(http.TimeFormat), 0},
+	} {
+		if got := parseRetryAfter(tc.value, now); got != tc.want {
+			t.Errorf("parseRetryAfter(%q) = %s, want %s", tc.value, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// NotificationClient 提供與通知服務交互的功能
+type NotificationClient interface {
+	SendTemplate(ctx context.Context, req *TemplateNotificationRequest) error
+}
+
+// TemplateNotificationRequest 對應通知服務的 /notifications/template 請求
+type TemplateNotificationRequest struct {
+	UserID     string                 `json:"userId"`
+	TemplateID string                 `json:"templateId"`
+	Priority   string                 `json:"priority"`
+	Variables  map[string]interface{} `json:"variables"`
+	Metadata   map[string]interface{} `json:"metadata,omitempty"`
+	MaxRetries int                    `json:"maxRetries,omitempty"`
+}
+
+type notificationClient struct {
+	baseURL       string
+	serviceSecret string
+	httpClient    HTTPDoer
+}
+
+// NewNotificationClient 創建一個新的通知服務客戶端
+func NewNotificationClient(baseURL, serviceSecret string, httpClient HTTPDoer) NotificationClient {
+	if httpClient == nil {
+		httpClient = NewResilientClient(ResilientClientConfig{})
+	}
+	return &notificationClient{
+		baseURL:       baseURL,
+		serviceSecret: serviceSecret,
+		httpClient:    httpClient,
+	}
+}
+
+// SendTemplate 以模板建立通知
+func (c *notificationClient) SendTemplate(ctx context.Context, req *TemplateNotificationRequest) error {
+	url := fmt.Sprintf("%s/api/v1/notifications/template", c.baseURL)
+
+	jsonData, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("marshal request failed: %w", err)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("create request failed: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	// 優先使用請求帶來的用戶 token，背景任務則簽發服務 token
+	token, _ := ctx.Value(TokenKey).(string)
+	if token == "" {
+		token, err = SignServiceToken(c.serviceSecret, "cart-service")
+		if err != nil {
+			return fmt.Errorf("sign service token failed: %w", err)
+		}
+	}
+	httpReq.Header.Set("Authorization", token)
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("unexpected status code: %d, body: %s", resp.StatusCode, string(body))
+	}
+
+	return nil
+}
@@ -1,77 +0,0 @@
-package client
-
-import (
-	"bytes"
-	"context"
-	"encoding/json"
-	"fmt"
-	"io"
-	"net/http"
-	"time"
-)
-
-type OrderClient interface {
-	CreateOrder(ctx context.Context, req *CreateOrderRequest) (*CreateOrderResponse, error)
-}
-
-type CreateOrderRequest struct {
-	UserID string         `json:"userId"`
-	Items  []CartItemInfo `json:"items"`
-}
-
-type CartItemInfo struct {
-	ProductID string  `json:"productId"`
-	Quantity  int     `json:"quantity"`
-	Price     float64 `json:"price"`
-}
-
-type CreateOrderResponse struct {
-	OrderID     string    `json:"orderId"`
-	TotalAmount float64   `json:"totalAmount"`
-	Status      string    `json:"status"`
-	CreatedAt   time.Time `json:"createdAt"`
-}
-
-type orderClient struct {
-	baseURL string
-}
-
-func NewOrderClient(baseURL string) OrderClient {
-	return &orderClient{
-		baseURL: baseURL,
-	}
-}
-
-func (c *orderClient) CreateOrder(ctx context.Context, req *CreateOrderRequest) (*CreateOrderResponse, error) {
-	url := fmt.Sprintf("%s/api/v1/orders", c.baseURL)
-
-	jsonData, err := json.Marshal(req)
-	if err != nil {
-		return nil, fmt.Errorf("marshal request failed: %w", err)
-	}
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("create request failed: %w", err)
-	}
-	httpReq.Header.Set("Content-Type", "application/json")
-	token, _ := ctx.Value(TokenKey).(string) // 從 context 獲取 token
-	httpReq.Header.Set("Authorization", token)
-
-	resp, err := http.DefaultClient.Do(httpReq) // 使用 httpReq 而不是 request
-	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("unexpected status code: %d, body: %s", resp.StatusCode, string(body))
-	}
-
-	var response CreateOrderResponse
-	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
-		return nil, fmt.Errorf("decode response failed: %w", err)
-	}
-
-	return &response, nil
-}
@@ -0,0 +1,210 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+var (
+	ErrPaymentNotFound      = errors.New("payment not found")
+	ErrInvalidPaymentStatus = errors.New("invalid payment status")
+)
+
+// 支付狀態（與 payment service 一致）
+const (
+	PaymentStatusPending           = "pending"
+	PaymentStatusSuccess           = "success"
+	PaymentStatusFailed            = "failed"
+	PaymentStatusRefunded          = "refunded"
+	PaymentStatusCancelled         = "cancelled"
+	PaymentStatusPartiallyRefunded = "partially_refunded"
+)
+
+// PaymentClient 提供與支付服務交互的功能
+type PaymentClient interface {
+	CreatePayment(ctx context.Context, req *CreatePaymentRequest) (*PaymentInfo, error)
+	GetPayment(ctx context.Context, paymentID string) (*PaymentInfo, error)
+	GetPaymentByOrderID(ctx context.Context, orderID string) (*PaymentInfo, error)
+	GetPaymentsByOrderIDs(ctx context.Context, orderIDs []string) (map[string]*PaymentInfo, error)
+	ProcessPayment(ctx context.Context, paymentID string) error
+	CancelPayment(ctx context.Context, paymentID string) error
+	RefundPayment(ctx context.Context, req *RefundPaymentRequest) error
+}
+
+// CreatePaymentRequest 創建支付請求
+type CreatePaymentRequest struct {
+	OrderID  string  `json:"orderId"`
+	Amount   float64 `json:"amount"`
+	Currency string  `json:"currency"`
+	Method   string  `json:"method"`
+}
+
+// RefundPaymentRequest 退款請求
+type RefundPaymentRequest struct {
+	PaymentID      string  `json:"paymentId"`
+	Amount         float64 `json:"amount"`
+	Reason         string  `json:"reason"`
+	IdempotencyKey string  `json:"-"` // 設定時以此作為 Idempotency-Key，重試不會重複退款
+}
+
+// PaymentInfo 支付資訊
+type PaymentInfo struct {
+	ID             string    `json:"id"`
+	OrderID        string    `json:"orderId"`
+	UserID         string    `json:"userId"`
+	Amount         float64   `json:"amount"`
+	RefundedAmount float64   `json:"refundedAmount,omitempty"`
+	Currency       string    `json:"currency"`
+	Status         string    `json:"status"`
+	Method         string    `json:"method"`
+	TransactionID  string    `json:"transactionId"`
+	ErrorMessage   string    `json:"errorMessage,omitempty"`
+	CreatedAt      time.Time `json:"createdAt"`
+	UpdatedAt      time.Time `json:"updatedAt"`
+}
+
+type paymentClient struct {
+	baseURL    string
+	httpClient HTTPDoer
+}
+
+// NewPaymentClient 創建一個新的支付服務客戶端
+func NewPaymentClient(baseURL string, httpClient HTTPDoer) PaymentClient {
+	if httpClient == nil {
+		httpClient = NewResilientClient(ResilientClientConfig{})
+	}
+	return &paymentClient{
+		baseURL:    baseURL,
+		httpClient: httpClient,
+	}
+}
+
+// CreatePayment 創建支付，以訂單ID作為 Idempotency-Key，重試時不會重複建立
+func (c *paymentClient) CreatePayment(ctx context.Context, req *CreatePaymentRequest) (*PaymentInfo, error) {
+	var payment PaymentInfo
+	if err := c.do(ctx, http.MethodPost, "/api/v1/payments/", req, &payment, "order-"+req.OrderID); err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+// GetPayment 獲取支付詳情
+func (c *paymentClient) GetPayment(ctx context.Context, paymentID string) (*PaymentInfo, error) {
+	var payment PaymentInfo
+	if err := c.do(ctx, http.MethodGet, fmt.Sprintf("/api/v1/payments/%s", paymentID), nil, &payment); err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+// GetPaymentByOrderID 根據訂單ID獲取支付
+func (c *paymentClient) GetPaymentByOrderID(ctx context.Context, orderID string) (*PaymentInfo, error) {
+	var payment PaymentInfo
+	if err := c.do(ctx, http.MethodGet, fmt.Sprintf("/api/v1/payments/order/%s", orderID), nil, &payment); err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+// maxPaymentBatch payment service 批次查詢一次可帶的訂單數
+const maxPaymentBatch = 100
+
+// GetPaymentsByOrderIDs 依訂單ID批次獲取支付，每批最多 maxPaymentBatch 筆；沒有支付的訂單不會出現在結果中
+func (c *paymentClient) GetPaymentsByOrderIDs(ctx context.Context, orderIDs []string) (map[string]*PaymentInfo, error) {
+	result := make(map[string]*PaymentInfo, len(orderIDs))
+	for start := 0; start < len(orderIDs); start += maxPaymentBatch {
+		end := start + maxPaymentBatch
+		if end > len(orderIDs) {
+			end = len(orderIDs)
+		}
+		var resp struct {
+			Payments map[string]PaymentInfo `json:"payments"`
+		}
+		// 批次查詢為唯讀操作，可安全重試
+		if err := c.do(WithIdempotent(ctx), http.MethodPost, "/api/v1/payments/order/batch", map[string][]string{"orderIds": orderIDs[start:end]}, &resp); err != nil {
+			return nil, err
+		}
+		for orderID, payment := range resp.Payments {
+			payment := payment
+			result[orderID] = &payment
+		}
+	}
+	return result, nil
+}
+
+// ProcessPayment 處理支付，結果需再查詢支付狀態
+func (c *paymentClient) ProcessPayment(ctx context.Context, paymentID string) error {
+	return c.do(ctx, http.MethodPost, fmt.Sprintf("/api/v1/payments/%s/process", paymentID), nil, nil)
+}
+
+// CancelPayment 取消待處理的支付
+func (c *paymentClient) CancelPayment(ctx context.Context, paymentID string) error {
+	return c.do(ctx, http.MethodPost, fmt.Sprintf("/api/v1/payments/%s/cancel", paymentID), nil, nil)
+}
+
+// RefundPayment 退款
+func (c *paymentClient) RefundPayment(ctx context.Context, req *RefundPaymentRequest) error {
+	if req.IdempotencyKey != "" {
+		return c.do(ctx, http.MethodPost, "/api/v1/payments/refund", req, nil, req.IdempotencyKey)
+	}
+	return c.do(ctx, http.MethodPost, "/api/v1/payments/refund", req, nil)
+}
+
+// do 發送請求並解析響應，404 與 400 分別轉換為 ErrPaymentNotFound 與 ErrInvalidPaymentStatus
+func (c *paymentClient) do(ctx context.Context, method, path string, payload, result interface{}, idempotencyKey ...string) error {
+	var body io.Reader
+	if payload != nil {
+		jsonData, err := json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("marshal request failed: %w", err)
+		}
+		body = bytes.NewBuffer(jsonData)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return fmt.Errorf("create request failed: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if len(idempotencyKey) > 0 {
+		req.Header.Set("Idempotency-Key", idempotencyKey[0])
+	}
+	if token, _ := ctx.Value(TokenKey).(string); token != "" {
+		req.Header.Set("Authorization", token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("read response body failed: %w", err)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusCreated:
+	case http.StatusNotFound:
+		return ErrPaymentNotFound
+	case http.StatusBadRequest:
+		return fmt.Errorf("%w: %s", ErrInvalidPaymentStatus, string(respBody))
+	default:
+		return fmt.Errorf("unexpected status code: %d, body: %s", resp.StatusCode, string(respBody))
+	}
+
+	if result == nil {
+		return nil
+	}
+	if err := json.Unmarshal(respBody, result); err != nil {
+		return fmt.Errorf("unmarshal response failed: %w", err)
+	}
+	return nil
+}
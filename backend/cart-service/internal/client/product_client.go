@@ -1,16 +1,23 @@
 package client
 
 import (
+	"bytes"
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"log"
 	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/model"
+	"golang.org/x/sync/singleflight"
 )
 
 // ContextKey 自定義 context key 類型
@@ -21,11 +28,30 @@ const (
 	TokenKey ContextKey = "token"
 )
 
+// productFetchTimeout 合併後批次查詢的逾時時間；查詢不隨單一呼叫端取消，需自行設定上限
+const productFetchTimeout = 30 * time.Second
+
+// maxProductBatch product service 批次查詢一次可帶的商品數
+const maxProductBatch = 100
+
+type freshProductsKey struct{}
+
+var (
+	ErrProductNotFound   = errors.New("product not found")
+	ErrInsufficientStock = errors.New("insufficient stock")
+	ErrReservationClosed = errors.New("stock reservation is no longer pending")
+)
+
 // ProductClient 提供與產品服務交互的功能
 type ProductClient interface {
 	GetProduct(ctx context.Context, productID string) (*ProductInfo, error)
+	GetProducts(ctx context.Context, productIDs []string) (map[string]*ProductInfo, error)
 	GetProductImageAsBase64(ctx context.Context, imageURL string) (string, error)
 	GetProductById(ctx context.Context, productId string) (*model.ProductInfo, error)
+	ReserveStock(ctx context.Context, req *ReserveStockRequest) (*StockReservation, error)
+	CommitReservation(ctx context.Context, reservationID string) (*StockReservation, error)
+	ReleaseReservation(ctx context.Context, reservationID string) (*StockReservation, error)
+	RestockProduct(ctx context.Context, productID string, quantity int) error
 }
 
 type ProductImage struct {
@@ -44,32 +70,212 @@ type ProductInfo struct {
 	Description string         `json:"description"`
 	Price       float64        `json:"price"`
 	Stock       int            `json:"stock"`
+	Weight      float64        `json:"weight"`
 	Status      string         `json:"status"`
 	CategoryID  string         `json:"categoryId"`
+	SellerID    string         `json:"sellerId"`
+	WarehouseID string         `json:"warehouseId"`
 	Images      []ProductImage `json:"images"`
 	Attributes  []interface{}  `json:"attributes"`
 	CreatedAt   string         `json:"createdAt"`
 	UpdatedAt   string         `json:"updatedAt"`
 }
 
+// ToModel 轉換為收藏清單使用的簡化商品資訊
+func (p *ProductInfo) ToModel() *model.ProductInfo {
+	images := make([]string, 0, len(p.Images))
+	for _, img := range p.Images {
+		if img.URL != "" {
+			images = append(images, img.URL)
+		}
+	}
+
+	return &model.ProductInfo{
+		ID:        p.ID,
+		Name:      p.Name,
+		Price:     p.Price,
+		Images:    images,
+		CreatedAt: p.CreatedAt,
+		UpdatedAt: p.UpdatedAt,
+	}
+}
+
+// productCacheEntry 商品快取項目
+type productCacheEntry struct {
+	product   ProductInfo
+	expiresAt time.Time
+}
+
 // productClient 實現 ProductClient 接口
 type productClient struct {
-	baseURL    string
-	httpClient *http.Client
+	baseURL       string
+	serviceSecret string
+	httpClient    HTTPDoer
+
+	cacheTTL time.Duration
+	cacheMu  sync.RWMutex
+	cache    map[string]productCacheEntry
+	group    singleflight.Group
 }
 
-// NewProductClient 創建一個新的產品客戶端
-func NewProductClient(baseURL string) ProductClient {
+// NewProductClient 創建一個新的產品客戶端，cacheTTL 為批次查詢的快取時間；
+// 庫存預留只接受服務 token，以 serviceSecret 簽發
+func NewProductClient(baseURL, serviceSecret string, httpClient HTTPDoer, cacheTTL time.Duration) ProductClient {
 	// 如果沒有提供 baseURL，使用默認值
 	if baseURL == "" {
 		baseURL = "https://ordermanagersystem-product-service.onrender.com"
 		fmt.Printf("No PRODUCT_SERVICE_URL provided, using default: %s", baseURL)
 	}
+	if httpClient == nil {
+		httpClient = NewResilientClient(ResilientClientConfig{})
+	}
 
 	return &productClient{
-		baseURL:    baseURL,
-		httpClient: &http.Client{},
+		baseURL:       baseURL,
+		serviceSecret: serviceSecret,
+		httpClient:    httpClient,
+		cacheTTL:      cacheTTL,
+		cache:         make(map[string]productCacheEntry),
+	}
+}
+
+// WithFreshProducts 標記 GetProducts 略過快取，用於依庫存決定可購買數量的查詢；
+// 查詢結果仍會寫入快取
+func WithFreshProducts(ctx context.Context) context.Context {
+	return context.WithValue(ctx, freshProductsKey{}, true)
+}
+
+// GetProducts 批次獲取商品，先查短效快取，未命中的商品以一次 HTTP 請求取得
+// 相同商品集合的並行請求會透過 singleflight 合併；不存在的商品不會出現在結果中
+func (c *productClient) GetProducts(ctx context.Context, productIDs []string) (map[string]*ProductInfo, error) {
+	result := make(map[string]*ProductInfo, len(productIDs))
+	missing := make([]string, 0, len(productIDs))
+	seen := make(map[string]bool, len(productIDs))
+	fresh, _ := ctx.Value(freshProductsKey{}).(bool)
+
+	now := time.Now()
+	c.cacheMu.RLock()
+	for _, id := range productIDs {
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		if entry, ok := c.cache[id]; ok && !fresh && now.Before(entry.expiresAt) {
+			product := entry.product
+			result[id] = &product
+			continue
+		}
+		missing = append(missing, id)
+	}
+	c.cacheMu.RUnlock()
+
+	if len(missing) == 0 {
+		return result, nil
+	}
+
+	sort.Strings(missing)
+	// 合併的查詢由所有等待者共用，不能因第一個呼叫端取消而讓其他呼叫端一起失敗，
+	// 因此以脫離取消的 context 執行，呼叫端只在自己的 context 結束時放棄等待
+	ch := c.group.DoChan(strings.Join(missing, ","), func() (interface{}, error) {
+		fetchCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), productFetchTimeout)
+		defer cancel()
+		// 超過批次上限時分批查詢後合併
+		var products []ProductInfo
+		for start := 0; start < len(missing); start += maxProductBatch {
+			end := start + maxProductBatch
+			if end > len(missing) {
+				end = len(missing)
+			}
+			batch, err := c.fetchProducts(fetchCtx, missing[start:end])
+			if err != nil {
+				return nil, err
+			}
+			products = append(products, batch...)
+		}
+		return products, nil
+	})
+	var res singleflight.Result
+	select {
+	case res = <-ch:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if res.Err != nil {
+		return nil, res.Err
+	}
+
+	for _, product := range res.Val.([]ProductInfo) {
+		product := product
+		result[product.ID] = &product
+	}
+	return result, nil
+}
+
+// fetchProducts 調用 product service 的批次查詢接口並寫入快取
+func (c *productClient) fetchProducts(ctx context.Context, productIDs []string) ([]ProductInfo, error) {
+	url := fmt.Sprintf("%s/api/v1/products/batch", c.baseURL)
+
+	jsonData, err := json.Marshal(map[string][]string{"ids": productIDs})
+	if err != nil {
+		return nil, fmt.Errorf("marshal request failed: %w", err)
+	}
+	// 批次查詢為唯讀操作，可安全重試
+	req, err := http.NewRequestWithContext(WithIdempotent(ctx), http.MethodPost, url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("create request failed: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token := ctx.Value(TokenKey); token != nil {
+		req.Header.Set("Authorization", fmt.Sprintf("%v", token))
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read response body failed: %w", err)
+	}
+
+	log.Printf("Product service batch response status: %d, requested: %d", resp.StatusCode, len(productIDs))
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusUnauthorized:
+		return nil, fmt.Errorf("unauthorized: %s", string(body))
+	default:
+		return nil, fmt.Errorf("unexpected status code: %d, body: %s", resp.StatusCode, string(body))
+	}
+
+	var response struct {
+		Data struct {
+			Products []ProductInfo `json:"products"`
+			Missing  []string      `json:"missing"`
+		} `json:"data"`
+		Success bool `json:"success"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("unmarshal response failed: %w", err)
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(c.cacheTTL)
+	c.cacheMu.Lock()
+	// 順便清除過期項目，避免快取無限成長
+	for id, entry := range c.cache {
+		if !now.Before(entry.expiresAt) {
+			delete(c.cache, id)
+		}
+	}
+	for _, product := range response.Data.Products {
+		c.cache[product.ID] = productCacheEntry{product: product, expiresAt: expiresAt}
 	}
+	c.cacheMu.Unlock()
+
+	return response.Data.Products, nil
 }
 
 func (c *productClient) GetProduct(ctx context.Context, productID string) (*ProductInfo, error) {
@@ -287,3 +493,135 @@ func (c *productClient) GetProductById(ctx context.Context, productId string) (*
 	log.Printf("Successfully parsed product info: %+v", productInfo)
 	return productInfo, nil
 }
+
+// ReservationItem 預留的商品與數量
+type ReservationItem struct {
+	ProductID string `json:"productId"`
+	Quantity  int    `json:"quantity"`
+}
+
+// ReserveStockRequest 預留庫存請求
+type ReserveStockRequest struct {
+	OrderID    string            `json:"orderId"`
+	Items      []ReservationItem `json:"items"`
+	TTLSeconds int               `json:"ttlSeconds,omitempty"`
+}
+
+// StockReservation 庫存預留
+type StockReservation struct {
+	ID        string            `json:"id"`
+	OrderID   string            `json:"orderId"`
+	Items     []ReservationItem `json:"items"`
+	Status    string            `json:"status"`
+	ExpiresAt time.Time         `json:"expiresAt"`
+}
+
+// ReserveStock 向 product service 預留庫存
+func (c *productClient) ReserveStock(ctx context.Context, req *ReserveStockRequest) (*StockReservation, error) {
+	return c.reservationRequest(ctx, "/api/v1/stock/reservations/", req)
+}
+
+// CommitReservation 確認庫存預留（重複確認為冪等操作）
+func (c *productClient) CommitReservation(ctx context.Context, reservationID string) (*StockReservation, error) {
+	return c.reservationRequest(WithIdempotent(ctx), fmt.Sprintf("/api/v1/stock/reservations/%s/commit", reservationID), nil)
+}
+
+// ReleaseReservation 釋放庫存預留（重複釋放為冪等操作）
+func (c *productClient) ReleaseReservation(ctx context.Context, reservationID string) (*StockReservation, error) {
+	return c.reservationRequest(WithIdempotent(ctx), fmt.Sprintf("/api/v1/stock/reservations/%s/release", reservationID), nil)
+}
+
+// RestockProduct 將退回的商品加回庫存；quantity 為增量，請求不重試以免重複入庫
+func (c *productClient) RestockProduct(ctx context.Context, productID string, quantity int) error {
+	url := fmt.Sprintf("%s/api/v1/products/%s/stock", c.baseURL, productID)
+
+	jsonData, err := json.Marshal(map[string]int{"quantity": quantity})
+	if err != nil {
+		return fmt.Errorf("marshal request failed: %w", err)
+	}
+	req, err := http.NewRequestWithContext(WithNonIdempotent(ctx), http.MethodPut, url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("create request failed: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token := ctx.Value(TokenKey); token != nil {
+		req.Header.Set("Authorization", fmt.Sprintf("%v", token))
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("read response body failed: %w", err)
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusNotFound:
+		return fmt.Errorf("%w: %s", ErrProductNotFound, string(respBody))
+	default:
+		return fmt.Errorf("unexpected status code: %d, body: %s", resp.StatusCode, string(respBody))
+	}
+}
+
+// reservationRequest 調用庫存預留相關接口，409 轉換為 ErrInsufficientStock 或 ErrReservationClosed
+func (c *productClient) reservationRequest(ctx context.Context, path string, payload interface{}) (*StockReservation, error) {
+	url := c.baseURL + path
+
+	var body io.Reader
+	if payload != nil {
+		jsonData, err := json.Marshal(payload)
+		if err != nil {
+			return nil, fmt.Errorf("marshal request failed: %w", err)
+		}
+		body = bytes.NewBuffer(jsonData)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, body)
+	if err != nil {
+		return nil, fmt.Errorf("create request failed: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	// 預留路由只接受服務或管理員 token，不轉送用戶的 token
+	token, err := SignServiceToken(c.serviceSecret, "cart-service")
+	if err != nil {
+		return nil, fmt.Errorf("sign service token failed: %w", err)
+	}
+	req.Header.Set("Authorization", token)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read response body failed: %w", err)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusCreated:
+	case http.StatusNotFound:
+		return nil, fmt.Errorf("%w: %s", ErrProductNotFound, string(respBody))
+	case http.StatusConflict:
+		if payload != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInsufficientStock, string(respBody))
+		}
+		return nil, fmt.Errorf("%w: %s", ErrReservationClosed, string(respBody))
+	default:
+		return nil, fmt.Errorf("unexpected status code: %d, body: %s", resp.StatusCode, string(respBody))
+	}
+
+	var response struct {
+		Data    StockReservation `json:"data"`
+		Success bool             `json:"success"`
+	}
+	if err := json.Unmarshal(respBody, &response); err != nil {
+		return nil, fmt.Errorf("unmarshal response failed: %w", err)
+	}
+	return &response.Data, nil
+}
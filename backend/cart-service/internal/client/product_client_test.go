@@ -0,0 +1,61 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestProductClientSplitsLargeBatches(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		var req struct {
+			IDs []string `json:"ids"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.IDs) == 0 || len(req.IDs) > maxProductBatch {
+			// 與 product service 的 binding:"required,min=1,max=100" 相同
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		products := make([]ProductInfo, 0, len(req.IDs))
+		for _, id := range req.IDs {
+			products = append(products, ProductInfo{ID: id, Name: "product " + id})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"data":    map[string]interface{}{"products": products},
+		})
+	}))
+	defer server.Close()
+
+	c := NewProductClient(server.URL, "", server.Client(), time.Minute)
+	ids := make([]string, 250)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("p%03d", i)
+	}
+
+	products, err := c.GetProducts(context.Background(), ids)
+	if err != nil {
+		t.Fatalf("GetProducts: %v", err)
+	}
+	if len(products) != len(ids) {
+		t.Errorf("got %d products, want %d", len(products), len(ids))
+	}
+	if got := atomic.LoadInt32(&calls); got != 3 {
+		t.Errorf("batch requests = %d, want 3", got)
+	}
+
+	// 已快取的商品不再查詢
+	if _, err := c.GetProducts(context.Background(), ids); err != nil {
+		t.Fatalf("GetProducts from cache: %v", err)
+	}
+	if got := atomic.LoadInt32(&calls); got != 3 {
+		t.Errorf("batch requests after cached lookup = %d, want 3", got)
+	}
+}
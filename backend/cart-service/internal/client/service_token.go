@@ -0,0 +1,30 @@
+package client
+
+import (
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ErrServiceSecretMissing 未設定服務密鑰
+var ErrServiceSecretMissing = errors.New("service jwt secret not configured")
+
+// SignServiceToken 以服務密鑰簽發服務間調用使用的短效 token，subject 為代表的用戶或服務；
+// 服務密鑰與用戶 token 的密鑰分開，下游服務只接受以服務密鑰簽發的 service 角色
+func SignServiceToken(secret, subject string) (string, error) {
+	if secret == "" {
+		return "", ErrServiceSecretMissing
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"iss":  "cart-service",
+		"sub":  subject,
+		"role": "service",
+		"exp":  time.Now().Add(5 * time.Minute).Unix(),
+	})
+	signed, err := token.SignedString([]byte(secret))
+	if err != nil {
+		return "", err
+	}
+	return "Bearer " + signed, nil
+}
@@ -1,8 +1,13 @@
 package config
 
 import (
+	"encoding/json"
 	"log"
 	"os"
+	"strconv"
+	"time"
+
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/model"
 )
 
 // Config 應用配置
@@ -15,14 +20,35 @@ type Config struct {
 		ProjectID       string
 	}
 	JWT struct {
-		Secret string
+		Secret        string
+		ServiceSecret string
 	}
 	ProductService struct {
+		BaseURL  string
+		CacheTTL time.Duration
+	}
+	AuthService struct {
 		BaseURL string
 	}
-	OrderService struct {
+	NotificationService struct {
 		BaseURL string
 	}
+	PaymentService struct {
+		BaseURL string
+	}
+	HTTPClient    HTTPClientConfig
+	Idempotency   IdempotencyConfig
+	Checkout      CheckoutConfig
+	Shipment      ShipmentConfig
+	Return        ReturnConfig
+	Invoice       InvoiceConfig
+	EInvoice      EInvoiceConfig
+	Subscription  SubscriptionConfig
+	Export        ExportConfig
+	Analytics     AnalyticsConfig
+	OrderMessage  OrderMessageConfig
+	AbandonedCart AbandonedCartConfig
+	Pricing       PricingConfig
 }
 
 // ServerConfig 服務器配置
@@ -32,16 +58,18 @@ type ServerConfig struct {
 
 // JWTConfig JWT配置
 type JWTConfig struct {
-	Secret string
+	Secret        string
+	ServiceSecret string // 服務間調用的 token 密鑰，與用戶 token 分開
 }
 
 // ProductServiceConfig 產品服務配置
 type ProductServiceConfig struct {
-	BaseURL string
+	BaseURL  string
+	CacheTTL time.Duration // 批次查詢商品的快取時間
 }
 
-// OrderServiceConfig 訂單服務配置
-type OrderServiceConfig struct {
+// AuthServiceConfig 認證服務配置
+type AuthServiceConfig struct {
 	BaseURL string
 }
 
@@ -51,6 +79,112 @@ type FirebaseConfig struct {
 	ProjectID       string
 }
 
+// NotificationServiceConfig 通知服務配置
+type NotificationServiceConfig struct {
+	BaseURL string
+}
+
+// PaymentServiceConfig 支付服務配置
+type PaymentServiceConfig struct {
+	BaseURL string
+}
+
+// HTTPClientConfig 服務間 HTTP 調用配置（逾時、重試、熔斷）
+type HTTPClientConfig struct {
+	Timeout          time.Duration // 單次請求逾時
+	MaxRetries       int           // 冪等請求重試次數
+	BaseBackoff      time.Duration // 重試基礎退避時間
+	MaxBackoff       time.Duration // 重試最大退避時間
+	FailureThreshold int           // 連續失敗幾次後熔斷
+	OpenTimeout      time.Duration // 熔斷持續時間
+}
+
+// IdempotencyConfig Idempotency-Key 記錄配置
+type IdempotencyConfig struct {
+	TTL time.Duration // 記錄保留時間，期間內的重試會重播原響應
+}
+
+// CheckoutConfig 結帳配置
+type CheckoutConfig struct {
+	ReservationTTL         time.Duration // 庫存預留時間，逾時未付款即取消訂單
+	ExpiryScanInterval     time.Duration // 掃描逾時訂單的間隔
+	DefaultCurrency        string        // 結帳預設幣別
+	ConfirmationTemplateID string        // 訂單確認通知模板ID
+	SagaResumeAfter        time.Duration // 結帳流程多久未更新視為中斷
+	SagaScanInterval       time.Duration // 續跑中斷流程的掃描間隔
+	SagaMaxAttempts        int           // 續跑次數上限
+}
+
+// ShipmentConfig 貨件配置
+type ShipmentConfig struct {
+	SyncInterval    time.Duration // 同步物流商追蹤狀態的間隔
+	FakeCarrier     bool          // 是否啟用本地模擬物流商
+	FakeCarrierStep time.Duration // 模擬物流商每個狀態之間的間隔
+}
+
+// ReturnConfig 退貨配置
+type ReturnConfig struct {
+	Window time.Duration // 送達後可申請退貨的期間
+}
+
+// InvoiceConfig 發票配置
+type InvoiceConfig struct {
+	SellerName    string // 賣方名稱
+	SellerAddress string // 賣方地址
+	SellerTaxID   string // 賣方統一編號
+	FontPath      string // 含中文字符的 UTF-8 TrueType 字型檔路徑，未設定時服務無法啟動
+}
+
+// SubscriptionConfig 定期訂購配置
+type SubscriptionConfig struct {
+	ScanInterval       time.Duration // 排程掃描間隔
+	RemindBefore       time.Duration // 執行前多久發送提醒
+	RetryAfter         time.Duration // 執行失敗後多久重試
+	MaxFailures        int           // 連續失敗次數上限，達到後暫停訂閱
+	ReminderTemplateID string        // 執行前提醒的通知模板ID
+	FailureTemplateID  string        // 執行失敗的通知模板ID
+}
+
+// ExportConfig 訂單匯出配置
+type ExportConfig struct {
+	Dir      string        // 排程匯出的輸出目錄
+	Format   string        // 排程匯出格式，csv 或 xlsx
+	Interval time.Duration // 排程匯出間隔，每次匯出上次之後建立的訂單，0 為停用
+	PageSize int           // 每次讀取的訂單數
+}
+
+// AnalyticsConfig 銷售報表配置
+type AnalyticsConfig struct {
+	Timezone string // 劃分日、週、月的時區
+}
+
+// OrderMessageConfig 訂單訊息配置
+type OrderMessageConfig struct {
+	TemplateID    string // 對方回覆時的通知模板ID
+	SupportUserID string // 尚無客服回覆時，顧客訊息通知的客服帳號
+}
+
+// EInvoiceConfig 電子發票配置
+type EInvoiceConfig struct {
+	Issuer string // 開立平台，fake 為本地模擬，空白時停用開立
+}
+
+// AbandonedCartConfig 棄置購物車偵測配置
+type AbandonedCartConfig struct {
+	IdleTimeout        time.Duration // 購物車閒置多久視為棄置
+	ScanInterval       time.Duration // 背景掃描間隔
+	ReminderTemplateID string        // 通知服務的提醒模板ID
+}
+
+// PricingConfig 計價配置（運費、稅率、折扣規則）
+type PricingConfig struct {
+	DefaultShippingMethod string
+	DefaultTaxRegion      string
+	ShippingRules         []model.ShippingRule
+	TaxRules              []model.TaxRule
+	DiscountRules         []model.DiscountRule
+}
+
 // LoadConfig 加載配置
 func LoadConfig() *Config {
 	return &Config{
@@ -61,10 +195,124 @@ func LoadConfig() *Config {
 			CredentialsFile: os.Getenv("FIREBASE_CREDENTIALS"),
 			ProjectID:       os.Getenv("FIREBASE_PROJECT_ID"),
 		},
+		JWT: JWTConfig{
+			Secret:        os.Getenv("JWT_SECRET"),
+			ServiceSecret: os.Getenv("SERVICE_JWT_SECRET"),
+		},
 		ProductService: ProductServiceConfig{
-			BaseURL: getEnv("PRODUCT_SERVICE_URL", "https://ordermanagersystem-product-service.onrender.com"),
+			BaseURL:  getEnv("PRODUCT_SERVICE_URL", "https://ordermanagersystem-product-service.onrender.com"),
+			CacheTTL: time.Duration(getEnvAsInt("PRODUCT_CACHE_TTL_SECONDS", 10)) * time.Second,
+		},
+		AuthService: AuthServiceConfig{
+			BaseURL: getEnv("AUTH_SERVICE_URL", "https://ordermanagersystem-auth-service.onrender.com"),
+		},
+		NotificationService: NotificationServiceConfig{
+			BaseURL: getEnv("NOTIFICATION_SERVICE_URL", "https://ordermanagersystem-notification-service.onrender.com"),
+		},
+		PaymentService: PaymentServiceConfig{
+			BaseURL: getEnv("PAYMENT_SERVICE_URL", "https://ordermanagersystem-payment-service.onrender.com"),
+		},
+		HTTPClient: HTTPClientConfig{
+			Timeout:          time.Duration(getEnvAsInt("HTTP_CLIENT_TIMEOUT_SECONDS", 10)) * time.Second,
+			MaxRetries:       getEnvAsInt("HTTP_CLIENT_MAX_RETRIES", 2),
+			BaseBackoff:      time.Duration(getEnvAsInt("HTTP_CLIENT_BACKOFF_MS", 200)) * time.Millisecond,
+			MaxBackoff:       time.Duration(getEnvAsInt("HTTP_CLIENT_MAX_BACKOFF_MS", 2000)) * time.Millisecond,
+			FailureThreshold: getEnvAsInt("HTTP_CLIENT_BREAKER_THRESHOLD", 5),
+			OpenTimeout:      time.Duration(getEnvAsInt("HTTP_CLIENT_BREAKER_OPEN_SECONDS", 30)) * time.Second,
+		},
+		Idempotency: IdempotencyConfig{
+			TTL: time.Duration(getEnvAsInt("IDEMPOTENCY_TTL_HOURS", 24)) * time.Hour,
+		},
+		Checkout: CheckoutConfig{
+			ReservationTTL:         time.Duration(getEnvAsInt("RESERVATION_TTL_SECONDS", 15*60)) * time.Second,
+			ExpiryScanInterval:     time.Duration(getEnvAsInt("ORDER_EXPIRY_SCAN_SECONDS", 60)) * time.Second,
+			DefaultCurrency:        getEnv("CHECKOUT_CURRENCY", "TWD"),
+			ConfirmationTemplateID: getEnv("ORDER_CONFIRMATION_TEMPLATE_ID", ""),
+			SagaResumeAfter:        time.Duration(getEnvAsInt("CHECKOUT_SAGA_RESUME_AFTER_SECONDS", 120)) * time.Second,
+			SagaScanInterval:       time.Duration(getEnvAsInt("CHECKOUT_SAGA_SCAN_SECONDS", 60)) * time.Second,
+			SagaMaxAttempts:        getEnvAsInt("CHECKOUT_SAGA_MAX_ATTEMPTS", 5),
+		},
+		Shipment: ShipmentConfig{
+			SyncInterval:    time.Duration(getEnvAsInt("SHIPMENT_SYNC_SECONDS", 300)) * time.Second,
+			FakeCarrier:     getEnv("FAKE_CARRIER_ENABLED", "true") == "true",
+			FakeCarrierStep: time.Duration(getEnvAsInt("FAKE_CARRIER_STEP_MINUTES", 60)) * time.Minute,
+		},
+		Return: ReturnConfig{
+			Window: time.Duration(getEnvAsInt("RETURN_WINDOW_DAYS", 14)) * 24 * time.Hour,
+		},
+		Invoice: InvoiceConfig{
+			SellerName:    getEnv("INVOICE_SELLER_NAME", "OrderManagerSystem"),
+			SellerAddress: getEnv("INVOICE_SELLER_ADDRESS", ""),
+			SellerTaxID:   getEnv("INVOICE_SELLER_TAX_ID", ""),
+			FontPath:      getEnv("INVOICE_FONT_PATH", ""),
+		},
+		EInvoice: EInvoiceConfig{
+			Issuer: getEnv("EINVOICE_ISSUER", "fake"),
+		},
+		Subscription: SubscriptionConfig{
+			ScanInterval:       time.Duration(getEnvAsInt("SUBSCRIPTION_SCAN_MINUTES", 5)) * time.Minute,
+			RemindBefore:       time.Duration(getEnvAsInt("SUBSCRIPTION_REMIND_HOURS", 48)) * time.Hour,
+			RetryAfter:         time.Duration(getEnvAsInt("SUBSCRIPTION_RETRY_HOURS", 24)) * time.Hour,
+			MaxFailures:        getEnvAsInt("SUBSCRIPTION_MAX_FAILURES", 3),
+			ReminderTemplateID: getEnv("SUBSCRIPTION_REMINDER_TEMPLATE_ID", ""),
+			FailureTemplateID:  getEnv("SUBSCRIPTION_FAILED_TEMPLATE_ID", ""),
+		},
+		Export: ExportConfig{
+			Dir:      getEnv("EXPORT_DIR", "exports"),
+			Format:   getEnv("EXPORT_FORMAT", "csv"),
+			Interval: time.Duration(getEnvAsInt("EXPORT_INTERVAL_HOURS", 0)) * time.Hour,
+			PageSize: getEnvAsInt("EXPORT_PAGE_SIZE", 200),
 		},
+		Analytics: AnalyticsConfig{
+			Timezone: getEnv("ANALYTICS_TIMEZONE", "Asia/Taipei"),
+		},
+		OrderMessage: OrderMessageConfig{
+			TemplateID:    getEnv("ORDER_MESSAGE_TEMPLATE_ID", ""),
+			SupportUserID: getEnv("SUPPORT_USER_ID", ""),
+		},
+		AbandonedCart: AbandonedCartConfig{
+			IdleTimeout:        time.Duration(getEnvAsInt("ABANDONED_CART_IDLE_MINUTES", 24*60)) * time.Minute,
+			ScanInterval:       time.Duration(getEnvAsInt("ABANDONED_CART_SCAN_MINUTES", 15)) * time.Minute,
+			ReminderTemplateID: getEnv("ABANDONED_CART_TEMPLATE_ID", ""),
+		},
+		Pricing: PricingConfig{
+			DefaultShippingMethod: getEnv("DEFAULT_SHIPPING_METHOD", "standard"),
+			DefaultTaxRegion:      getEnv("DEFAULT_TAX_REGION", "TW"),
+			ShippingRules: getEnvJSON("SHIPPING_RULES", []model.ShippingRule{
+				{Method: "standard", Type: model.ShippingRuleFreeOver, Fee: 60, FreeThreshold: 1000},
+				{Method: "express", Type: model.ShippingRuleWeight, BaseFee: 100, PerKg: 20},
+				{Method: "store_pickup", Type: model.ShippingRuleFlat, Fee: 0},
+			}),
+			TaxRules: getEnvJSON("TAX_RULES", []model.TaxRule{
+				{Region: "TW", Rate: 0.05, Included: true},
+			}),
+			DiscountRules: getEnvJSON("DISCOUNT_RULES", []model.DiscountRule{}),
+		},
+	}
+}
+
+// getEnvAsInt 獲取整數環境變量，如果不存在或格式錯誤則返回默認值
+func getEnvAsInt(key string, defaultValue int) int {
+	if value, exists := os.LookupEnv(key); exists {
+		if intVal, err := strconv.Atoi(value); err == nil {
+			return intVal
+		}
+	}
+	return defaultValue
+}
+
+// getEnvJSON 從環境變量解析 JSON，如果不存在或格式錯誤則返回默認值
+func getEnvJSON[T any](key string, defaultValue T) T {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	var result T
+	if err := json.Unmarshal([]byte(value), &result); err != nil {
+		log.Printf("Warning: Environment variable %s is not valid JSON: %v", key, err)
+		return defaultValue
 	}
+	return result
 }
 
 // getEnv 獲取環境變量，如果不存在則返回默認值
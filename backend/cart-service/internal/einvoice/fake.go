@@ -0,0 +1,47 @@
+package einvoice
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+)
+
+// FakeIssuerName 本地模擬開立平台代碼
+const FakeIssuerName = "fake"
+
+// FakeIssuer 本地模擬開立平台，供開發與測試使用，開立紀錄只保存在記憶體
+type FakeIssuer struct {
+	mu     sync.Mutex
+	issued map[string]string // 發票號碼 → 訂單ID
+}
+
+// NewFakeIssuer 創建模擬開立平台
+func NewFakeIssuer() *FakeIssuer {
+	return &FakeIssuer{
+		issued: make(map[string]string),
+	}
+}
+
+// Name 平台代碼
+func (f *FakeIssuer) Name() string {
+	return FakeIssuerName
+}
+
+// Issue 檢查開立方式後記錄發票；同一號碼只能對應同一筆訂單
+func (f *FakeIssuer) Issue(ctx context.Context, req *IssueRequest) error {
+	if req.Order.EInvoiceInfo != nil {
+		if err := req.Order.EInvoiceInfo.Validate(); err != nil {
+			return fmt.Errorf("%w: %v", ErrRejected, err)
+		}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if orderID, ok := f.issued[req.Number]; ok && orderID != req.Order.ID {
+		return fmt.Errorf("%w: number %s already issued for order %s", ErrRejected, req.Number, orderID)
+	}
+	f.issued[req.Number] = req.Order.ID
+	log.Printf("Fake e-invoice %s issued for order %s (amount %.0f)", req.Number, req.Order.ID, req.Order.TotalAmount)
+	return nil
+}
@@ -0,0 +1,29 @@
+package einvoice
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/model"
+)
+
+// ErrRejected 開立平台拒絕開立，通常為資料錯誤，重試不會成功
+var ErrRejected = errors.New("e-invoice rejected by issuer")
+
+// IssueRequest 開立電子發票請求
+type IssueRequest struct {
+	Number      string // 已配發的字軌號碼
+	RandomCode  string
+	IssuedAt    time.Time
+	SellerTaxID string
+	Order       *model.Order // 明細、金額與顧客選擇的開立方式
+}
+
+// Issuer 電子發票開立平台介面（例如財政部 Turnkey 或加值服務中心），新增平台時實作此介面
+type Issuer interface {
+	// Name 平台代碼，記錄於 EInvoice.Issuer
+	Name() string
+	// Issue 上傳開立發票；同一號碼重複開立須為冪等操作
+	Issue(ctx context.Context, req *IssueRequest) error
+}
@@ -0,0 +1,184 @@
+package export
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// Format 匯出檔案格式
+type Format string
+
+const (
+	FormatCSV  Format = "csv"
+	FormatXLSX Format = "xlsx"
+)
+
+// ErrUnsupportedFormat 不支援的匯出格式
+var ErrUnsupportedFormat = errors.New("unsupported export format")
+
+// ContentType 返回格式對應的 MIME 類型
+func (f Format) ContentType() string {
+	if f == FormatXLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// RowWriter 逐列寫出表格資料
+type RowWriter interface {
+	// WriteRow 寫出一列，支援 string、int、float64 與 time.Time
+	WriteRow(values ...interface{}) error
+	// Flush 將已寫出的列送出，XLSX 需至 Close 才會輸出
+	Flush() error
+	// Close 完成檔案並寫入底層 io.Writer
+	Close() error
+}
+
+// NewRowWriter 依格式創建 RowWriter
+func NewRowWriter(format Format, w io.Writer) (RowWriter, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w)
+	case FormatXLSX:
+		return newXLSXWriter(w)
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+}
+
+type csvWriter struct {
+	w   io.Writer
+	csv *csv.Writer
+}
+
+func newCSVWriter(w io.Writer) (*csvWriter, error) {
+	// 寫入 UTF-8 BOM，Excel 開啟時才能正確顯示中文
+	if _, err := io.WriteString(w, "\ufeff"); err != nil {
+		return nil, err
+	}
+	return &csvWriter{
+		w:   w,
+		csv: csv.NewWriter(w),
+	}, nil
+}
+
+func (c *csvWriter) WriteRow(values ...interface{}) error {
+	record := make([]string, len(values))
+	for i, value := range values {
+		record[i] = formatValue(value)
+	}
+	return c.csv.Write(record)
+}
+
+func (c *csvWriter) Flush() error {
+	c.csv.Flush()
+	if err := c.csv.Error(); err != nil {
+		return err
+	}
+	// 串流至 HTTP 響應時立即送出
+	if f, ok := c.w.(http.Flusher); ok {
+		f.Flush()
+	}
+	return nil
+}
+
+func (c *csvWriter) Close() error {
+	return c.Flush()
+}
+
+// xlsxWriter 以 excelize 的 StreamWriter 寫出，資料量大時暫存於磁碟而非記憶體
+type xlsxWriter struct {
+	w      io.Writer
+	file   *excelize.File
+	stream *excelize.StreamWriter
+	row    int
+}
+
+const xlsxSheet = "Sheet1"
+
+func newXLSXWriter(w io.Writer) (*xlsxWriter, error) {
+	file := excelize.NewFile()
+	stream, err := file.NewStreamWriter(xlsxSheet)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("error creating xlsx stream: %v", err)
+	}
+	return &xlsxWriter{
+		w:      w,
+		file:   file,
+		stream: stream,
+	}, nil
+}
+
+func (x *xlsxWriter) WriteRow(values ...interface{}) error {
+	x.row++
+	cell, err := excelize.CoordinatesToCellName(1, x.row)
+	if err != nil {
+		return err
+	}
+	row := make([]interface{}, len(values))
+	for i, value := range values {
+		switch v := value.(type) {
+		case time.Time:
+			// 以字串寫出時間，避免試算表依時區轉換
+			value = formatValue(v)
+		case string:
+			value = escapeFormula(v)
+		}
+		row[i] = value
+	}
+	return x.stream.SetRow(cell, row)
+}
+
+func (x *xlsxWriter) Flush() error {
+	return nil
+}
+
+func (x *xlsxWriter) Close() error {
+	defer x.file.Close()
+	if err := x.stream.Flush(); err != nil {
+		return fmt.Errorf("error flushing xlsx stream: %v", err)
+	}
+	if err := x.file.Write(x.w); err != nil {
+		return fmt.Errorf("error writing xlsx: %v", err)
+	}
+	return nil
+}
+
+// formatValue 將欄位值轉為 CSV 字串，文字欄位經 escapeFormula 處理
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return escapeFormula(v)
+	case int:
+		return strconv.Itoa(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', 2, 64)
+	case time.Time:
+		if v.IsZero() {
+			return ""
+		}
+		return v.Format(time.RFC3339)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// escapeFormula 開頭為 = + - @ tab 或 CR 的文字會被試算表視為公式（商品名稱等可由用戶輸入），
+// 加上 ' 前綴避免開啟匯出檔時執行
+func escapeFormula(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
@@ -0,0 +1,31 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/service"
+)
+
+// AbandonedCartHandler 棄置購物車處理器
+type AbandonedCartHandler struct {
+	abandonedCartService service.AbandonedCartService
+}
+
+// NewAbandonedCartHandler 創建新的棄置購物車處理器
+func NewAbandonedCartHandler(abandonedCartService service.AbandonedCartService) *AbandonedCartHandler {
+	return &AbandonedCartHandler{
+		abandonedCartService: abandonedCartService,
+	}
+}
+
+// GetStats 獲取棄置購物車統計
+func (h *AbandonedCartHandler) GetStats(c *gin.Context) {
+	stats, err := h.abandonedCartService.GetStats(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get abandoned cart stats"})
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
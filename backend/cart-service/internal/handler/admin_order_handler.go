@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/client"
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/model"
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/service"
+)
+
+// AdminOrderHandler 管理員訂單處理器
+type AdminOrderHandler struct {
+	adminOrderService service.AdminOrderService
+}
+
+// NewAdminOrderHandler 創建新的管理員訂單處理器
+func NewAdminOrderHandler(adminOrderService service.AdminOrderService) *AdminOrderHandler {
+	return &AdminOrderHandler{
+		adminOrderService: adminOrderService,
+	}
+}
+
+// UpdateOrderStatusRequest 更新訂單狀態請求
+type UpdateOrderStatusRequest struct {
+	Status model.OrderStatus `json:"status" binding:"required"`
+	Reason string            `json:"reason"`
+}
+
+// ListOrders 查詢所有訂單，查詢參數同顧客訂單列表，另可用 userId 指定用戶；未指定 userId 時須提供 from 與 to
+func (h *AdminOrderHandler) ListOrders(c *gin.Context) {
+	query, err := parseOrderQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	query.UserID = c.Query("userId")
+
+	page, err := h.adminOrderService.QueryOrders(c.Request.Context(), query, c.Query("cursor"))
+	if err != nil {
+		switch err {
+		case service.ErrInvalidCursor:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+			return
+		case service.ErrOrderQueryRange:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get orders"})
+		return
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+// GetOrder 獲取訂單詳情，包含支付、顧客資訊與內部備註
+func (h *AdminOrderHandler) GetOrder(c *gin.Context) {
+	ctx := context.WithValue(c.Request.Context(), client.TokenKey, c.GetHeader("Authorization"))
+	detail, err := h.adminOrderService.GetOrderDetail(ctx, c.Param("id"))
+	if err != nil {
+		if err == service.ErrOrderNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get order"})
+		return
+	}
+	c.JSON(http.StatusOK, detail)
+}
+
+// AddNote 新增訂單內部備註
+func (h *AdminOrderHandler) AddNote(c *gin.Context) {
+	var req model.AddOrderNoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	note, err := h.adminOrderService.AddNote(c.Request.Context(), c.Param("id"), c.GetString("userID"), req.Body)
+	if err != nil {
+		if err == service.ErrOrderNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add note"})
+		return
+	}
+	c.JSON(http.StatusCreated, note)
+}
+
+// UpdateOrderStatus 經由狀態機變更訂單狀態
+func (h *AdminOrderHandler) UpdateOrderStatus(c *gin.Context) {
+	var req UpdateOrderStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	ctx := context.WithValue(c.Request.Context(), client.TokenKey, c.GetHeader("Authorization"))
+	if err := h.adminOrderService.UpdateStatus(ctx, c.Param("id"), req.Status, c.GetString("userID"), req.Reason); err != nil {
+		handleOrderStatusError(c, err, "Failed to update order status")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Order status updated successfully"})
+}
+
+// BulkUpdateStatus 批次變更訂單狀態，例如一次將多筆訂單標記為已出貨
+func (h *AdminOrderHandler) BulkUpdateStatus(c *gin.Context) {
+	var req model.BulkOrderStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !req.Status.IsValid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order status"})
+		return
+	}
+
+	ctx := context.WithValue(c.Request.Context(), client.TokenKey, c.GetHeader("Authorization"))
+	c.JSON(http.StatusOK, h.adminOrderService.BulkUpdateStatus(ctx, &req, c.GetString("userID")))
+}
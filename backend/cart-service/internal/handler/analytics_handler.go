@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/model"
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/service"
+)
+
+// AnalyticsHandler 銷售報表處理器
+type AnalyticsHandler struct {
+	analyticsService service.AnalyticsService
+	location         *time.Location // 解析日期參數的時區，與彙總劃分區間的時區一致
+}
+
+// NewAnalyticsHandler 創建新的銷售報表處理器
+func NewAnalyticsHandler(analyticsService service.AnalyticsService, location *time.Location) *AnalyticsHandler {
+	return &AnalyticsHandler{
+		analyticsService: analyticsService,
+		location:         location,
+	}
+}
+
+// GetSalesReport 依 interval（day、week、month，預設 day）返回營收、訂單數與比率
+func (h *AnalyticsHandler) GetSalesReport(c *gin.Context) {
+	from, to, err := h.parseRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	interval := model.AnalyticsInterval(c.DefaultQuery("interval", string(model.AnalyticsIntervalDay)))
+
+	report, err := h.analyticsService.SalesReport(c.Request.Context(), interval, from, to)
+	if err != nil {
+		handleAnalyticsError(c, err, "Failed to get sales report")
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// GetTopProducts 返回熱銷商品，sort 為 revenue 或 quantity
+func (h *AnalyticsHandler) GetTopProducts(c *gin.Context) {
+	from, to, err := h.parseRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	limit, err := parseAnalyticsLimit(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	products, err := h.analyticsService.TopProducts(c.Request.Context(), from, to, c.DefaultQuery("sort", service.AnalyticsSortRevenue), limit)
+	if err != nil {
+		handleAnalyticsError(c, err, "Failed to get top products")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"products": products})
+}
+
+// GetTopCategories 返回熱銷分類，sort 為 revenue 或 quantity
+func (h *AnalyticsHandler) GetTopCategories(c *gin.Context) {
+	from, to, err := h.parseRange(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	limit, err := parseAnalyticsLimit(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	categories, err := h.analyticsService.TopCategories(c.Request.Context(), from, to, c.DefaultQuery("sort", service.AnalyticsSortRevenue), limit)
+	if err != nil {
+		handleAnalyticsError(c, err, "Failed to get top categories")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"categories": categories})
+}
+
+// parseRange 解析 from 與 to（RFC3339 或 YYYY-MM-DD），預設為最近 30 天
+func (h *AnalyticsHandler) parseRange(c *gin.Context) (time.Time, time.Time, error) {
+	to := time.Now()
+	from := to.AddDate(0, 0, -30)
+	if value := c.Query("from"); value != "" {
+		t, err := h.parseTime(value)
+		if err != nil {
+			return from, to, fmt.Errorf("invalid from: %v", err)
+		}
+		from = t
+	}
+	if value := c.Query("to"); value != "" {
+		t, err := h.parseTime(value)
+		if err != nil {
+			return from, to, fmt.Errorf("invalid to: %v", err)
+		}
+		to = t
+	}
+	return from, to, nil
+}
+
+// parseTime 日期格式以報表時區解讀，避免跨日
+func (h *AnalyticsHandler) parseTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation("2006-01-02", value, h.location)
+}
+
+// parseAnalyticsLimit 解析排行筆數，預設 10
+func parseAnalyticsLimit(c *gin.Context) (int, error) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limit <= 0 {
+		return 0, fmt.Errorf("invalid limit")
+	}
+	return limit, nil
+}
+
+// handleAnalyticsError 將報表錯誤轉換為對應的 HTTP 響應
+func handleAnalyticsError(c *gin.Context, err error, fallback string) {
+	if err == service.ErrInvalidAnalyticsQuery {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid interval, sort or date range"})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+}
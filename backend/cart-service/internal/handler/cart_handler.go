@@ -30,9 +30,13 @@ func (h *CartHandler) GetCart(c *gin.Context) {
 		return
 	}
 
-	cart, err := h.cartService.GetCart(c.Request.Context(), userID)
+	cart, err := h.cartService.GetCart(c.Request.Context(), userID, c.Query("shippingMethod"), c.Query("region"))
 	if err != nil {
 		log.Printf("Error getting cart: %v", err)
+		if err == service.ErrInvalidShippingMethod {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -181,8 +185,96 @@ func (h *CartHandler) ClearCart(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Cart cleared successfully"})
 }
 
-// CreateOrder 從購物車創建訂單
-func (h *CartHandler) CreateOrder(c *gin.Context) {
+// SaveForLater 將購物車商品移到稍後購買
+func (h *CartHandler) SaveForLater(c *gin.Context) {
+	userID := c.GetString("userID")
+	productID := c.Param("productId")
+
+	if err := h.cartService.SaveForLater(c.Request.Context(), userID, productID); err != nil {
+		if err == service.ErrItemNotInCart {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Item saved for later successfully"})
+}
+
+// MoveToCart 將稍後購買的商品移回購物車
+func (h *CartHandler) MoveToCart(c *gin.Context) {
+	token := c.GetHeader("Authorization")
+	if token == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "no authorization header"})
+		return
+	}
+
+	ctx := context.WithValue(c.Request.Context(), client.TokenKey, token)
+	userID := c.GetString("userID")
+	productID := c.Param("productId")
+
+	if err := h.cartService.MoveToCart(ctx, userID, productID); err != nil {
+		switch {
+		case err == service.ErrItemNotInCart:
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case err == service.ErrProductNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "product not found"})
+		case err == service.ErrInvalidStock:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case strings.Contains(err.Error(), "unauthorized"):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized access"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Item moved to cart successfully"})
+}
+
+// RemoveSavedItem 從稍後購買中移除商品
+func (h *CartHandler) RemoveSavedItem(c *gin.Context) {
+	userID := c.GetString("userID")
+	productID := c.Param("productId")
+
+	if err := h.cartService.RemoveSavedItem(c.Request.Context(), userID, productID); err != nil {
+		if err == service.ErrItemNotInCart {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Saved item removed successfully"})
+}
+
+// MoveToWishlist 將購物車或稍後購買的商品移到收藏清單
+func (h *CartHandler) MoveToWishlist(c *gin.Context) {
+	userID := c.GetString("userID")
+	productID := c.Param("productId")
+
+	if err := h.cartService.MoveToWishlist(c.Request.Context(), userID, productID); err != nil {
+		if err == service.ErrItemNotInCart {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Item moved to wishlist successfully"})
+}
+
+// BatchUpdate 批次套用購物車操作，一次最多 100 筆，超過時返回 400
+func (h *CartHandler) BatchUpdate(c *gin.Context) {
+	var req model.BatchCartRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	token := c.GetHeader("Authorization")
 	if token == "" {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "no authorization header"})
@@ -192,10 +284,9 @@ func (h *CartHandler) CreateOrder(c *gin.Context) {
 	ctx := context.WithValue(c.Request.Context(), client.TokenKey, token)
 	userID := c.GetString("userID")
 
-	if err := h.cartService.CreateOrder(ctx, userID); err != nil {
+	resp, err := h.cartService.BatchUpdate(ctx, userID, &req)
+	if err != nil {
 		switch {
-		case strings.Contains(err.Error(), "no items selected"):
-			c.JSON(http.StatusBadRequest, gin.H{"error": "no items selected in cart"})
 		case strings.Contains(err.Error(), "unauthorized"):
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized access"})
 		default:
@@ -204,7 +295,13 @@ func (h *CartHandler) CreateOrder(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Order created successfully"})
+	// 任一操作驗證失敗時，整批未套用
+	if !resp.Applied {
+		c.JSON(http.StatusUnprocessableEntity, resp)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
 }
 
 // ... 實現其他處理器方法 ...
@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/client"
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/model"
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/service"
+)
+
+// CheckoutHandler 結帳流程處理器
+type CheckoutHandler struct {
+	checkoutService      service.CheckoutSagaService
+	abandonedCartService service.AbandonedCartService
+	stuckAfter           time.Duration
+}
+
+// NewCheckoutHandler 創建新的結帳流程處理器，stuckAfter 為管理員查詢卡住流程的預設門檻
+func NewCheckoutHandler(checkoutService service.CheckoutSagaService, abandonedCartService service.AbandonedCartService, stuckAfter time.Duration) *CheckoutHandler {
+	return &CheckoutHandler{
+		checkoutService:      checkoutService,
+		abandonedCartService: abandonedCartService,
+		stuckAfter:           stuckAfter,
+	}
+}
+
+// Checkout 以已選商品結帳
+func (h *CheckoutHandler) Checkout(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	var req model.CheckoutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := context.WithValue(c.Request.Context(), client.TokenKey, c.GetHeader("Authorization"))
+	saga, err := h.checkoutService.Checkout(ctx, userID, &req)
+	if err != nil {
+		switch {
+		case saga == nil && (err == service.ErrNoItemsSelected || err == service.ErrInvalidShippingMethod || errors.Is(err, service.ErrInvalidShippingInfo) || errors.Is(err, service.ErrInvalidEInvoiceInfo)):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case saga == nil && errors.Is(err, service.ErrStockUnavailable):
+			c.JSON(http.StatusConflict, gin.H{"error": "Insufficient stock"})
+		case saga == nil:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start checkout"})
+		case saga.Status == model.SagaStatusRunning:
+			// 付款已完成或遇到暫時性錯誤，剩餘步驟由背景任務續跑
+			c.JSON(http.StatusAccepted, saga)
+		case errors.Is(err, service.ErrStockUnavailable):
+			c.JSON(http.StatusConflict, gin.H{"error": "Insufficient stock", "saga": saga})
+		case errors.Is(err, service.ErrPaymentFailed):
+			c.JSON(http.StatusPaymentRequired, gin.H{"error": "Payment failed", "saga": saga})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Checkout failed", "saga": saga})
+		}
+		return
+	}
+
+	// 若購物車曾被標記為棄置，記錄為回流結帳
+	if err := h.abandonedCartService.MarkRecovered(ctx, userID, saga.OrderID); err != nil {
+		log.Printf("Error marking abandoned cart recovered for user %s: %v", userID, err)
+	}
+
+	c.JSON(http.StatusCreated, saga)
+}
+
+// GetCheckout 獲取結帳流程狀態
+func (h *CheckoutHandler) GetCheckout(c *gin.Context) {
+	saga, err := h.checkoutService.GetSaga(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		if err == service.ErrSagaNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Checkout not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get checkout"})
+		return
+	}
+
+	// 驗證流程所有者
+	if saga.UserID != c.GetString("userID") {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Checkout not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, saga)
+}
+
+// ListStuckSagas 管理員查看卡住或需人工處理的結帳流程
+func (h *CheckoutHandler) ListStuckSagas(c *gin.Context) {
+	olderThan := h.stuckAfter
+	if minutesStr := c.Query("olderThanMinutes"); minutesStr != "" {
+		if minutes, err := strconv.Atoi(minutesStr); err == nil && minutes >= 0 {
+			olderThan = time.Duration(minutes) * time.Minute
+		}
+	}
+
+	sagas, err := h.checkoutService.ListStuckSagas(c.Request.Context(), olderThan)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list stuck checkouts"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"sagas": sagas,
+		"total": len(sagas),
+	})
+}
+
+// RetrySaga 管理員重試 failed 的結帳流程
+func (h *CheckoutHandler) RetrySaga(c *gin.Context) {
+	saga, err := h.checkoutService.RetrySaga(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		switch {
+		case err == service.ErrSagaNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "Checkout not found"})
+		case err == service.ErrSagaNotStuck:
+			c.JSON(http.StatusConflict, gin.H{"error": "Checkout is not in failed state"})
+		case saga != nil:
+			c.JSON(http.StatusOK, gin.H{"error": err.Error(), "saga": saga})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retry checkout"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, saga)
+}
@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/model"
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/service"
+)
+
+// EInvoiceHandler 電子發票處理器
+type EInvoiceHandler struct {
+	einvoiceService service.EInvoiceService
+}
+
+// NewEInvoiceHandler 創建新的電子發票處理器
+func NewEInvoiceHandler(einvoiceService service.EInvoiceService) *EInvoiceHandler {
+	return &EInvoiceHandler{
+		einvoiceService: einvoiceService,
+	}
+}
+
+// ListNumberRanges 管理員查詢期別的字軌號碼區間
+func (h *EInvoiceHandler) ListNumberRanges(c *gin.Context) {
+	ranges, err := h.einvoiceService.ListNumberRanges(c.Request.Context(), c.Query("period"))
+	if err != nil {
+		log.Printf("Error listing e-invoice number ranges: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list number ranges"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"ranges": ranges})
+}
+
+// AddNumberRange 管理員新增字軌號碼區間
+func (h *EInvoiceHandler) AddNumberRange(c *gin.Context) {
+	var req model.AddEInvoiceRangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	numberRange, err := h.einvoiceService.AddNumberRange(c.Request.Context(), &req)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidEInvoiceRange) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		log.Printf("Error adding e-invoice number range: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add number range"})
+		return
+	}
+	c.JSON(http.StatusCreated, numberRange)
+}
+
+// IssueOrderEInvoice 管理員為訂單開立或補開電子發票
+func (h *EInvoiceHandler) IssueOrderEInvoice(c *gin.Context) {
+	einvoice, err := h.einvoiceService.IssueForOrder(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		switch {
+		case err == service.ErrOrderNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+		case err == service.ErrInvoiceNotAvailable:
+			c.JSON(http.StatusConflict, gin.H{"error": "Order has not been paid"})
+		case err == service.ErrEInvoiceDisabled:
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "E-invoice issuing is disabled"})
+		case err == service.ErrEInvoiceExhausted:
+			c.JSON(http.StatusConflict, gin.H{"error": "No e-invoice numbers left for the current period"})
+		case err == service.ErrEInvoicePending:
+			c.Header("Retry-After", "5")
+			c.JSON(http.StatusConflict, gin.H{"error": "E-invoice is being issued, please retry"})
+		default:
+			log.Printf("Error issuing e-invoice for order %s: %v", c.Param("id"), err)
+			c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to issue e-invoice"})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, einvoice)
+}
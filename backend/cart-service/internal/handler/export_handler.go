@@ -0,0 +1,54 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/client"
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/export"
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/service"
+)
+
+// ExportHandler 訂單匯出處理器
+type ExportHandler struct {
+	exportService service.ExportService
+}
+
+// NewExportHandler 創建新的訂單匯出處理器
+func NewExportHandler(exportService service.ExportService) *ExportHandler {
+	return &ExportHandler{
+		exportService: exportService,
+	}
+}
+
+// ExportOrders 以 CSV 或 XLSX 串流匯出訂單，篩選參數同管理員訂單列表，format 預設為 csv
+func (h *ExportHandler) ExportOrders(c *gin.Context) {
+	query, err := parseOrderQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	query.UserID = c.Query("userId")
+
+	format := export.Format(c.DefaultQuery("format", string(export.FormatCSV)))
+	if format != export.FormatCSV && format != export.FormatXLSX {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid format: must be csv or xlsx"})
+		return
+	}
+
+	filename := fmt.Sprintf("orders-%s.%s", time.Now().Format("20060102-150405"), format)
+	c.Header("Content-Type", format.ContentType())
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Status(http.StatusOK)
+
+	// 開始寫出後無法再變更狀態碼，錯誤只能記錄
+	ctx := context.WithValue(c.Request.Context(), client.TokenKey, c.GetHeader("Authorization"))
+	count, err := h.exportService.ExportOrders(ctx, query, format, c.Writer)
+	if err != nil {
+		log.Printf("Failed to export orders after %d orders: %v", count, err)
+	}
+}
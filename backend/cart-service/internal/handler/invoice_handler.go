@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/client"
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/model"
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/service"
+)
+
+// InvoiceHandler 發票處理器
+type InvoiceHandler struct {
+	invoiceService service.InvoiceService
+}
+
+// NewInvoiceHandler 創建新的發票處理器
+func NewInvoiceHandler(invoiceService service.InvoiceService) *InvoiceHandler {
+	return &InvoiceHandler{
+		invoiceService: invoiceService,
+	}
+}
+
+// GetInvoice 顧客下載訂單發票 PDF
+func (h *InvoiceHandler) GetInvoice(c *gin.Context) {
+	ctx := context.WithValue(c.Request.Context(), client.TokenKey, c.GetHeader("Authorization"))
+	inv, err := h.invoiceService.GetInvoice(ctx, c.GetString("userID"), c.Param("id"))
+	h.respond(c, inv, err)
+}
+
+// GetCreditNote 顧客下載退貨折讓單 PDF
+func (h *InvoiceHandler) GetCreditNote(c *gin.Context) {
+	ctx := context.WithValue(c.Request.Context(), client.TokenKey, c.GetHeader("Authorization"))
+	inv, err := h.invoiceService.GetCreditNote(ctx, c.GetString("userID"), c.Param("id"), c.Param("returnId"))
+	h.respond(c, inv, err)
+}
+
+// GetOrderInvoice 管理員下載訂單發票 PDF
+func (h *InvoiceHandler) GetOrderInvoice(c *gin.Context) {
+	ctx := context.WithValue(c.Request.Context(), client.TokenKey, c.GetHeader("Authorization"))
+	inv, err := h.invoiceService.IssueInvoice(ctx, c.Param("id"))
+	h.respond(c, inv, err)
+}
+
+// GetReturnCreditNote 管理員下載退貨折讓單 PDF
+func (h *InvoiceHandler) GetReturnCreditNote(c *gin.Context) {
+	ctx := context.WithValue(c.Request.Context(), client.TokenKey, c.GetHeader("Authorization"))
+	inv, err := h.invoiceService.IssueCreditNote(ctx, c.Param("id"))
+	h.respond(c, inv, err)
+}
+
+// respond 將發票以 PDF 附件返回，或將錯誤轉換為對應的 HTTP 響應
+func (h *InvoiceHandler) respond(c *gin.Context, inv *model.Invoice, err error) {
+	if err != nil {
+		switch err {
+		case service.ErrOrderNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+		case service.ErrReturnNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "Return request not found"})
+		case service.ErrOrderNotOwned:
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		case service.ErrInvoiceNotAvailable:
+			c.JSON(http.StatusConflict, gin.H{"error": "Invoice is not available until payment is completed"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get invoice"})
+		}
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := h.invoiceService.Render(&buf, inv); err != nil {
+		log.Printf("Failed to render invoice %s: %v", inv.Number, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render invoice"})
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", inv.Number+".pdf"))
+	c.Data(http.StatusOK, "application/pdf", buf.Bytes())
+}
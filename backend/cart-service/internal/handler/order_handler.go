@@ -1,10 +1,16 @@
 package handler
 
 import (
+	"context"
+	"fmt"
+	"log"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/client"
 	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/model"
 	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/service"
 )
@@ -23,68 +29,12 @@ func NewOrderHandler(orderService service.OrderService, cartService service.Cart
 	}
 }
 
-// CreateOrderRequest 創建訂單請求
-type CreateOrderRequest struct {
-	ShippingInfo model.ShippingInfo `json:"shippingInfo"`
-}
-
-// CreateOrder 創建訂單
-func (h *OrderHandler) CreateOrder(c *gin.Context) {
-	userID := c.GetString("userID")
-
-	var req CreateOrderRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
-		return
-	}
-
-	// 獲取用戶的購物車
-	cartItems, err := h.cartService.GetCartItems(c, userID)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get cart items"})
-		return
-	}
-
-	if len(cartItems) == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Cart is empty"})
-		return
-	}
-
-	// 將購物車項目轉換為訂單項目
-	var orderItems []model.OrderItem
-	for _, item := range cartItems {
-		orderItem := model.OrderItem{
-			ProductID:  item.ProductID,
-			Name:       item.Name,
-			Price:      item.Price,
-			Quantity:   item.Quantity,
-			TotalPrice: item.Price * float64(item.Quantity),
-		}
-		orderItems = append(orderItems, orderItem)
-	}
-
-	// 創建訂單
-	order, err := h.orderService.CreateOrder(c, userID, orderItems, req.ShippingInfo)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create order"})
-		return
-	}
-
-	// 清空購物車
-	if err := h.cartService.ClearCart(c, userID); err != nil {
-		// 記錄錯誤但不影響訂單創建
-		// TODO: 添加日誌記錄
-	}
-
-	c.JSON(http.StatusOK, order)
-}
-
 // GetOrder 獲取訂單詳情
 func (h *OrderHandler) GetOrder(c *gin.Context) {
 	userID := c.GetString("userID")
 	orderID := c.Param("id")
 
-	order, err := h.orderService.GetOrder(c, orderID)
+	order, err := h.orderService.GetOrderWithChildren(c, orderID)
 	if err != nil {
 		if err == service.ErrOrderNotFound {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
@@ -103,164 +53,196 @@ func (h *OrderHandler) GetOrder(c *gin.Context) {
 	c.JSON(http.StatusOK, order)
 }
 
-// GetUserOrders 獲取用戶的所有訂單
-func (h *OrderHandler) GetUserOrders(c *gin.Context) {
-	userID := c.GetString("userID")
-
-	// 獲取分頁參數
-	page := 1
-	limit := 10
-	if pageStr := c.Query("page"); pageStr != "" {
-		if pageNum, err := strconv.Atoi(pageStr); err == nil && pageNum > 0 {
-			page = pageNum
-		}
-	}
-	if limitStr := c.Query("limit"); limitStr != "" {
-		if limitNum, err := strconv.Atoi(limitStr); err == nil && limitNum > 0 {
-			limit = limitNum
-		}
-	}
-
-	orders, err := h.orderService.GetUserOrders(c, userID, page, limit)
+// ListOrders 查詢訂單列表
+// 查詢參數：status（可逗號分隔多個）、from/to（RFC3339 或 YYYY-MM-DD）、minAmount/maxAmount、
+// productId、q（商品名稱關鍵字）、sort（asc/desc，預設 desc）、cursor、limit
+func (h *OrderHandler) ListOrders(c *gin.Context) {
+	query, err := parseOrderQuery(c)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get orders"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	h.queryOrders(c, query)
+}
 
-	c.JSON(http.StatusOK, gin.H{
-		"orders": orders,
-		"page":   page,
-		"limit":  limit,
-	})
+// DeleteOrder 刪除訂單
+func (h *OrderHandler) DeleteOrder(c *gin.Context) {
+	c.JSON(http.StatusMethodNotAllowed, gin.H{"error": "Delete operation not supported"})
 }
 
-// UpdateOrderStatus 更新訂單狀態
-func (h *OrderHandler) UpdateOrderStatus(c *gin.Context) {
+// CancelOrder 取消訂單，僅限訂單所有者且訂單尚未付款
+func (h *OrderHandler) CancelOrder(c *gin.Context) {
 	orderID := c.Param("id")
 
+	// 取消原因為選填，允許空的請求體
 	var req struct {
-		Status model.OrderStatus `json:"status"`
+		Reason string `json:"reason"`
 	}
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
-		return
-	}
-
-	if err := h.orderService.UpdateOrderStatus(c, orderID, req.Status); err != nil {
-		if err == service.ErrInvalidOrderStatus {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order status"})
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
 			return
 		}
-		if err == service.ErrOrderNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update order status"})
-		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Order status updated successfully"})
+	ctx := context.WithValue(c.Request.Context(), client.TokenKey, c.GetHeader("Authorization"))
+	if err := h.orderService.CancelOrder(ctx, c.GetString("userID"), orderID, req.Reason); err != nil {
+		handleOrderStatusError(c, err, "Failed to cancel order")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Order cancelled successfully"})
 }
 
-// ListOrders 獲取訂單列表
-func (h *OrderHandler) ListOrders(c *gin.Context) {
+// Reorder 再次購買：將訂單商品以目前價格加入購物車，返回加入、調整與略過的商品
+func (h *OrderHandler) Reorder(c *gin.Context) {
 	userID := c.GetString("userID")
 
-	// 獲取分頁參數
-	page := 1
-	limit := 10
-	if pageStr := c.Query("page"); pageStr != "" {
-		if pageNum, err := strconv.Atoi(pageStr); err == nil && pageNum > 0 {
-			page = pageNum
+	order, err := h.orderService.GetOrder(c, c.Param("id"))
+	if err != nil {
+		if err == service.ErrOrderNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+			return
 		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get order"})
+		return
 	}
-	if limitStr := c.Query("limit"); limitStr != "" {
-		if limitNum, err := strconv.Atoi(limitStr); err == nil && limitNum > 0 {
-			limit = limitNum
-		}
+	if order.UserID != userID {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
 	}
 
-	orders, err := h.orderService.GetUserOrders(c, userID, page, limit)
+	ctx := context.WithValue(c.Request.Context(), client.TokenKey, c.GetHeader("Authorization"))
+	resp, err := h.cartService.Reorder(ctx, userID, order)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get orders"})
+		log.Printf("Error reordering order %s for user %s: %v", order.ID, userID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add items to cart"})
 		return
 	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"orders": orders,
-		"page":   page,
-		"limit":  limit,
-	})
+	c.JSON(http.StatusOK, resp)
 }
 
-// UpdateOrder 更新訂單
-func (h *OrderHandler) UpdateOrder(c *gin.Context) {
-	orderID := c.Param("id")
-	var req struct {
-		Status model.OrderStatus `json:"status"`
-	}
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
-		return
-	}
-	if err := h.orderService.UpdateOrderStatus(c, orderID, req.Status); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update order"})
-		return
+// handleOrderStatusError 將狀態變更錯誤轉換為對應的 HTTP 響應
+func handleOrderStatusError(c *gin.Context, err error, fallback string) {
+	switch err {
+	case service.ErrInvalidOrderStatus:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order status"})
+	case service.ErrOrderNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+	case service.ErrOrderNotOwned:
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+	case service.ErrInvalidTransition:
+		c.JSON(http.StatusConflict, gin.H{"error": "Order status transition not allowed"})
+	case service.ErrReservationExpired:
+		c.JSON(http.StatusConflict, gin.H{"error": "Stock reservation expired"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
 	}
-	c.JSON(http.StatusOK, gin.H{"message": "Order updated successfully"})
 }
 
-// DeleteOrder 刪除訂單
-func (h *OrderHandler) DeleteOrder(c *gin.Context) {
-	c.JSON(http.StatusMethodNotAllowed, gin.H{"error": "Delete operation not supported"})
+// GetOrdersByStatus 根據狀態獲取訂單，其餘查詢參數同 ListOrders
+func (h *OrderHandler) GetOrdersByStatus(c *gin.Context) {
+	query, err := parseOrderQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	query.Statuses = []model.OrderStatus{model.OrderStatus(c.Param("status"))}
+	h.queryOrders(c, query)
 }
 
-// CancelOrder 取消訂單
-func (h *OrderHandler) CancelOrder(c *gin.Context) {
-	orderID := c.Param("id")
-	if err := h.orderService.UpdateOrderStatus(c, orderID, model.OrderStatusCancelled); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel order"})
+// queryOrders 執行查詢並輸出分頁結果
+func (h *OrderHandler) queryOrders(c *gin.Context, query *model.OrderQuery) {
+	page, err := h.orderService.QueryOrders(c.Request.Context(), query, c.Query("cursor"))
+	if err != nil {
+		if err == service.ErrInvalidCursor {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get orders"})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"message": "Order cancelled successfully"})
+	c.JSON(http.StatusOK, page)
 }
 
-// GetOrdersByStatus 根據狀態獲取訂單
-func (h *OrderHandler) GetOrdersByStatus(c *gin.Context) {
-	status := model.OrderStatus(c.Param("status"))
-	userID := c.GetString("userID")
-
-	// 獲取分頁參數
-	page := 1
-	limit := 10
-	if pageStr := c.Query("page"); pageStr != "" {
-		if pageNum, err := strconv.Atoi(pageStr); err == nil && pageNum > 0 {
-			page = pageNum
+// parseOrderQuery 解析訂單查詢參數
+func parseOrderQuery(c *gin.Context) (*model.OrderQuery, error) {
+	query := &model.OrderQuery{
+		UserID:    c.GetString("userID"),
+		ProductID: c.Query("productId"),
+		Text:      strings.TrimSpace(c.Query("q")),
+	}
+
+	for _, value := range c.QueryArray("status") {
+		for _, status := range strings.Split(value, ",") {
+			status := model.OrderStatus(strings.TrimSpace(status))
+			if status == "" {
+				continue
+			}
+			if !status.IsValid() {
+				return nil, fmt.Errorf("invalid status: %s", status)
+			}
+			query.Statuses = append(query.Statuses, status)
 		}
 	}
+
+	var err error
+	if query.CreatedFrom, err = parseQueryTime(c.Query("from"), false); err != nil {
+		return nil, fmt.Errorf("invalid from: %v", err)
+	}
+	if query.CreatedTo, err = parseQueryTime(c.Query("to"), true); err != nil {
+		return nil, fmt.Errorf("invalid to: %v", err)
+	}
+	if query.MinAmount, err = parseQueryFloat(c.Query("minAmount")); err != nil {
+		return nil, fmt.Errorf("invalid minAmount: %v", err)
+	}
+	if query.MaxAmount, err = parseQueryFloat(c.Query("maxAmount")); err != nil {
+		return nil, fmt.Errorf("invalid maxAmount: %v", err)
+	}
+
+	switch c.DefaultQuery("sort", "desc") {
+	case "asc":
+		query.Ascending = true
+	case "desc":
+	default:
+		return nil, fmt.Errorf("invalid sort: must be asc or desc")
+	}
+
 	if limitStr := c.Query("limit"); limitStr != "" {
-		if limitNum, err := strconv.Atoi(limitStr); err == nil && limitNum > 0 {
-			limit = limitNum
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 {
+			return nil, fmt.Errorf("invalid limit")
 		}
+		query.Limit = limit
 	}
+	return query, nil
+}
 
-	orders, err := h.orderService.GetUserOrders(c, userID, page, limit)
+// parseQueryTime 解析 RFC3339 或 YYYY-MM-DD，日期格式作為結束時間時包含當天整日
+func parseQueryTime(value string, endOfDay bool) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get orders"})
-		return
+		return nil, err
 	}
-
-	// 過濾指定狀態的訂單
-	var filteredOrders []model.Order
-	for _, order := range orders {
-		if order.Status == status {
-			filteredOrders = append(filteredOrders, order)
-		}
+	if endOfDay {
+		t = t.Add(24*time.Hour - time.Millisecond)
 	}
+	return &t, nil
+}
 
-	c.JSON(http.StatusOK, gin.H{
-		"orders": filteredOrders,
-		"page":   page,
-		"limit":  limit,
-	})
+// parseQueryFloat 解析金額參數
+func parseQueryFloat(value string) (*float64, error) {
+	if value == "" {
+		return nil, nil
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil, err
+	}
+	return &f, nil
 }
@@ -0,0 +1,120 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/model"
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/service"
+)
+
+// OrderMessageHandler 訂單訊息處理器
+type OrderMessageHandler struct {
+	messageService service.OrderMessageService
+}
+
+// NewOrderMessageHandler 創建新的訂單訊息處理器
+func NewOrderMessageHandler(messageService service.OrderMessageService) *OrderMessageHandler {
+	return &OrderMessageHandler{
+		messageService: messageService,
+	}
+}
+
+// GetMessages 顧客查看訂單訊息，查看後未讀數歸零
+func (h *OrderMessageHandler) GetMessages(c *gin.Context) {
+	thread, err := h.messageService.GetCustomerThread(c.Request.Context(), c.GetString("userID"), c.Param("id"))
+	if err != nil {
+		handleOrderMessageError(c, err, "Failed to get messages")
+		return
+	}
+	c.JSON(http.StatusOK, thread)
+}
+
+// PostMessage 顧客在訂單留言
+func (h *OrderMessageHandler) PostMessage(c *gin.Context) {
+	var req model.PostOrderMessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	message, err := h.messageService.PostCustomerMessage(c.Request.Context(), c.GetString("userID"), c.Param("id"), &req)
+	if err != nil {
+		handleOrderMessageError(c, err, "Failed to post message")
+		return
+	}
+	c.JSON(http.StatusCreated, message)
+}
+
+// ListThreads 顧客的訊息串與未讀數
+func (h *OrderMessageHandler) ListThreads(c *gin.Context) {
+	threads, err := h.messageService.ListCustomerThreads(c.Request.Context(), c.GetString("userID"))
+	if err != nil {
+		handleOrderMessageError(c, err, "Failed to get message threads")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"threads": threads, "unread": totalUnread(threads, model.OrderMessageRoleCustomer)})
+}
+
+// GetStaffMessages 客服查看訂單訊息，查看後客服未讀數歸零
+func (h *OrderMessageHandler) GetStaffMessages(c *gin.Context) {
+	thread, err := h.messageService.GetStaffThread(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		handleOrderMessageError(c, err, "Failed to get messages")
+		return
+	}
+	c.JSON(http.StatusOK, thread)
+}
+
+// PostStaffMessage 客服回覆訂單訊息
+func (h *OrderMessageHandler) PostStaffMessage(c *gin.Context) {
+	var req model.PostOrderMessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	message, err := h.messageService.PostStaffMessage(c.Request.Context(), c.GetString("userID"), c.Param("id"), &req)
+	if err != nil {
+		handleOrderMessageError(c, err, "Failed to post message")
+		return
+	}
+	c.JSON(http.StatusCreated, message)
+}
+
+// ListThreadsAwaitingStaff 客服尚未讀取的訊息串
+func (h *OrderMessageHandler) ListThreadsAwaitingStaff(c *gin.Context) {
+	threads, err := h.messageService.ListThreadsAwaitingStaff(c.Request.Context())
+	if err != nil {
+		handleOrderMessageError(c, err, "Failed to get message threads")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"threads": threads, "unread": totalUnread(threads, model.OrderMessageRoleStaff)})
+}
+
+// totalUnread 加總 role 一方的未讀數
+func totalUnread(threads []model.OrderThread, role model.OrderMessageRole) int {
+	total := 0
+	for _, thread := range threads {
+		if role == model.OrderMessageRoleCustomer {
+			total += thread.CustomerUnread
+		} else {
+			total += thread.StaffUnread
+		}
+	}
+	return total
+}
+
+// handleOrderMessageError 將訂單訊息錯誤轉換為對應的 HTTP 響應
+func handleOrderMessageError(c *gin.Context, err error, fallback string) {
+	switch err {
+	case service.ErrOrderNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+	case service.ErrOrderNotOwned:
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+	case service.ErrInvalidOrderMessage:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
@@ -0,0 +1,144 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/client"
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/model"
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/service"
+)
+
+// ReturnHandler 退貨處理器
+type ReturnHandler struct {
+	returnService service.ReturnService
+}
+
+// NewReturnHandler 創建新的退貨處理器
+func NewReturnHandler(returnService service.ReturnService) *ReturnHandler {
+	return &ReturnHandler{
+		returnService: returnService,
+	}
+}
+
+// CreateReturn 顧客為訂單申請退貨
+func (h *ReturnHandler) CreateReturn(c *gin.Context) {
+	var req model.CreateReturnRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ret, err := h.returnService.CreateReturn(c.Request.Context(), c.GetString("userID"), c.Param("id"), &req)
+	if err != nil {
+		handleReturnError(c, err, "Failed to create return request")
+		return
+	}
+	c.JSON(http.StatusCreated, ret)
+}
+
+// ListReturns 顧客查看訂單的退貨申請
+func (h *ReturnHandler) ListReturns(c *gin.Context) {
+	returns, err := h.returnService.ListReturns(c.Request.Context(), c.GetString("userID"), c.Param("id"))
+	if err != nil {
+		handleReturnError(c, err, "Failed to get return requests")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"returns": returns})
+}
+
+// ListOrderReturns 管理員查看訂單的退貨申請
+func (h *ReturnHandler) ListOrderReturns(c *gin.Context) {
+	returns, err := h.returnService.ListOrderReturns(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		handleReturnError(c, err, "Failed to get return requests")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"returns": returns})
+}
+
+// ListReturnsByStatus 管理員依狀態查看退貨申請，預設為待審核
+func (h *ReturnHandler) ListReturnsByStatus(c *gin.Context) {
+	status := model.ReturnStatus(c.DefaultQuery("status", string(model.ReturnStatusRequested)))
+	if !status.IsValid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid return status"})
+		return
+	}
+
+	returns, err := h.returnService.ListReturnsByStatus(c.Request.Context(), status)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get return requests"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"returns": returns})
+}
+
+// ReviewReturn 管理員核准或拒絕退貨申請
+func (h *ReturnHandler) ReviewReturn(c *gin.Context) {
+	var req model.ReviewReturnRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ret, err := h.returnService.ReviewReturn(c.Request.Context(), c.Param("id"), c.GetString("userID"), &req)
+	if err != nil {
+		handleReturnError(c, err, "Failed to review return request")
+		return
+	}
+	c.JSON(http.StatusOK, ret)
+}
+
+// ReceiveReturn 管理員確認收到退貨，可選擇回補庫存，並自動退款
+func (h *ReturnHandler) ReceiveReturn(c *gin.Context) {
+	var req model.ReceiveReturnRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := context.WithValue(c.Request.Context(), client.TokenKey, c.GetHeader("Authorization"))
+	ret, err := h.returnService.ReceiveReturn(ctx, c.Param("id"), c.GetString("userID"), &req)
+	if err != nil {
+		handleReturnError(c, err, "Failed to receive return")
+		return
+	}
+	c.JSON(http.StatusOK, ret)
+}
+
+// RefundReturn 管理員重試退貨退款
+func (h *ReturnHandler) RefundReturn(c *gin.Context) {
+	ctx := context.WithValue(c.Request.Context(), client.TokenKey, c.GetHeader("Authorization"))
+	ret, err := h.returnService.RefundReturn(ctx, c.Param("id"), c.GetString("userID"))
+	if err != nil {
+		handleReturnError(c, err, "Failed to refund return")
+		return
+	}
+	c.JSON(http.StatusOK, ret)
+}
+
+// handleReturnError 將退貨服務的錯誤轉換為對應的 HTTP 響應
+func handleReturnError(c *gin.Context, err error, fallback string) {
+	switch {
+	case err == service.ErrOrderNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+	case err == service.ErrReturnNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "Return request not found"})
+	case err == service.ErrOrderNotOwned:
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+	case err == service.ErrReturnNotAllowed:
+		c.JSON(http.StatusConflict, gin.H{"error": "Only delivered orders can be returned"})
+	case err == service.ErrReturnWindowClosed:
+		c.JSON(http.StatusConflict, gin.H{"error": "Return window has closed"})
+	case err == service.ErrInvalidReturnTransition:
+		c.JSON(http.StatusConflict, gin.H{"error": "Invalid return status transition"})
+	case errors.Is(err, service.ErrInvalidReturnItems):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrRefundFailed):
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
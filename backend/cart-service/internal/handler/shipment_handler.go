@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/model"
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/service"
+)
+
+// ShipmentHandler 貨件處理器
+type ShipmentHandler struct {
+	shipmentService service.ShipmentService
+}
+
+// NewShipmentHandler 創建新的貨件處理器
+func NewShipmentHandler(shipmentService service.ShipmentService) *ShipmentHandler {
+	return &ShipmentHandler{
+		shipmentService: shipmentService,
+	}
+}
+
+// CreateShipment 管理員為訂單建立貨件，可只出貨部分商品
+func (h *ShipmentHandler) CreateShipment(c *gin.Context) {
+	var req model.CreateShipmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	shipment, err := h.shipmentService.CreateShipment(c.Request.Context(), c.Param("id"), c.GetString("userID"), &req)
+	if err != nil {
+		switch {
+		case err == service.ErrOrderNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+		case err == service.ErrShipmentNotAllowed:
+			c.JSON(http.StatusConflict, gin.H{"error": "Only paid orders can be shipped"})
+		case err == service.ErrUnknownCarrier:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown carrier"})
+		case errors.Is(err, service.ErrInvalidTrackingNumber):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Tracking number not recognized by carrier"})
+		case errors.Is(err, service.ErrInvalidShipmentItems):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create shipment"})
+		}
+		return
+	}
+	c.JSON(http.StatusCreated, shipment)
+}
+
+// ListShipments 管理員查看訂單的所有貨件
+func (h *ShipmentHandler) ListShipments(c *gin.Context) {
+	shipments, err := h.shipmentService.ListShipments(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		if err == service.ErrOrderNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get shipments"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"shipments": shipments})
+}
+
+// GetTracking 顧客查看訂單的貨件追蹤
+func (h *ShipmentHandler) GetTracking(c *gin.Context) {
+	shipments, err := h.shipmentService.GetTracking(c.Request.Context(), c.GetString("userID"), c.Param("id"))
+	if err != nil {
+		switch err {
+		case service.ErrOrderNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+		case service.ErrOrderNotOwned:
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get tracking"})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"shipments": shipments})
+}
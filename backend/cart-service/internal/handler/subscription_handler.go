@@ -0,0 +1,137 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/client"
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/model"
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/service"
+)
+
+// SubscriptionHandler 定期訂購處理器
+type SubscriptionHandler struct {
+	subscriptionService service.SubscriptionService
+}
+
+// NewSubscriptionHandler 創建新的定期訂購處理器
+func NewSubscriptionHandler(subscriptionService service.SubscriptionService) *SubscriptionHandler {
+	return &SubscriptionHandler{
+		subscriptionService: subscriptionService,
+	}
+}
+
+// CreateSubscription 建立定期訂購
+func (h *SubscriptionHandler) CreateSubscription(c *gin.Context) {
+	var req model.CreateSubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 查詢商品與預設地址需將 token 傳給下游服務
+	ctx := context.WithValue(c.Request.Context(), client.TokenKey, c.GetHeader("Authorization"))
+	sub, err := h.subscriptionService.CreateSubscription(ctx, c.GetString("userID"), &req)
+	if err != nil {
+		handleSubscriptionError(c, err, "Failed to create subscription")
+		return
+	}
+	c.JSON(http.StatusCreated, sub)
+}
+
+// ListSubscriptions 獲取用戶的定期訂購
+func (h *SubscriptionHandler) ListSubscriptions(c *gin.Context) {
+	subs, err := h.subscriptionService.ListSubscriptions(c.Request.Context(), c.GetString("userID"))
+	if err != nil {
+		handleSubscriptionError(c, err, "Failed to get subscriptions")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"subscriptions": subs})
+}
+
+// GetSubscription 獲取定期訂購詳情與最近的執行紀錄
+func (h *SubscriptionHandler) GetSubscription(c *gin.Context) {
+	sub, err := h.subscriptionService.GetSubscription(c.Request.Context(), c.GetString("userID"), c.Param("id"))
+	if err != nil {
+		handleSubscriptionError(c, err, "Failed to get subscription")
+		return
+	}
+	c.JSON(http.StatusOK, sub)
+}
+
+// UpdateSubscription 更新定期訂購
+func (h *SubscriptionHandler) UpdateSubscription(c *gin.Context) {
+	var req model.UpdateSubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := context.WithValue(c.Request.Context(), client.TokenKey, c.GetHeader("Authorization"))
+	sub, err := h.subscriptionService.UpdateSubscription(ctx, c.GetString("userID"), c.Param("id"), &req)
+	if err != nil {
+		handleSubscriptionError(c, err, "Failed to update subscription")
+		return
+	}
+	c.JSON(http.StatusOK, sub)
+}
+
+// SkipNextRun 略過下一次執行
+func (h *SubscriptionHandler) SkipNextRun(c *gin.Context) {
+	sub, err := h.subscriptionService.SkipNextRun(c.Request.Context(), c.GetString("userID"), c.Param("id"))
+	if err != nil {
+		handleSubscriptionError(c, err, "Failed to skip subscription run")
+		return
+	}
+	c.JSON(http.StatusOK, sub)
+}
+
+// PauseSubscription 暫停定期訂購
+func (h *SubscriptionHandler) PauseSubscription(c *gin.Context) {
+	sub, err := h.subscriptionService.PauseSubscription(c.Request.Context(), c.GetString("userID"), c.Param("id"))
+	if err != nil {
+		handleSubscriptionError(c, err, "Failed to pause subscription")
+		return
+	}
+	c.JSON(http.StatusOK, sub)
+}
+
+// ResumeSubscription 恢復定期訂購
+func (h *SubscriptionHandler) ResumeSubscription(c *gin.Context) {
+	sub, err := h.subscriptionService.ResumeSubscription(c.Request.Context(), c.GetString("userID"), c.Param("id"))
+	if err != nil {
+		handleSubscriptionError(c, err, "Failed to resume subscription")
+		return
+	}
+	c.JSON(http.StatusOK, sub)
+}
+
+// CancelSubscription 取消定期訂購
+func (h *SubscriptionHandler) CancelSubscription(c *gin.Context) {
+	sub, err := h.subscriptionService.CancelSubscription(c.Request.Context(), c.GetString("userID"), c.Param("id"))
+	if err != nil {
+		handleSubscriptionError(c, err, "Failed to cancel subscription")
+		return
+	}
+	c.JSON(http.StatusOK, sub)
+}
+
+// handleSubscriptionError 將定期訂購錯誤轉換為對應的 HTTP 響應
+func handleSubscriptionError(c *gin.Context, err error, fallback string) {
+	switch {
+	case err == service.ErrSubscriptionNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "Subscription not found"})
+	case err == service.ErrSubscriptionNotOwned:
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+	case err == service.ErrSubscriptionCancelled:
+		c.JSON(http.StatusConflict, gin.H{"error": "Subscription is cancelled"})
+	case err == service.ErrInvalidSubscriptionStep:
+		c.JSON(http.StatusConflict, gin.H{"error": "Subscription status does not allow this action"})
+	case errors.Is(err, service.ErrInvalidSubscription), errors.Is(err, service.ErrInvalidShippingInfo), errors.Is(err, service.ErrInvalidEInvoiceInfo):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
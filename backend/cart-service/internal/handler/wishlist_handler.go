@@ -162,3 +162,36 @@ func (h *WishlistHandler) GetWishlist(c *gin.Context) {
 		"data":    wishlistResp,
 	})
 }
+
+// GetWishlistIds 獲取收藏的商品ID
+// @Summary 獲取用戶收藏的商品ID
+// @Description 只返回商品ID，供商品列表標示收藏狀態
+// @Tags wishlist
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 500 {object} map[string]interface{}
+// @Router /api/v1/wishlist/ids [get]
+func (h *WishlistHandler) GetWishlistIds(c *gin.Context) {
+	userId := c.GetString("userID")
+	if userId == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"message": "未授權",
+		})
+		return
+	}
+
+	idsResp, err := h.wishlistService.GetWishlistIds(c.Request.Context(), userId)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": fmt.Sprintf("獲取收藏清單失敗: %v", err),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    idsResp,
+	})
+}
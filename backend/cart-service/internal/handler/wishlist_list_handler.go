@@ -0,0 +1,316 @@
+package handler
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/client"
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/model"
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/service"
+)
+
+// WishlistListHandler 處理具名收藏清單相關請求
+type WishlistListHandler struct {
+	listService service.WishlistListService
+}
+
+// NewWishlistListHandler 創建一個新的具名收藏清單處理器
+func NewWishlistListHandler(listService service.WishlistListService) *WishlistListHandler {
+	return &WishlistListHandler{
+		listService: listService,
+	}
+}
+
+// CreateList 建立具名收藏清單
+// @Summary 建立具名收藏清單
+// @Tags wishlist
+// @Accept json
+// @Produce json
+// @Param list body model.CreateWishlistListRequest true "清單資訊"
+// @Success 201 {object} map[string]interface{}
+// @Router /api/v1/wishlist/lists [post]
+func (h *WishlistListHandler) CreateList(c *gin.Context) {
+	var req model.CreateWishlistListRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "無效的請求參數",
+		})
+		return
+	}
+
+	list, err := h.listService.CreateList(c.Request.Context(), c.GetString("userID"), &req)
+	if err != nil {
+		handleWishlistListError(c, err, "建立收藏清單失敗")
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "已建立收藏清單",
+		"data":    list,
+	})
+}
+
+// GetLists 獲取用戶的具名收藏清單
+// @Summary 獲取用戶的具名收藏清單
+// @Tags wishlist
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/wishlist/lists [get]
+func (h *WishlistListHandler) GetLists(c *gin.Context) {
+	lists, err := h.listService.GetLists(c.Request.Context(), c.GetString("userID"))
+	if err != nil {
+		handleWishlistListError(c, err, "獲取收藏清單失敗")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    lists,
+	})
+}
+
+// GetList 獲取具名收藏清單與商品詳細資訊
+// @Summary 獲取具名收藏清單
+// @Tags wishlist
+// @Produce json
+// @Param listId path string true "清單ID"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/wishlist/lists/{listId} [get]
+func (h *WishlistListHandler) GetList(c *gin.Context) {
+	list, err := h.listService.GetList(c.Request.Context(), c.GetString("userID"), c.Param("listId"))
+	if err != nil {
+		handleWishlistListError(c, err, "獲取收藏清單失敗")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    list,
+	})
+}
+
+// UpdateList 更新具名收藏清單的名稱、說明與公開設定
+// @Summary 更新具名收藏清單
+// @Tags wishlist
+// @Accept json
+// @Produce json
+// @Param listId path string true "清單ID"
+// @Param list body model.UpdateWishlistListRequest true "清單資訊"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/wishlist/lists/{listId} [put]
+func (h *WishlistListHandler) UpdateList(c *gin.Context) {
+	var req model.UpdateWishlistListRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "無效的請求參數",
+		})
+		return
+	}
+
+	list, err := h.listService.UpdateList(c.Request.Context(), c.GetString("userID"), c.Param("listId"), &req)
+	if err != nil {
+		handleWishlistListError(c, err, "更新收藏清單失敗")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "已更新收藏清單",
+		"data":    list,
+	})
+}
+
+// DeleteList 刪除具名收藏清單
+// @Summary 刪除具名收藏清單
+// @Tags wishlist
+// @Produce json
+// @Param listId path string true "清單ID"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/wishlist/lists/{listId} [delete]
+func (h *WishlistListHandler) DeleteList(c *gin.Context) {
+	if err := h.listService.DeleteList(c.Request.Context(), c.GetString("userID"), c.Param("listId")); err != nil {
+		handleWishlistListError(c, err, "刪除收藏清單失敗")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "已刪除收藏清單",
+	})
+}
+
+// AddItem 加入商品至具名收藏清單，已存在時更新備註與數量
+// @Summary 加入商品至具名收藏清單
+// @Tags wishlist
+// @Accept json
+// @Produce json
+// @Param listId path string true "清單ID"
+// @Param item body model.AddWishlistListItemRequest true "商品資訊"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/wishlist/lists/{listId}/items [post]
+func (h *WishlistListHandler) AddItem(c *gin.Context) {
+	var req model.AddWishlistListItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "無效的請求參數",
+		})
+		return
+	}
+
+	list, err := h.listService.AddItem(c.Request.Context(), c.GetString("userID"), c.Param("listId"), &req)
+	if err != nil {
+		handleWishlistListError(c, err, "添加到收藏清單失敗")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "已成功添加到收藏清單",
+		"data":    list,
+	})
+}
+
+// UpdateItem 更新具名收藏清單商品的備註或數量
+// @Summary 更新具名收藏清單商品
+// @Tags wishlist
+// @Accept json
+// @Produce json
+// @Param listId path string true "清單ID"
+// @Param productId path string true "商品ID"
+// @Param item body model.UpdateWishlistListItemRequest true "商品資訊"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/wishlist/lists/{listId}/items/{productId} [put]
+func (h *WishlistListHandler) UpdateItem(c *gin.Context) {
+	var req model.UpdateWishlistListItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "無效的請求參數",
+		})
+		return
+	}
+
+	list, err := h.listService.UpdateItem(c.Request.Context(), c.GetString("userID"), c.Param("listId"), c.Param("productId"), &req)
+	if err != nil {
+		handleWishlistListError(c, err, "更新收藏清單失敗")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "已更新收藏清單",
+		"data":    list,
+	})
+}
+
+// RemoveItem 從具名收藏清單移除商品
+// @Summary 從具名收藏清單移除商品
+// @Tags wishlist
+// @Produce json
+// @Param listId path string true "清單ID"
+// @Param productId path string true "商品ID"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/wishlist/lists/{listId}/items/{productId} [delete]
+func (h *WishlistListHandler) RemoveItem(c *gin.Context) {
+	list, err := h.listService.RemoveItem(c.Request.Context(), c.GetString("userID"), c.Param("listId"), c.Param("productId"))
+	if err != nil {
+		handleWishlistListError(c, err, "從收藏清單移除失敗")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "已成功從收藏清單移除",
+		"data":    list,
+	})
+}
+
+// RotateShareToken 重新產生公開清單的分享連結
+// @Summary 重新產生分享連結
+// @Tags wishlist
+// @Produce json
+// @Param listId path string true "清單ID"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/wishlist/lists/{listId}/share [post]
+func (h *WishlistListHandler) RotateShareToken(c *gin.Context) {
+	list, err := h.listService.RotateShareToken(c.Request.Context(), c.GetString("userID"), c.Param("listId"))
+	if err != nil {
+		handleWishlistListError(c, err, "產生分享連結失敗")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "已產生新的分享連結",
+		"data":    list,
+	})
+}
+
+// GetSharedList 以分享連結檢視公開清單，不需登入
+// @Summary 檢視分享的收藏清單
+// @Tags wishlist
+// @Produce json
+// @Param token path string true "分享連結代碼"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/shared/wishlists/{token} [get]
+func (h *WishlistListHandler) GetSharedList(c *gin.Context) {
+	list, err := h.listService.GetSharedList(c.Request.Context(), c.Param("token"))
+	if err != nil {
+		handleWishlistListError(c, err, "獲取收藏清單失敗")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    list,
+	})
+}
+
+// AddSharedListToCart 將分享清單的商品加入自己的購物車，返回加入、調整與略過的商品；
+// 購物車以用戶ID為鍵且本服務沒有訪客購物車，因此需登入，未登入的訪客只能透過
+// /api/v1/shared/wishlists/{token} 檢視清單
+// @Summary 將分享清單加入購物車
+// @Tags wishlist
+// @Produce json
+// @Param token path string true "分享連結代碼"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/wishlist/shared/{token}/cart [post]
+func (h *WishlistListHandler) AddSharedListToCart(c *gin.Context) {
+	userID := c.GetString("userID")
+	ctx := context.WithValue(c.Request.Context(), client.TokenKey, c.GetHeader("Authorization"))
+	resp, err := h.listService.AddSharedListToCart(ctx, userID, c.Param("token"))
+	if err != nil {
+		log.Printf("Error adding shared wishlist to cart for user %s: %v", userID, err)
+		handleWishlistListError(c, err, "加入購物車失敗")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "已將收藏清單加入購物車",
+		"data":    resp,
+	})
+}
+
+// handleWishlistListError 將具名收藏清單錯誤轉換為對應的 HTTP 響應
+func handleWishlistListError(c *gin.Context, err error, fallback string) {
+	status := http.StatusInternalServerError
+	message := fallback
+	switch err {
+	case service.ErrWishlistListNotFound:
+		status, message = http.StatusNotFound, "收藏清單不存在"
+	case service.ErrWishlistItemNotFound:
+		status, message = http.StatusNotFound, "商品不在收藏清單中"
+	case service.ErrProductNotFound:
+		status, message = http.StatusNotFound, "商品不存在"
+	case service.ErrWishlistListPrivate:
+		status, message = http.StatusConflict, "私人清單無法分享"
+	case service.ErrWishlistListEmpty:
+		status, message = http.StatusBadRequest, "收藏清單沒有商品"
+	case service.ErrWishlistListLimit:
+		status, message = http.StatusConflict, "收藏清單數量已達上限"
+	case service.ErrWishlistListDefault:
+		status, message = http.StatusConflict, "預設收藏清單無法刪除"
+	case service.ErrInvalidWishlistList:
+		status, message = http.StatusBadRequest, "清單名稱不能為空"
+	}
+	c.JSON(status, gin.H{
+		"success": false,
+		"message": message,
+	})
+}
@@ -0,0 +1,246 @@
+package invoice
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+
+	"github.com/jung-kurt/gofpdf"
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/model"
+)
+
+// Seller 開立發票的賣方資訊
+type Seller struct {
+	Name    string
+	Address string
+	TaxID   string
+}
+
+// ErrFontRequired 未設定字型；內建字型僅支援 Latin-1，無法顯示中文
+var ErrFontRequired = errors.New("invoice font is required")
+
+// fontFamily 載入字型後使用的字型名稱
+const fontFamily = "invoice"
+
+// Renderer 以 gofpdf 產生發票與折讓單 PDF
+type Renderer struct {
+	seller Seller
+	font   []byte
+}
+
+// NewRenderer 創建 PDF 產生器；fontPath 為含中文字符的 UTF-8 TrueType 字型檔，
+// 未設定或無法載入時返回錯誤，避免開立出中文亂碼的發票
+func NewRenderer(seller Seller, fontPath string) (*Renderer, error) {
+	if fontPath == "" {
+		return nil, ErrFontRequired
+	}
+	font, err := os.ReadFile(fontPath)
+	if err != nil {
+		return nil, fmt.Errorf("read invoice font: %w", err)
+	}
+
+	// 預先載入一次，確認字型檔可被 gofpdf 解析
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.AddUTF8FontFromBytes(fontFamily, "", font)
+	if err := pdf.Error(); err != nil {
+		return nil, fmt.Errorf("load invoice font %s: %w", fontPath, err)
+	}
+
+	return &Renderer{
+		seller: seller,
+		font:   font,
+	}, nil
+}
+
+// 頁面版面（A4，單位 mm）
+const (
+	pageMargin = 15.0
+	lineHeight = 6.0
+	colName    = 90.0
+	colQty     = 20.0
+	colPrice   = 35.0
+	colTotal   = 35.0
+)
+
+// Render 將發票寫入 w
+func (r *Renderer) Render(w io.Writer, inv *model.Invoice) error {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(pageMargin, pageMargin, pageMargin)
+	pdf.SetAutoPageBreak(true, pageMargin)
+
+	family := fontFamily
+	pdf.AddUTF8FontFromBytes(family, "", r.font)
+	pdf.AddUTF8FontFromBytes(family, "B", r.font)
+
+	title := "INVOICE"
+	if inv.Type == model.InvoiceTypeCreditNote {
+		title = "CREDIT NOTE"
+	}
+	pdf.SetTitle(title+" "+inv.Number, true)
+	pdf.SetAuthor(r.seller.Name, true)
+	pdf.SetCreationDate(inv.IssuedAt)
+	pdf.AliasNbPages("")
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-pageMargin)
+		pdf.SetFont(family, "", 8)
+		pdf.CellFormat(0, lineHeight, fmt.Sprintf("%s  %d/{nb}", inv.Number, pdf.PageNo()), "", 0, "C", false, 0, "")
+	})
+	pdf.AddPage()
+
+	// 標題與賣方
+	pdf.SetFont(family, "B", 18)
+	pdf.CellFormat(0, 10, title, "", 1, "R", false, 0, "")
+	pdf.SetFont(family, "B", 11)
+	pdf.CellFormat(0, lineHeight, r.seller.Name, "", 1, "L", false, 0, "")
+	pdf.SetFont(family, "", 9)
+	if r.seller.Address != "" {
+		pdf.CellFormat(0, lineHeight, r.seller.Address, "", 1, "L", false, 0, "")
+	}
+	if r.seller.TaxID != "" {
+		pdf.CellFormat(0, lineHeight, "Tax ID: "+r.seller.TaxID, "", 1, "L", false, 0, "")
+	}
+	pdf.Ln(4)
+
+	// 發票資訊
+	details := [][2]string{
+		{"Number", inv.Number},
+		{"Issued", inv.IssuedAt.Format("2006-01-02")},
+		{"Order", inv.OrderID},
+	}
+	if inv.Type == model.InvoiceTypeCreditNote {
+		details = append(details, [2]string{"Original invoice", inv.ReferenceNumber}, [2]string{"Return", inv.ReturnID})
+	}
+	details = append(details,
+		[2]string{"Payment method", inv.PaymentMethod},
+		[2]string{"Transaction ID", inv.TransactionID},
+	)
+	for _, d := range details {
+		pdf.SetFont(family, "B", 9)
+		pdf.CellFormat(35, lineHeight, d[0], "", 0, "L", false, 0, "")
+		pdf.SetFont(family, "", 9)
+		pdf.CellFormat(0, lineHeight, d[1], "", 1, "L", false, 0, "")
+	}
+	pdf.Ln(4)
+
+	// 收件資訊
+	shipping := inv.ShippingInfo
+	pdf.SetFont(family, "B", 10)
+	pdf.CellFormat(0, lineHeight, "Ship to", "", 1, "L", false, 0, "")
+	pdf.SetFont(family, "", 9)
+	for _, line := range []string{
+		shipping.RecipientName,
+		shipping.PhoneNumber,
+		shipping.Address.Street,
+		joinNonEmpty(", ", shipping.Address.City, shipping.Address.State, shipping.Address.PostalCode),
+		shipping.Address.Country,
+	} {
+		if line != "" {
+			pdf.CellFormat(0, lineHeight, line, "", 1, "L", false, 0, "")
+		}
+	}
+	pdf.Ln(4)
+
+	// 明細
+	pdf.SetFont(family, "B", 9)
+	pdf.SetFillColor(230, 230, 230)
+	pdf.CellFormat(colName, lineHeight+1, "Item", "B", 0, "L", true, 0, "")
+	pdf.CellFormat(colQty, lineHeight+1, "Qty", "B", 0, "R", true, 0, "")
+	pdf.CellFormat(colPrice, lineHeight+1, "Unit price", "B", 0, "R", true, 0, "")
+	pdf.CellFormat(colTotal, lineHeight+1, "Amount", "B", 1, "R", true, 0, "")
+	pdf.SetFont(family, "", 9)
+	for _, line := range inv.Lines {
+		pdf.CellFormat(colName, lineHeight, truncate(line.Name, 60), "", 0, "L", false, 0, "")
+		pdf.CellFormat(colQty, lineHeight, fmt.Sprintf("%d", line.Quantity), "", 0, "R", false, 0, "")
+		pdf.CellFormat(colPrice, lineHeight, formatAmount(line.Price), "", 0, "R", false, 0, "")
+		pdf.CellFormat(colTotal, lineHeight, formatAmount(line.Total), "", 1, "R", false, 0, "")
+	}
+	pdf.Ln(2)
+
+	// 金額明細
+	var totals [][2]string
+	if p := inv.Pricing; p != nil {
+		totals = append(totals, [2]string{"Subtotal", formatAmount(p.Subtotal)})
+		if p.Discount > 0 {
+			name := "Discount"
+			if p.DiscountName != "" {
+				name += " (" + p.DiscountName + ")"
+			}
+			totals = append(totals, [2]string{name, "-" + formatAmount(p.Discount)})
+		}
+		totals = append(totals, [2]string{"Shipping (" + p.ShippingMethod + ")", formatAmount(p.ShippingFee)})
+		taxLabel := fmt.Sprintf("Tax %.0f%%", p.TaxRate*100)
+		if p.TaxIncluded {
+			taxLabel += " (included)"
+		}
+		totals = append(totals, [2]string{taxLabel, formatAmount(p.Tax)})
+	} else {
+		// 折讓單的退款金額已依訂單折扣與稅額折算，列出與明細的差額
+		subtotal := 0.0
+		for _, line := range inv.Lines {
+			subtotal += line.Total
+		}
+		totals = append(totals, [2]string{"Subtotal", formatAmount(subtotal)})
+		if adjustment := inv.Amount - subtotal; math.Abs(adjustment) >= 0.005 {
+			totals = append(totals, [2]string{"Discount and tax adjustment", formatAmount(adjustment)})
+		}
+	}
+	totalLabel := "Total"
+	if inv.Type == model.InvoiceTypeCreditNote {
+		totalLabel = "Refund total"
+	}
+	totals = append(totals, [2]string{totalLabel, inv.Currency + " " + formatAmount(inv.Amount)})
+
+	labelWidth := colName + colQty + colPrice
+	for i, t := range totals {
+		style, border := "", ""
+		if i == len(totals)-1 {
+			style, border = "B", "T"
+		}
+		pdf.SetFont(family, style, 9)
+		pdf.CellFormat(labelWidth, lineHeight, t[0], border, 0, "R", false, 0, "")
+		pdf.CellFormat(colTotal, lineHeight, t[1], border, 1, "R", false, 0, "")
+	}
+
+	return pdf.Output(w)
+}
+
+// formatAmount 金額格式化為兩位小數並加上千分位
+func formatAmount(amount float64) string {
+	s := fmt.Sprintf("%.2f", amount)
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	intPart, frac := s[:len(s)-3], s[len(s)-3:]
+	var b strings.Builder
+	for i, c := range intPart {
+		if i > 0 && (len(intPart)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(c)
+	}
+	return sign + b.String() + frac
+}
+
+// truncate 截斷過長的文字
+func truncate(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max-3]) + "..."
+}
+
+// joinNonEmpty 以 sep 連接非空白字串
+func joinNonEmpty(sep string, parts ...string) string {
+	nonEmpty := make([]string, 0, len(parts))
+	for _, p := range parts {
+		if p != "" {
+			nonEmpty = append(nonEmpty, p)
+		}
+	}
+	return strings.Join(nonEmpty, sep)
+}
@@ -0,0 +1,33 @@
+package model
+
+import "time"
+
+// AbandonedCartStatus 棄置購物車狀態
+type AbandonedCartStatus string
+
+const (
+	AbandonedCartStatusAbandoned AbandonedCartStatus = "abandoned"
+	AbandonedCartStatusReminded  AbandonedCartStatus = "reminded"
+	AbandonedCartStatusRecovered AbandonedCartStatus = "recovered"
+)
+
+// AbandonedCart 棄置購物車記錄
+type AbandonedCart struct {
+	UserID         string              `json:"userId"`
+	Status         AbandonedCartStatus `json:"status"`
+	ItemCount      int                 `json:"itemCount"`
+	CartAmount     float64             `json:"cartAmount"`
+	CartUpdatedAt  time.Time           `json:"cartUpdatedAt"` // 判定棄置時購物車的最後更新時間
+	AbandonedAt    time.Time           `json:"abandonedAt"`
+	ReminderSentAt *time.Time          `json:"reminderSentAt,omitempty"`
+	RecoveredAt    *time.Time          `json:"recoveredAt,omitempty"`
+	OrderID        string              `json:"orderId,omitempty"` // 回流結帳的訂單ID
+}
+
+// AbandonedCartStats 棄置購物車統計
+type AbandonedCartStats struct {
+	Abandoned    int     `json:"abandoned"`    // 累計棄置數
+	Reminded     int     `json:"reminded"`     // 已發送提醒數
+	Recovered    int     `json:"recovered"`    // 回流結帳數
+	RecoveryRate float64 `json:"recoveryRate"` // 回流率
+}
@@ -0,0 +1,111 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+// AnalyticsInterval 報表彙總區間
+type AnalyticsInterval string
+
+const (
+	AnalyticsIntervalDay   AnalyticsInterval = "day"
+	AnalyticsIntervalWeek  AnalyticsInterval = "week"
+	AnalyticsIntervalMonth AnalyticsInterval = "month"
+)
+
+// AnalyticsIntervals 所有彙總區間，訂單狀態變更時同時更新
+var AnalyticsIntervals = []AnalyticsInterval{AnalyticsIntervalDay, AnalyticsIntervalWeek, AnalyticsIntervalMonth}
+
+// IsValid 檢查區間是否有效
+func (i AnalyticsInterval) IsValid() bool {
+	switch i {
+	case AnalyticsIntervalDay, AnalyticsIntervalWeek, AnalyticsIntervalMonth:
+		return true
+	}
+	return false
+}
+
+// Period 返回 t 所屬的區間鍵值，依字串排序即為時間順序：
+// 日為 2006-01-02，週為 ISO 週 2006-W01，月為 2006-01
+func (i AnalyticsInterval) Period(t time.Time) string {
+	switch i {
+	case AnalyticsIntervalWeek:
+		year, week := t.ISOWeek()
+		return fmt.Sprintf("%04d-W%02d", year, week)
+	case AnalyticsIntervalMonth:
+		return t.Format("2006-01")
+	default:
+		return t.Format("2006-01-02")
+	}
+}
+
+// SalesBucket 單一區間的銷售彙總，存於 analytics/sales/{interval}/{period}；
+// 訂單依建立時間歸入區間，狀態變更時遞增對應欄位
+type SalesBucket struct {
+	Period             string    `json:"period"`
+	PlacedOrders       int       `json:"placedOrders"`       // 已付款或已取消的訂單（離開待付款）
+	PaidOrders         int       `json:"paidOrders"`         // 已付款訂單
+	Revenue            float64   `json:"revenue"`            // 已付款訂單金額合計
+	CancelledOrders    int       `json:"cancelledOrders"`    // 已取消訂單
+	RefundedOrders     int       `json:"refundedOrders"`     // 有退款（含部分退款）的訂單
+	RefundedAmount     float64   `json:"refundedAmount"`     // 實際退款金額合計，不含未退還的運費
+	NewCustomers       int       `json:"newCustomers"`       // 首次付款的顧客
+	ReturningCustomers int       `json:"returningCustomers"` // 曾付款、在此區間再次付款的顧客
+	UpdatedAt          time.Time `json:"updatedAt"`
+}
+
+// SalesReportRow 銷售報表的一列，包含由彙總計算的比率
+type SalesReportRow struct {
+	SalesBucket
+	AverageOrderValue float64 `json:"averageOrderValue"`
+	CancellationRate  float64 `json:"cancellationRate"` // 取消數 / 離開待付款的訂單數
+	RefundRate        float64 `json:"refundRate"`       // 退款數 / 已付款訂單數
+}
+
+// NewSalesReportRow 由彙總計算報表列
+func NewSalesReportRow(bucket SalesBucket) SalesReportRow {
+	row := SalesReportRow{SalesBucket: bucket}
+	if bucket.PaidOrders > 0 {
+		row.AverageOrderValue = bucket.Revenue / float64(bucket.PaidOrders)
+		row.RefundRate = float64(bucket.RefundedOrders) / float64(bucket.PaidOrders)
+	}
+	if bucket.PlacedOrders > 0 {
+		row.CancellationRate = float64(bucket.CancelledOrders) / float64(bucket.PlacedOrders)
+	}
+	return row
+}
+
+// SalesReport 銷售報表
+type SalesReport struct {
+	Interval AnalyticsInterval `json:"interval"`
+	From     string            `json:"from"`
+	To       string            `json:"to"`
+	Rows     []SalesReportRow  `json:"rows"`
+	Total    SalesReportRow    `json:"total"` // 新舊顧客數為各區間加總
+}
+
+// ProductSales 商品銷售彙總，每日存於 analytics/products/{day}/{productId}
+type ProductSales struct {
+	ProductID  string  `json:"productId"`
+	Name       string  `json:"name"`
+	CategoryID string  `json:"categoryId,omitempty"`
+	Quantity   int     `json:"quantity"`
+	Revenue    float64 `json:"revenue"`
+	Orders     int     `json:"orders"`
+}
+
+// CategorySales 分類銷售彙總，由商品彙總加總
+type CategorySales struct {
+	CategoryID string  `json:"categoryId"` // 未分類時為空白
+	Quantity   int     `json:"quantity"`
+	Revenue    float64 `json:"revenue"`
+	Orders     int     `json:"orders"` // 各商品的訂單數加總，同一訂單含多項同分類商品時重複計算
+}
+
+// CustomerStats 顧客付款紀錄，存於 analytics/customers/{userId}，用於區分新舊顧客
+type CustomerStats struct {
+	FirstPaidAt *time.Time                   `json:"firstPaidAt,omitempty"`
+	PaidOrders  int                          `json:"paidOrders"`
+	LastPeriods map[AnalyticsInterval]string `json:"lastPeriods,omitempty"` // 各區間最後計入的區間鍵值
+}
@@ -8,11 +8,15 @@ import (
 
 // Cart 購物車模型
 type Cart struct {
-	UserID    string     `gorm:"primaryKey"`
-	Items     []CartItem `gorm:"foreignKey:UserID;references:UserID"`
-	CreatedAt time.Time
-	UpdatedAt time.Time
-	DeletedAt gorm.DeletedAt `gorm:"index"`
+	UserID     string     `gorm:"primaryKey"`
+	Items      []CartItem `gorm:"foreignKey:UserID;references:UserID"`
+	SavedItems []CartItem // 稍後購買的商品，不計入結帳與金額
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	// UpdatedAtMillis 為 UpdatedAt 的 Unix 毫秒，儲存時由 repository 寫入，供依更新時間查詢；
+	// UpdatedAt 的字串帶有時區偏移，無法直接比較大小
+	UpdatedAtMillis int64
+	DeletedAt       gorm.DeletedAt `gorm:"index"`
 }
 
 // CartItem 購物車項目模型
@@ -24,6 +28,7 @@ type CartItem struct {
 	Image      string  `gorm:"type:text"` // 確保這是 TEXT 類型，可以存儲大量文本
 	Price      float64 // 商品價格
 	StockCount int     // 庫存數量
+	Weight     float64 // 單件重量（公斤）
 	Quantity   int
 	Selected   bool `gorm:"default:false"`
 	CreatedAt  time.Time
@@ -48,11 +53,83 @@ type SelectItemsRequest struct {
 	ProductIDs []string `json:"productIds" binding:"required"`
 }
 
+// CartOperationType 批次購物車操作類型
+type CartOperationType string
+
+const (
+	CartOperationAdd    CartOperationType = "add"
+	CartOperationUpdate CartOperationType = "update"
+	CartOperationRemove CartOperationType = "remove"
+	CartOperationSelect CartOperationType = "select"
+)
+
+// CartOperation 單一購物車操作
+type CartOperation struct {
+	Op        CartOperationType `json:"op" binding:"required,oneof=add update remove select"`
+	ProductID string            `json:"productId" binding:"required"`
+	Quantity  int               `json:"quantity" binding:"gte=0"` // add 為增加數量，update 為設定數量（0 表示移除）
+	Selected  *bool             `json:"selected"`                 // select 使用
+}
+
+// BatchCartRequest 批次購物車操作請求，一次最多 100 筆操作
+type BatchCartRequest struct {
+	Operations []CartOperation `json:"operations" binding:"required,min=1,max=100,dive"`
+}
+
+// CartOperationResult 單一操作結果
+type CartOperationResult struct {
+	Index     int               `json:"index"`
+	Op        CartOperationType `json:"op"`
+	ProductID string            `json:"productId"`
+	Success   bool              `json:"success"`
+	Error     string            `json:"error,omitempty"`
+}
+
+// BatchCartResponse 批次購物車操作響應
+type BatchCartResponse struct {
+	Applied bool                  `json:"applied"` // 任一操作驗證失敗時整批不套用
+	Results []CartOperationResult `json:"results"`
+	Cart    *CartResponse         `json:"cart,omitempty"`
+}
+
+// ReorderItemStatus 再次購買時單一商品的處理結果
+type ReorderItemStatus string
+
+const (
+	ReorderItemAdded    ReorderItemStatus = "added"
+	ReorderItemAdjusted ReorderItemStatus = "adjusted" // 庫存不足，僅加入可購買的數量
+	ReorderItemSkipped  ReorderItemStatus = "skipped"  // 商品已刪除、下架或無庫存
+)
+
+// ReorderItemResult 再次購買單一商品的結果
+type ReorderItemResult struct {
+	ProductID         string            `json:"productId"`
+	Name              string            `json:"name"`
+	Status            ReorderItemStatus `json:"status"`
+	RequestedQuantity int               `json:"requestedQuantity"` // 原訂單數量
+	AddedQuantity     int               `json:"addedQuantity"`
+	OrderedPrice      float64           `json:"orderedPrice,omitempty"` // 原訂單單價，從收藏清單加入時為空
+	CurrentPrice      float64           `json:"currentPrice,omitempty"` // 加入購物車的目前單價
+	Reason            string            `json:"reason,omitempty"`
+}
+
+// ReorderResponse 再次購買響應
+type ReorderResponse struct {
+	OrderID  string              `json:"orderId,omitempty"`
+	Items    []ReorderItemResult `json:"items"`
+	Added    int                 `json:"added"`
+	Adjusted int                 `json:"adjusted"`
+	Skipped  int                 `json:"skipped"`
+	Cart     *CartResponse       `json:"cart"`
+}
+
 // CartResponse 購物車響應
 type CartResponse struct {
-	Items         []CartItem `json:"items"`
-	TotalSelected int        `json:"totalSelected"` // 已選商品總數
-	TotalAmount   float64    `json:"totalAmount"`   // 已選商品總金額
+	Items         []CartItem      `json:"items"`
+	SavedItems    []CartItem      `json:"savedItems"`    // 稍後購買（不計入金額）
+	TotalSelected int             `json:"totalSelected"` // 已選商品總數
+	TotalAmount   float64         `json:"totalAmount"`   // 已選商品應付總金額
+	Pricing       *PriceBreakdown `json:"pricing"`       // 金額明細
 }
 
 // 新增一個用於處理圖片的類型
@@ -0,0 +1,62 @@
+package model
+
+import "time"
+
+// SagaStatus 結帳流程狀態
+type SagaStatus string
+
+const (
+	SagaStatusRunning      SagaStatus = "running"      // 正在執行步驟
+	SagaStatusCompensating SagaStatus = "compensating" // 步驟失敗，正在執行補償
+	SagaStatusCompleted    SagaStatus = "completed"    // 所有步驟完成
+	SagaStatusCompensated  SagaStatus = "compensated"  // 已完成補償，結帳失敗
+	SagaStatusFailed       SagaStatus = "failed"       // 補償失敗或重試次數用盡，需人工處理
+)
+
+// SagaStep 結帳流程步驟
+type SagaStep string
+
+const (
+	SagaStepReserveStock     SagaStep = "reserve_stock"
+	SagaStepCreateOrder      SagaStep = "create_order"
+	SagaStepCreatePayment    SagaStep = "create_payment"
+	SagaStepProcessPayment   SagaStep = "process_payment"
+	SagaStepConfirmOrder     SagaStep = "confirm_order"
+	SagaStepClearCart        SagaStep = "clear_cart"
+	SagaStepSendConfirmation SagaStep = "send_confirmation"
+	SagaStepIssueEInvoice    SagaStep = "issue_einvoice"
+)
+
+// CheckoutSaga 結帳流程狀態，每個步驟完成後保存以便服務重啟後續跑
+type CheckoutSaga struct {
+	ID                   string          `json:"id"`
+	UserID               string          `json:"userId"`
+	Status               SagaStatus      `json:"status"`
+	CurrentStep          SagaStep        `json:"currentStep"`
+	CompletedSteps       []SagaStep      `json:"completedSteps"`
+	Items                []OrderItem     `json:"items"`
+	ShippingInfo         ShippingInfo    `json:"shippingInfo"`
+	EInvoiceInfo         *EInvoiceInfo   `json:"eInvoiceInfo,omitempty"`
+	Pricing              *PriceBreakdown `json:"pricing"`
+	PaymentMethod        string          `json:"paymentMethod"`
+	Currency             string          `json:"currency"`
+	OrderID              string          `json:"orderId"`
+	SubscriptionID       string          `json:"subscriptionId,omitempty"` // 由訂閱排程建立時不清除購物車
+	ReservationID        string          `json:"reservationId,omitempty"`
+	ReservationExpiresAt *time.Time      `json:"reservationExpiresAt,omitempty"`
+	PaymentID            string          `json:"paymentId,omitempty"`
+	Error                string          `json:"error,omitempty"`
+	Attempts             int             `json:"attempts"`                 // 背景續跑次數
+	LeaseOwner           string          `json:"leaseOwner,omitempty"`     // 正在執行流程的執行者，租約期間其他實例或背景任務不得執行
+	LeaseExpiresAt       *time.Time      `json:"leaseExpiresAt,omitempty"` // 每次保存時延長，執行者中斷後到期由背景任務接手
+	CreatedAt            time.Time       `json:"createdAt"`
+	UpdatedAt            time.Time       `json:"updatedAt"`
+}
+
+// CheckoutRequest 結帳請求
+type CheckoutRequest struct {
+	ShippingInfo  *ShippingInfo `json:"shippingInfo"` // 未提供時使用預設地址
+	EInvoice      *EInvoiceInfo `json:"eInvoice"`     // 未提供時開立個人發票並存入會員載具
+	PaymentMethod string        `json:"paymentMethod" binding:"required"`
+	Currency      string        `json:"currency"`
+}
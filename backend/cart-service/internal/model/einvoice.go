@@ -0,0 +1,185 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// EInvoiceType 電子發票類型
+type EInvoiceType string
+
+const (
+	EInvoiceTypeB2C      EInvoiceType = "b2c"      // 個人，存入載具
+	EInvoiceTypeB2B      EInvoiceType = "b2b"      // 公司，需統一編號
+	EInvoiceTypeDonation EInvoiceType = "donation" // 捐贈，需愛心碼
+)
+
+// EInvoiceCarrierType 電子發票載具類型
+type EInvoiceCarrierType string
+
+const (
+	EInvoiceCarrierMember EInvoiceCarrierType = "member" // 會員載具，以用戶ID歸戶
+	EInvoiceCarrierMobile EInvoiceCarrierType = "mobile" // 手機條碼
+)
+
+// EInvoiceStatus 電子發票開立狀態
+type EInvoiceStatus string
+
+const (
+	EInvoiceStatusAllocated EInvoiceStatus = "allocated" // 已配號，尚未上傳開立
+	EInvoiceStatusIssued    EInvoiceStatus = "issued"
+)
+
+var (
+	ErrInvalidEInvoiceInfo = errors.New("invalid e-invoice info")
+
+	mobileBarcodePattern = regexp.MustCompile(`^/[0-9A-Z.+-]{7}$`)
+	donationCodePattern  = regexp.MustCompile(`^[0-9]{3,7}$`)
+	taxIDPattern         = regexp.MustCompile(`^[0-9]{8}$`)
+	trackPattern         = regexp.MustCompile(`^[A-Z]{2}$`)
+	periodPattern        = regexp.MustCompile(`^[0-9]{3}(01|03|05|07|09|11)$`)
+)
+
+// EInvoiceInfo 顧客於結帳時選擇的電子發票開立方式
+type EInvoiceInfo struct {
+	Type         EInvoiceType        `json:"type"`
+	CarrierType  EInvoiceCarrierType `json:"carrierType,omitempty"`
+	CarrierID    string              `json:"carrierId,omitempty"`    // 手機條碼，例如 /ABC+123
+	BuyerTaxID   string              `json:"buyerTaxId,omitempty"`   // 統一編號
+	BuyerName    string              `json:"buyerName,omitempty"`    // 公司抬頭
+	DonationCode string              `json:"donationCode,omitempty"` // 愛心碼
+}
+
+// DefaultEInvoiceInfo 未指定時開立個人發票並存入會員載具
+func DefaultEInvoiceInfo() *EInvoiceInfo {
+	return &EInvoiceInfo{Type: EInvoiceTypeB2C, CarrierType: EInvoiceCarrierMember}
+}
+
+// Validate 檢查開立方式與欄位格式，錯誤包裝 ErrInvalidEInvoiceInfo
+func (i *EInvoiceInfo) Validate() error {
+	switch i.Type {
+	case EInvoiceTypeB2C:
+		if i.BuyerTaxID != "" || i.DonationCode != "" {
+			return fmt.Errorf("%w: personal invoice cannot have tax ID or donation code", ErrInvalidEInvoiceInfo)
+		}
+		return i.validateCarrier()
+	case EInvoiceTypeB2B:
+		if !ValidTaxID(i.BuyerTaxID) {
+			return fmt.Errorf("%w: invalid buyer tax ID", ErrInvalidEInvoiceInfo)
+		}
+		if i.BuyerName == "" {
+			return fmt.Errorf("%w: buyer name is required", ErrInvalidEInvoiceInfo)
+		}
+		if i.DonationCode != "" {
+			return fmt.Errorf("%w: company invoice cannot be donated", ErrInvalidEInvoiceInfo)
+		}
+		return i.validateCarrier()
+	case EInvoiceTypeDonation:
+		if !donationCodePattern.MatchString(i.DonationCode) {
+			return fmt.Errorf("%w: donation code must be 3 to 7 digits", ErrInvalidEInvoiceInfo)
+		}
+		if i.BuyerTaxID != "" || i.CarrierType != "" || i.CarrierID != "" {
+			return fmt.Errorf("%w: donated invoice cannot have tax ID or carrier", ErrInvalidEInvoiceInfo)
+		}
+		return nil
+	}
+	return fmt.Errorf("%w: unknown invoice type %q", ErrInvalidEInvoiceInfo, i.Type)
+}
+
+// validateCarrier 檢查載具，未指定時使用會員載具
+func (i *EInvoiceInfo) validateCarrier() error {
+	switch i.CarrierType {
+	case "", EInvoiceCarrierMember:
+		if i.CarrierID != "" {
+			return fmt.Errorf("%w: member carrier does not take a carrier ID", ErrInvalidEInvoiceInfo)
+		}
+	case EInvoiceCarrierMobile:
+		if !mobileBarcodePattern.MatchString(i.CarrierID) {
+			return fmt.Errorf("%w: mobile barcode must be / followed by 7 characters of 0-9, A-Z, '.', '+' or '-'", ErrInvalidEInvoiceInfo)
+		}
+	default:
+		return fmt.Errorf("%w: unknown carrier type %q", ErrInvalidEInvoiceInfo, i.CarrierType)
+	}
+	return nil
+}
+
+// ValidTaxID 檢查統一編號格式與檢查碼（財政部 112 年起改為可被 5 整除）
+func ValidTaxID(taxID string) bool {
+	if !taxIDPattern.MatchString(taxID) {
+		return false
+	}
+	weights := [8]int{1, 2, 1, 2, 1, 2, 4, 1}
+	sum := 0
+	for i, w := range weights {
+		p := int(taxID[i]-'0') * w
+		sum += p/10 + p%10
+	}
+	if sum%5 == 0 {
+		return true
+	}
+	// 第七碼為 7 時乘積 28 的十位數相加可取 0 或 1
+	return taxID[6] == '7' && (sum+1)%5 == 0
+}
+
+// EInvoice 訂單已配號或開立的電子發票，存於訂單的 eInvoice 節點
+type EInvoice struct {
+	Number     string         `json:"number"`     // 字軌與八位號碼，例如 AB12345678
+	RandomCode string         `json:"randomCode"` // 四位隨機碼
+	Period     string         `json:"period"`
+	Status     EInvoiceStatus `json:"status"`
+	Issuer     string         `json:"issuer,omitempty"`
+	IssuedAt   *time.Time     `json:"issuedAt,omitempty"`
+	ClaimedBy  string         `json:"claimedBy,omitempty"` // 正在開立的請求，避免並發配號與重複上傳
+	ClaimedAt  *time.Time     `json:"claimedAt,omitempty"`
+}
+
+// EInvoiceVoid 已配發但未使用的號碼，存於 einvoice_voids/{period}/{number}，供申報空白字軌
+type EInvoiceVoid struct {
+	Number   string    `json:"number"`
+	Period   string    `json:"period"`
+	OrderID  string    `json:"orderId"`
+	Reason   string    `json:"reason"`
+	VoidedAt time.Time `json:"voidedAt"`
+}
+
+// EInvoiceNumberRange 財政部配發的字軌號碼區間，存於 einvoice_ranges/{id}
+type EInvoiceNumberRange struct {
+	ID        string    `json:"id"`
+	Period    string    `json:"period"` // 期別：民國年與單數起始月，例如 11511 代表 115 年 11-12 月
+	Track     string    `json:"track"`  // 字軌，兩個大寫英文字母
+	Start     int64     `json:"start"`
+	End       int64     `json:"end"`
+	Next      int64     `json:"next"` // 下一個可配發的號碼，大於 End 表示已用完
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// AddEInvoiceRangeRequest 新增字軌號碼區間請求
+type AddEInvoiceRangeRequest struct {
+	Period string `json:"period" binding:"required"`
+	Track  string `json:"track" binding:"required"`
+	Start  int64  `json:"start" binding:"gte=0,lte=99999999"`
+	End    int64  `json:"end" binding:"gte=0,lte=99999999"`
+}
+
+// Validate 檢查期別、字軌與號碼區間
+func (r *AddEInvoiceRangeRequest) Validate() error {
+	if !periodPattern.MatchString(r.Period) {
+		return errors.New("period must be ROC year followed by an odd starting month, e.g. 11511")
+	}
+	if !trackPattern.MatchString(r.Track) {
+		return errors.New("track must be two uppercase letters")
+	}
+	if r.End < r.Start {
+		return errors.New("end must not be less than start")
+	}
+	return nil
+}
+
+// EInvoicePeriod 以台灣時間計算期別，每兩個月為一期
+func EInvoicePeriod(t time.Time) string {
+	t = t.In(time.FixedZone("Asia/Taipei", 8*60*60))
+	month := (int(t.Month())-1)/2*2 + 1
+	return fmt.Sprintf("%03d%02d", t.Year()-1911, month)
+}
@@ -0,0 +1,40 @@
+package model
+
+import "time"
+
+// InvoiceType 發票類型
+type InvoiceType string
+
+const (
+	InvoiceTypeInvoice    InvoiceType = "invoice"     // 訂單發票／收據
+	InvoiceTypeCreditNote InvoiceType = "credit_note" // 退款折讓單
+)
+
+// Invoice 發票或折讓單，開立時保存訂單與支付資訊的快照，存於 invoices/{id}；
+// 訂單發票ID為 invoice-{orderId}，折讓單ID為 credit-{returnId}，確保每筆來源只開立一次
+type Invoice struct {
+	ID              string          `json:"id"`
+	Number          string          `json:"number"` // 依類型與年度連續編號，例如 INV-2026-000001
+	Type            InvoiceType     `json:"type"`
+	OrderID         string          `json:"orderId"`
+	UserID          string          `json:"userId"`
+	ReturnID        string          `json:"returnId,omitempty"`
+	ReferenceNumber string          `json:"referenceNumber,omitempty"` // 折讓單對應的原發票號碼
+	Lines           []InvoiceLine   `json:"lines"`
+	Pricing         *PriceBreakdown `json:"pricing,omitempty"`
+	Amount          float64         `json:"amount"`
+	Currency        string          `json:"currency"`
+	ShippingInfo    ShippingInfo    `json:"shippingInfo"`
+	PaymentMethod   string          `json:"paymentMethod"`
+	TransactionID   string          `json:"transactionId"`
+	IssuedAt        time.Time       `json:"issuedAt"`
+}
+
+// InvoiceLine 發票明細
+type InvoiceLine struct {
+	ProductID string  `json:"productId"`
+	Name      string  `json:"name"`
+	Price     float64 `json:"price"`
+	Quantity  int     `json:"quantity"`
+	Total     float64 `json:"total"`
+}
@@ -11,27 +11,112 @@ const (
 	OrderStatusShipped   OrderStatus = "shipped"
 	OrderStatusDelivered OrderStatus = "delivered"
 	OrderStatusCancelled OrderStatus = "cancelled"
+	OrderStatusRefunded  OrderStatus = "refunded"
 )
 
+// OrderActorSystem 背景任務或內部流程變更訂單狀態時的操作者
+const OrderActorSystem = "system"
+
+// orderTransitions 允許的狀態轉換：pending→paid→shipped→delivered，
+// 未付款可取消，已付款或已送達可退款；cancelled 與 refunded 為終止狀態
+var orderTransitions = map[OrderStatus][]OrderStatus{
+	OrderStatusPending:   {OrderStatusPaid, OrderStatusCancelled},
+	OrderStatusPaid:      {OrderStatusShipped, OrderStatusRefunded},
+	OrderStatusShipped:   {OrderStatusDelivered},
+	OrderStatusDelivered: {OrderStatusRefunded},
+}
+
+// IsValid 是否為已定義的訂單狀態
+func (s OrderStatus) IsValid() bool {
+	switch s {
+	case OrderStatusPending,
+		OrderStatusPaid,
+		OrderStatusShipped,
+		OrderStatusDelivered,
+		OrderStatusCancelled,
+		OrderStatusRefunded:
+		return true
+	}
+	return false
+}
+
+// CanTransitionTo 是否允許從目前狀態轉換到 next
+func (s OrderStatus) CanTransitionTo(next OrderStatus) bool {
+	for _, allowed := range orderTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
 // Order 訂單模型
 type Order struct {
-	ID           string       `json:"id"`
-	UserID       string       `json:"userId"`
-	Items        []OrderItem  `json:"items"`
-	TotalAmount  float64      `json:"totalAmount"`
-	Status       OrderStatus  `json:"status"`
-	ShippingInfo ShippingInfo `json:"shippingInfo"`
-	CreatedAt    time.Time    `json:"createdAt"`
-	UpdatedAt    time.Time    `json:"updatedAt"`
+	ID                   string              `json:"id"`
+	UserID               string              `json:"userId"`
+	Items                []OrderItem         `json:"items"`
+	TotalAmount          float64             `json:"totalAmount"`
+	Pricing              *PriceBreakdown     `json:"pricing,omitempty"`
+	Status               OrderStatus         `json:"status"`
+	ShippingInfo         ShippingInfo        `json:"shippingInfo"`
+	ReservationID        string              `json:"reservationId,omitempty"`        // product service 的庫存預留ID
+	ReservationExpiresAt *time.Time          `json:"reservationExpiresAt,omitempty"` // 逾時未付款即取消
+	EInvoiceInfo         *EInvoiceInfo       `json:"eInvoiceInfo,omitempty"`         // 顧客選擇的電子發票開立方式
+	EInvoice             *EInvoice           `json:"eInvoice,omitempty"`             // 付款後配號開立
+	SubscriptionID       string              `json:"subscriptionId,omitempty"`       // 由訂閱排程建立
+	SellerID             string              `json:"sellerId,omitempty"`
+	WarehouseID          string              `json:"warehouseId,omitempty"`
+	ParentOrderID        string              `json:"parentOrderId,omitempty"` // 拆單後的子訂單所屬的父訂單，付款與取消跟隨父訂單
+	SubOrders            []SubOrder          `json:"subOrders,omitempty"`     // 父訂單的子訂單與付款分攤
+	Children             []Order             `json:"children,omitempty"`      // 查詢父訂單詳情時填入，不寫入資料庫
+	StatusHistory        []OrderStatusChange `json:"statusHistory,omitempty"`
+	CreatedAt            time.Time           `json:"createdAt"`
+	UpdatedAt            time.Time           `json:"updatedAt"`
+}
+
+// SubOrder 父訂單記錄的子訂單，Amount 為單次付款中分攤給該子訂單的金額
+type SubOrder struct {
+	OrderID     string  `json:"orderId"`
+	SellerID    string  `json:"sellerId,omitempty"`
+	WarehouseID string  `json:"warehouseId,omitempty"`
+	Amount      float64 `json:"amount"`
+}
+
+// PaymentOrderID 支付所屬的訂單ID，子訂單的支付建立於父訂單
+func (o *Order) PaymentOrderID() string {
+	if o.ParentOrderID != "" {
+		return o.ParentOrderID
+	}
+	return o.ID
+}
+
+// OrderStatusChange 訂單狀態變更紀錄
+type OrderStatusChange struct {
+	From   OrderStatus `json:"from,omitempty"`
+	To     OrderStatus `json:"to"`
+	Actor  string      `json:"actor"` // 操作者用戶ID，背景任務為 system
+	Reason string      `json:"reason,omitempty"`
+	At     time.Time   `json:"at"`
 }
 
 // OrderItem 訂單項目
 type OrderItem struct {
-	ProductID  string  `json:"productId"`
-	Name       string  `json:"name"`
-	Price      float64 `json:"price"`
-	Quantity   int     `json:"quantity"`
-	TotalPrice float64 `json:"totalPrice"`
+	ProductID   string        `json:"productId"`
+	Name        string        `json:"name"`
+	Image       string        `json:"image,omitempty"`      // 下單時的商品圖片URL
+	Attributes  []interface{} `json:"attributes,omitempty"` // 下單時的商品屬性
+	Price       float64       `json:"price"`
+	Quantity    int           `json:"quantity"`
+	Weight      float64       `json:"weight,omitempty"`
+	CategoryID  string        `json:"categoryId,omitempty"`
+	SellerID    string        `json:"sellerId,omitempty"`
+	WarehouseID string        `json:"warehouseId,omitempty"`
+	TotalPrice  float64       `json:"totalPrice"`
+}
+
+// FulfillmentGroup 出貨分組，同一賣家與倉庫的商品在同一筆子訂單出貨
+func (i *OrderItem) FulfillmentGroup() string {
+	return i.SellerID + "/" + i.WarehouseID
 }
 
 // ShippingInfo 配送信息
@@ -50,3 +135,37 @@ type Address struct {
 	PostalCode string `json:"postalCode"`
 	Country    string `json:"country"`
 }
+
+// OrderNote 訂單內部備註，僅管理員可見
+type OrderNote struct {
+	ID        string    `json:"id"`
+	Author    string    `json:"author"` // 管理員用戶ID
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// AddOrderNoteRequest 新增訂單備註請求
+type AddOrderNoteRequest struct {
+	Body string `json:"body" binding:"required,max=2000"`
+}
+
+// BulkOrderStatusRequest 批次更新訂單狀態請求
+type BulkOrderStatusRequest struct {
+	OrderIDs []string    `json:"orderIds" binding:"required,min=1,max=100"`
+	Status   OrderStatus `json:"status" binding:"required"`
+	Reason   string      `json:"reason"`
+}
+
+// BulkOrderStatusResult 單筆訂單的批次更新結果
+type BulkOrderStatusResult struct {
+	OrderID string `json:"orderId"`
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
+
+// BulkOrderStatusResponse 批次更新訂單狀態響應
+type BulkOrderStatusResponse struct {
+	Succeeded int                     `json:"succeeded"`
+	Failed    int                     `json:"failed"`
+	Results   []BulkOrderStatusResult `json:"results"`
+}
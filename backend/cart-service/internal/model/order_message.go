@@ -0,0 +1,55 @@
+package model
+
+import "time"
+
+// OrderMessageRole 訊息發送方
+type OrderMessageRole string
+
+const (
+	OrderMessageRoleCustomer OrderMessageRole = "customer"
+	OrderMessageRoleStaff    OrderMessageRole = "staff"
+)
+
+// OrderMessageAttachment 訊息附件，只保存已上傳檔案的參照
+type OrderMessageAttachment struct {
+	Name        string `json:"name" binding:"required,max=200"`
+	URL         string `json:"url" binding:"required,url"`
+	ContentType string `json:"contentType,omitempty"`
+	Size        int64  `json:"size,omitempty" binding:"min=0"`
+}
+
+// OrderMessage 顧客與客服之間的訂單訊息，存於 order_messages/{orderId}/{id}；
+// 顧客可見，管理員的內部備註另存於 order_notes
+type OrderMessage struct {
+	ID          string                   `json:"id"`
+	OrderID     string                   `json:"orderId"`
+	AuthorID    string                   `json:"authorId"`
+	Role        OrderMessageRole         `json:"role"`
+	Body        string                   `json:"body"`
+	Attachments []OrderMessageAttachment `json:"attachments,omitempty"`
+	CreatedAt   time.Time                `json:"createdAt"`
+}
+
+// OrderThread 訂單訊息串摘要，存於 order_threads/{orderId}，記錄雙方的未讀數
+type OrderThread struct {
+	OrderID        string           `json:"orderId"`
+	UserID         string           `json:"userId"`
+	StaffID        string           `json:"staffId,omitempty"` // 最後回覆的客服，顧客回覆時通知此人
+	CustomerUnread int              `json:"customerUnread"`
+	StaffUnread    int              `json:"staffUnread"`
+	MessageCount   int              `json:"messageCount"`
+	LastMessageAt  time.Time        `json:"lastMessageAt"`
+	LastRole       OrderMessageRole `json:"lastRole"`
+}
+
+// OrderMessageThread 訊息串與其訊息
+type OrderMessageThread struct {
+	Thread   *OrderThread   `json:"thread"`
+	Messages []OrderMessage `json:"messages"`
+}
+
+// PostOrderMessageRequest 發送訂單訊息請求，內容與附件至少需提供一項
+type PostOrderMessageRequest struct {
+	Body        string                   `json:"body" binding:"max=4000"`
+	Attachments []OrderMessageAttachment `json:"attachments" binding:"max=5,dive"`
+}
@@ -0,0 +1,64 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+// OrderIndexEntry 訂單查詢索引，存於 order_index/{userId}/{orderId} 與管理員用的 order_index_all/{orderId}，隨訂單寫入維護
+type OrderIndexEntry struct {
+	OrderID     string      `json:"orderId"`
+	UserID      string      `json:"userId"`
+	Status      OrderStatus `json:"status"`
+	TotalAmount float64     `json:"totalAmount"`
+	ProductIDs  []string    `json:"productIds"`
+	ItemNames   string      `json:"itemNames"` // 小寫的商品名稱，供關鍵字搜尋
+	CreatedAt   int64       `json:"createdAt"` // Unix 毫秒，供 RTDB 依數值排序與範圍查詢
+}
+
+// NewOrderIndexEntry 由訂單建立索引項目
+func NewOrderIndexEntry(order *Order) *OrderIndexEntry {
+	productIDs := make([]string, 0, len(order.Items))
+	names := make([]string, 0, len(order.Items))
+	for _, item := range order.Items {
+		productIDs = append(productIDs, item.ProductID)
+		names = append(names, strings.ToLower(item.Name))
+	}
+	return &OrderIndexEntry{
+		OrderID:     order.ID,
+		UserID:      order.UserID,
+		Status:      order.Status,
+		TotalAmount: order.TotalAmount,
+		ProductIDs:  productIDs,
+		ItemNames:   strings.Join(names, "\n"),
+		CreatedAt:   order.CreatedAt.UnixMilli(),
+	}
+}
+
+// OrderCursor 分頁游標，指向上一頁最後一筆訂單
+type OrderCursor struct {
+	CreatedAt int64
+	OrderID   string
+}
+
+// OrderQuery 訂單查詢條件
+type OrderQuery struct {
+	UserID      string // 空白時查詢所有用戶（管理員）
+	Statuses    []OrderStatus
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	MinAmount   *float64
+	MaxAmount   *float64
+	ProductID   string
+	Text        string // 商品名稱關鍵字，不分大小寫
+	Ascending   bool   // 預設依建立時間由新到舊
+	After       *OrderCursor
+	Limit       int
+}
+
+// OrderPage 訂單查詢結果
+type OrderPage struct {
+	Orders     []Order `json:"orders"`
+	NextCursor string  `json:"nextCursor,omitempty"` // 空白表示沒有下一頁
+	Limit      int     `json:"limit"`
+}
@@ -0,0 +1,58 @@
+package model
+
+// ShippingRuleType 運費計算方式
+type ShippingRuleType string
+
+const (
+	ShippingRuleFlat     ShippingRuleType = "flat"      // 固定運費
+	ShippingRuleWeight   ShippingRuleType = "weight"    // 依重量計費
+	ShippingRuleFreeOver ShippingRuleType = "free_over" // 滿額免運
+)
+
+// ShippingRule 配送方式對應的運費規則
+type ShippingRule struct {
+	Method        string           `json:"method"`
+	Type          ShippingRuleType `json:"type"`
+	Fee           float64          `json:"fee"`           // flat / free_over 使用的運費
+	BaseFee       float64          `json:"baseFee"`       // weight 使用的基本運費
+	PerKg         float64          `json:"perKg"`         // weight 每公斤運費
+	FreeThreshold float64          `json:"freeThreshold"` // free_over 免運門檻
+}
+
+// TaxRule 地區稅率規則
+type TaxRule struct {
+	Region   string  `json:"region"`
+	Rate     float64 `json:"rate"`     // 例如 0.05 代表 5%
+	Included bool    `json:"included"` // true 表示價格已含稅
+}
+
+// DiscountRule 滿額折扣規則
+type DiscountRule struct {
+	Name        string  `json:"name"`
+	MinSubtotal float64 `json:"minSubtotal"`
+	Amount      float64 `json:"amount"`  // 固定折抵金額
+	Percent     float64 `json:"percent"` // 折扣百分比，例如 0.1 代表打九折
+}
+
+// PricingLine 計價用的商品行
+type PricingLine struct {
+	ProductID string
+	Price     float64
+	Quantity  int
+	Weight    float64 // 單件重量（公斤）
+	Group     string  // 出貨分組（賣家/倉庫），CalculateSplit 依此分別計算運費
+}
+
+// PriceBreakdown 金額明細
+type PriceBreakdown struct {
+	Subtotal       float64 `json:"subtotal"`
+	Discount       float64 `json:"discount"`
+	DiscountName   string  `json:"discountName,omitempty"`
+	ShippingMethod string  `json:"shippingMethod"`
+	ShippingFee    float64 `json:"shippingFee"`
+	TaxRegion      string  `json:"taxRegion"`
+	TaxRate        float64 `json:"taxRate"`
+	TaxIncluded    bool    `json:"taxIncluded"`
+	Tax            float64 `json:"tax"`
+	GrandTotal     float64 `json:"grandTotal"`
+}
@@ -0,0 +1,107 @@
+package model
+
+import "time"
+
+// ReturnStatus 退貨申請狀態
+type ReturnStatus string
+
+const (
+	ReturnStatusRequested ReturnStatus = "requested"
+	ReturnStatusApproved  ReturnStatus = "approved"
+	ReturnStatusRejected  ReturnStatus = "rejected"
+	ReturnStatusReceived  ReturnStatus = "received"
+	ReturnStatusRefunded  ReturnStatus = "refunded"
+)
+
+// returnTransitions 允許的狀態轉換：requested→approved→received→refunded，
+// 申請可被拒絕；rejected 與 refunded 為終止狀態
+var returnTransitions = map[ReturnStatus][]ReturnStatus{
+	ReturnStatusRequested: {ReturnStatusApproved, ReturnStatusRejected},
+	ReturnStatusApproved:  {ReturnStatusReceived},
+	ReturnStatusReceived:  {ReturnStatusRefunded},
+}
+
+// IsValid 是否為已定義的退貨狀態
+func (s ReturnStatus) IsValid() bool {
+	switch s {
+	case ReturnStatusRequested,
+		ReturnStatusApproved,
+		ReturnStatusRejected,
+		ReturnStatusReceived,
+		ReturnStatusRefunded:
+		return true
+	}
+	return false
+}
+
+// CanTransitionTo 是否允許從目前狀態轉換到 next
+func (s ReturnStatus) CanTransitionTo(next ReturnStatus) bool {
+	for _, allowed := range returnTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// ReturnRequest 退貨申請（RMA），存於 returns/{id}
+type ReturnRequest struct {
+	ID            string               `json:"id"`
+	OrderID       string               `json:"orderId"`
+	UserID        string               `json:"userId"`
+	Items         []ReturnItem         `json:"items"`
+	Reason        string               `json:"reason"`
+	Photos        []string             `json:"photos,omitempty"`
+	Status        ReturnStatus         `json:"status"`
+	RefundAmount  float64              `json:"refundAmount"`            // 依下單價格與折扣比例計算，不含運費
+	Restock       bool                 `json:"restock"`                 // 收貨時是否回補庫存
+	RestockFailed []string             `json:"restockFailed,omitempty"` // 回補庫存失敗的商品ID，需人工處理
+	RefundError   string               `json:"refundError,omitempty"`   // 最近一次退款失敗原因
+	History       []ReturnStatusChange `json:"history"`
+	CreatedAt     time.Time            `json:"createdAt"`
+	UpdatedAt     time.Time            `json:"updatedAt"`
+}
+
+// ReturnItem 退貨商品，名稱與單價取自訂單快照
+type ReturnItem struct {
+	ProductID string  `json:"productId"`
+	Name      string  `json:"name"`
+	Price     float64 `json:"price"`
+	Quantity  int     `json:"quantity"`
+	Reason    string  `json:"reason,omitempty"`
+}
+
+// ReturnStatusChange 退貨狀態變更紀錄
+type ReturnStatusChange struct {
+	From  ReturnStatus `json:"from,omitempty"`
+	To    ReturnStatus `json:"to"`
+	Actor string       `json:"actor"` // 操作者用戶ID，背景流程為 system
+	Note  string       `json:"note,omitempty"`
+	At    time.Time    `json:"at"`
+}
+
+// ReturnItemRequest 申請退貨的商品
+type ReturnItemRequest struct {
+	ProductID string `json:"productId" binding:"required"`
+	Quantity  int    `json:"quantity" binding:"required,gt=0"`
+	Reason    string `json:"reason" binding:"max=500"`
+}
+
+// CreateReturnRequest 申請退貨請求
+type CreateReturnRequest struct {
+	Items  []ReturnItemRequest `json:"items" binding:"required,min=1,dive"`
+	Reason string              `json:"reason" binding:"required,max=1000"`
+	Photos []string            `json:"photos" binding:"max=5,dive,url"` // 照片URL
+}
+
+// ReviewReturnRequest 審核退貨請求
+type ReviewReturnRequest struct {
+	Approve *bool  `json:"approve" binding:"required"`
+	Note    string `json:"note" binding:"max=1000"`
+}
+
+// ReceiveReturnRequest 確認收到退貨請求
+type ReceiveReturnRequest struct {
+	Restock bool   `json:"restock"`
+	Note    string `json:"note" binding:"max=1000"`
+}
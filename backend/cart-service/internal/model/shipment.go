@@ -0,0 +1,60 @@
+package model
+
+import "time"
+
+// ShipmentStatus 貨件狀態
+type ShipmentStatus string
+
+const (
+	ShipmentStatusLabelCreated   ShipmentStatus = "label_created"
+	ShipmentStatusInTransit      ShipmentStatus = "in_transit"
+	ShipmentStatusOutForDelivery ShipmentStatus = "out_for_delivery"
+	ShipmentStatusDelivered      ShipmentStatus = "delivered"
+	ShipmentStatusException      ShipmentStatus = "exception"
+	ShipmentStatusFailed         ShipmentStatus = "failed" // 物流商查無追蹤號碼，不再同步，需人工處理
+)
+
+// ActiveShipmentStatuses 尚未送達、需同步物流狀態的貨件狀態
+var ActiveShipmentStatuses = []ShipmentStatus{
+	ShipmentStatusLabelCreated,
+	ShipmentStatusInTransit,
+	ShipmentStatusOutForDelivery,
+	ShipmentStatusException,
+}
+
+// Shipment 貨件，一筆訂單可分多個貨件出貨
+type Shipment struct {
+	ID             string          `json:"id"`
+	OrderID        string          `json:"orderId"`
+	UserID         string          `json:"userId"`
+	Carrier        string          `json:"carrier"`
+	TrackingNumber string          `json:"trackingNumber"`
+	Status         ShipmentStatus  `json:"status"`
+	Items          []ShipmentItem  `json:"items"`
+	Events         []TrackingEvent `json:"events,omitempty"`
+	ShippedAt      *time.Time      `json:"shippedAt,omitempty"`
+	DeliveredAt    *time.Time      `json:"deliveredAt,omitempty"`
+	CreatedAt      time.Time       `json:"createdAt"`
+	UpdatedAt      time.Time       `json:"updatedAt"`
+}
+
+// ShipmentItem 貨件內的商品
+type ShipmentItem struct {
+	ProductID string `json:"productId" binding:"required"`
+	Quantity  int    `json:"quantity" binding:"required,gt=0"`
+}
+
+// TrackingEvent 物流追蹤事件
+type TrackingEvent struct {
+	Status      ShipmentStatus `json:"status"`
+	Description string         `json:"description"`
+	Location    string         `json:"location,omitempty"`
+	OccurredAt  time.Time      `json:"occurredAt"`
+}
+
+// CreateShipmentRequest 建立貨件請求
+type CreateShipmentRequest struct {
+	Carrier        string         `json:"carrier" binding:"required"`
+	TrackingNumber string         `json:"trackingNumber"`                 // 空白時由物流商產生
+	Items          []ShipmentItem `json:"items" binding:"omitempty,dive"` // 空白時出貨所有尚未出貨的商品
+}
@@ -0,0 +1,134 @@
+package model
+
+import "time"
+
+// SubscriptionStatus 訂閱狀態
+type SubscriptionStatus string
+
+const (
+	SubscriptionStatusActive    SubscriptionStatus = "active"
+	SubscriptionStatusPaused    SubscriptionStatus = "paused" // 用戶暫停或連續失敗次數過多
+	SubscriptionStatusCancelled SubscriptionStatus = "cancelled"
+)
+
+// SubscriptionFrequency 訂閱頻率
+type SubscriptionFrequency string
+
+const (
+	SubscriptionFrequencyWeekly    SubscriptionFrequency = "weekly"
+	SubscriptionFrequencyBiweekly  SubscriptionFrequency = "biweekly"
+	SubscriptionFrequencyMonthly   SubscriptionFrequency = "monthly"
+	SubscriptionFrequencyBimonthly SubscriptionFrequency = "bimonthly"
+)
+
+// IsValid 檢查頻率是否有效
+func (f SubscriptionFrequency) IsValid() bool {
+	switch f {
+	case SubscriptionFrequencyWeekly, SubscriptionFrequencyBiweekly, SubscriptionFrequencyMonthly, SubscriptionFrequencyBimonthly:
+		return true
+	}
+	return false
+}
+
+// Next 返回 t 之後的下一次執行時間；每月與每兩月以 anchorDay（最初排定的日期）為準，
+// 該月沒有這一天時改為月底，例如 1/31 之後依序為 2/28、3/31；anchorDay 為 0 時使用 t 的日期
+func (f SubscriptionFrequency) Next(t time.Time, anchorDay int) time.Time {
+	switch f {
+	case SubscriptionFrequencyWeekly:
+		return t.AddDate(0, 0, 7)
+	case SubscriptionFrequencyBiweekly:
+		return t.AddDate(0, 0, 14)
+	case SubscriptionFrequencyBimonthly:
+		return addMonths(t, 2, anchorDay)
+	default:
+		return addMonths(t, 1, anchorDay)
+	}
+}
+
+// addMonths 將 t 順延 months 個月並對齊 anchorDay，超過該月天數時取月底
+func addMonths(t time.Time, months, anchorDay int) time.Time {
+	if anchorDay <= 0 {
+		anchorDay = t.Day()
+	}
+	year, month, _ := t.Date()
+	target := month + time.Month(months)
+	if lastDay := time.Date(year, target+1, 0, 0, 0, 0, 0, t.Location()).Day(); anchorDay > lastDay {
+		anchorDay = lastDay
+	}
+	return time.Date(year, target, anchorDay, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+}
+
+// SubscriptionRunStatus 訂閱單次執行結果
+type SubscriptionRunStatus string
+
+const (
+	SubscriptionRunSucceeded SubscriptionRunStatus = "succeeded"
+	SubscriptionRunFailed    SubscriptionRunStatus = "failed"
+	SubscriptionRunSkipped   SubscriptionRunStatus = "skipped" // 用戶略過該次
+)
+
+// SubscriptionItem 訂閱商品與數量，每次執行時以當下價格下單
+type SubscriptionItem struct {
+	ProductID string `json:"productId" binding:"required"`
+	Quantity  int    `json:"quantity" binding:"required,min=1"`
+}
+
+// SubscriptionRun 訂閱執行紀錄
+type SubscriptionRun struct {
+	ScheduledAt time.Time             `json:"scheduledAt"`
+	Status      SubscriptionRunStatus `json:"status"`
+	OrderID     string                `json:"orderId,omitempty"`
+	SagaID      string                `json:"sagaId,omitempty"`
+	Error       string                `json:"error,omitempty"`
+	At          time.Time             `json:"at"`
+}
+
+// Subscription 定期訂購，存於 subscriptions/{id}
+type Subscription struct {
+	ID            string                `json:"id"`
+	UserID        string                `json:"userId"`
+	Items         []SubscriptionItem    `json:"items"`
+	Frequency     SubscriptionFrequency `json:"frequency"`
+	NextRunAt     time.Time             `json:"nextRunAt"`
+	AnchorDay     int                   `json:"anchorDay,omitempty"` // 每月排程對齊的日期
+	RetryOf       *time.Time            `json:"retryOf,omitempty"`   // NextRunAt 為失敗重試時，原本的排程時間
+	ShippingInfo  ShippingInfo          `json:"shippingInfo"`
+	PaymentMethod string                `json:"paymentMethod"`
+	Currency      string                `json:"currency,omitempty"`
+	EInvoiceInfo  *EInvoiceInfo         `json:"eInvoiceInfo,omitempty"`
+	Status        SubscriptionStatus    `json:"status"`
+	RemindedFor   *time.Time            `json:"remindedFor,omitempty"` // 已發送執行前提醒的排程時間
+	Failures      int                   `json:"failures"`              // 連續失敗次數，成功後歸零
+	Runs          []SubscriptionRun     `json:"runs,omitempty"`        // 最近的執行紀錄
+	CreatedAt     time.Time             `json:"createdAt"`
+	UpdatedAt     time.Time             `json:"updatedAt"`
+}
+
+// ScheduledRunAt 返回目前排程的原定時間，失敗重試中時為被重試的排程而非重試時間
+func (s *Subscription) ScheduledRunAt() time.Time {
+	if s.RetryOf != nil {
+		return *s.RetryOf
+	}
+	return s.NextRunAt
+}
+
+// CreateSubscriptionRequest 建立訂閱請求
+type CreateSubscriptionRequest struct {
+	Items         []SubscriptionItem    `json:"items" binding:"required,min=1,dive"`
+	Frequency     SubscriptionFrequency `json:"frequency" binding:"required"`
+	StartAt       *time.Time            `json:"startAt"`      // 第一次執行時間，未提供時為一個週期後
+	ShippingInfo  *ShippingInfo         `json:"shippingInfo"` // 未提供時使用預設地址
+	PaymentMethod string                `json:"paymentMethod" binding:"required"`
+	Currency      string                `json:"currency"`
+	EInvoice      *EInvoiceInfo         `json:"eInvoice"`
+}
+
+// UpdateSubscriptionRequest 更新訂閱請求，未提供的欄位保持不變
+type UpdateSubscriptionRequest struct {
+	Items         []SubscriptionItem    `json:"items" binding:"omitempty,min=1,dive"`
+	Frequency     SubscriptionFrequency `json:"frequency"`
+	NextRunAt     *time.Time            `json:"nextRunAt"`
+	ShippingInfo  *ShippingInfo         `json:"shippingInfo"`
+	PaymentMethod string                `json:"paymentMethod"`
+	EInvoice      *EInvoiceInfo         `json:"eInvoice"`
+}
@@ -0,0 +1,87 @@
+package model
+
+import "time"
+
+// WishlistVisibility 具名收藏清單的公開設定
+type WishlistVisibility string
+
+const (
+	WishlistVisibilityPrivate WishlistVisibility = "private"
+	WishlistVisibilityPublic  WishlistVisibility = "public"
+)
+
+// 預設收藏清單：舊的收藏清單端點與「移到收藏清單」都操作此清單
+const (
+	DefaultWishlistListID   = "default"
+	DefaultWishlistListName = "我的收藏"
+)
+
+// WishlistListItem 具名收藏清單中的商品，以商品ID為鍵
+type WishlistListItem struct {
+	ProductID string       `json:"productId"`
+	Note      string       `json:"note,omitempty"`
+	Quantity  int          `json:"quantity"` // 想要的數量
+	AddedAt   time.Time    `json:"addedAt"`
+	Product   *ProductInfo `json:"product,omitempty"` // 商品詳細資訊 (不儲存)
+}
+
+// WishlistList 用戶的具名收藏清單，存於 wishlist_lists/{userId}/{id}；
+// 公開清單另以 wishlist_shares/{shareToken} 對應至此
+type WishlistList struct {
+	ID          string                      `json:"id"`
+	UserID      string                      `json:"userId"`
+	Name        string                      `json:"name"`
+	Description string                      `json:"description,omitempty"`
+	Visibility  WishlistVisibility          `json:"visibility"`
+	ShareToken  string                      `json:"shareToken,omitempty"`
+	Items       map[string]WishlistListItem `json:"items,omitempty"`
+	CreatedAt   time.Time                   `json:"createdAt"`
+	UpdatedAt   time.Time                   `json:"updatedAt"`
+}
+
+// WishlistShare 分享連結對應的清單
+type WishlistShare struct {
+	UserID string `json:"userId"`
+	ListID string `json:"listId"`
+}
+
+// WishlistListResponse 具名收藏清單響應，商品依加入時間由新到舊排列
+type WishlistListResponse struct {
+	ID          string             `json:"id"`
+	UserID      string             `json:"userId,omitempty"` // 分享檢視時不返回
+	Name        string             `json:"name"`
+	Description string             `json:"description,omitempty"`
+	Visibility  WishlistVisibility `json:"visibility"`
+	ShareToken  string             `json:"shareToken,omitempty"` // 分享檢視時不返回
+	Items       []WishlistListItem `json:"items"`
+	ItemCount   int                `json:"itemCount"`
+	CreatedAt   time.Time          `json:"createdAt"`
+	UpdatedAt   time.Time          `json:"updatedAt"`
+}
+
+// CreateWishlistListRequest 建立具名收藏清單請求，visibility 預設為 private
+type CreateWishlistListRequest struct {
+	Name        string             `json:"name" binding:"required,max=100"`
+	Description string             `json:"description" binding:"max=500"`
+	Visibility  WishlistVisibility `json:"visibility" binding:"omitempty,oneof=private public"`
+}
+
+// UpdateWishlistListRequest 更新具名收藏清單請求，未提供的欄位不變更
+type UpdateWishlistListRequest struct {
+	Name        *string            `json:"name" binding:"omitempty,min=1,max=100"`
+	Description *string            `json:"description" binding:"omitempty,max=500"`
+	Visibility  WishlistVisibility `json:"visibility" binding:"omitempty,oneof=private public"`
+}
+
+// AddWishlistListItemRequest 加入商品至具名收藏清單請求，已存在時更新備註與數量
+type AddWishlistListItemRequest struct {
+	ProductID string `json:"productId" binding:"required"`
+	Note      string `json:"note" binding:"max=500"`
+	Quantity  int    `json:"quantity" binding:"omitempty,min=1,max=99"`
+}
+
+// UpdateWishlistListItemRequest 更新具名收藏清單商品請求，未提供的欄位不變更
+type UpdateWishlistListItemRequest struct {
+	Note     *string `json:"note" binding:"omitempty,max=500"`
+	Quantity *int    `json:"quantity" binding:"omitempty,min=1,max=99"`
+}
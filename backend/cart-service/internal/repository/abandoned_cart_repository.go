@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"firebase.google.com/go/db"
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/model"
+)
+
+// AbandonedCartRepository 棄置購物車記錄倉庫接口
+type AbandonedCartRepository interface {
+	Get(ctx context.Context, userID string) (*model.AbandonedCart, error)
+	Save(ctx context.Context, record *model.AbandonedCart) error
+	IncrementStat(ctx context.Context, status model.AbandonedCartStatus) error
+	GetStats(ctx context.Context) (*model.AbandonedCartStats, error)
+}
+
+type abandonedCartRepository struct {
+	client *db.Client
+}
+
+// NewAbandonedCartRepository 創建棄置購物車記錄倉庫實例
+func NewAbandonedCartRepository(client *db.Client) AbandonedCartRepository {
+	return &abandonedCartRepository{
+		client: client,
+	}
+}
+
+// Get 獲取用戶的棄置購物車記錄，不存在時返回 nil
+func (r *abandonedCartRepository) Get(ctx context.Context, userID string) (*model.AbandonedCart, error) {
+	var record model.AbandonedCart
+	if err := r.client.NewRef("abandoned_carts").Child(userID).Get(ctx, &record); err != nil {
+		return nil, fmt.Errorf("failed to get abandoned cart: %v", err)
+	}
+	if record.UserID == "" {
+		return nil, nil
+	}
+	return &record, nil
+}
+
+// Save 保存棄置購物車記錄
+func (r *abandonedCartRepository) Save(ctx context.Context, record *model.AbandonedCart) error {
+	return r.client.NewRef("abandoned_carts").Child(record.UserID).Set(ctx, record)
+}
+
+// IncrementStat 以交易方式累加對應狀態的計數
+func (r *abandonedCartRepository) IncrementStat(ctx context.Context, status model.AbandonedCartStatus) error {
+	ref := r.client.NewRef("abandoned_cart_stats").Child(string(status))
+	return ref.Transaction(ctx, func(tn db.TransactionNode) (interface{}, error) {
+		var count int
+		if err := tn.Unmarshal(&count); err != nil {
+			return nil, err
+		}
+		return count + 1, nil
+	})
+}
+
+// GetStats 獲取棄置購物車統計
+func (r *abandonedCartRepository) GetStats(ctx context.Context) (*model.AbandonedCartStats, error) {
+	var counts map[string]int
+	if err := r.client.NewRef("abandoned_cart_stats").Get(ctx, &counts); err != nil {
+		return nil, fmt.Errorf("failed to get abandoned cart stats: %v", err)
+	}
+
+	stats := &model.AbandonedCartStats{
+		Abandoned: counts[string(model.AbandonedCartStatusAbandoned)],
+		Reminded:  counts[string(model.AbandonedCartStatusReminded)],
+		Recovered: counts[string(model.AbandonedCartStatusRecovered)],
+	}
+	if stats.Abandoned > 0 {
+		stats.RecoveryRate = float64(stats.Recovered) / float64(stats.Abandoned)
+	}
+	return stats, nil
+}
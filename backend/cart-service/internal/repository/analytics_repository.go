@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"firebase.google.com/go/db"
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/model"
+)
+
+// AnalyticsRepository 報表彙總存儲接口
+type AnalyticsRepository interface {
+	UpdateSales(ctx context.Context, interval model.AnalyticsInterval, period string, apply func(bucket *model.SalesBucket)) error
+	ListSales(ctx context.Context, interval model.AnalyticsInterval, fromPeriod, toPeriod string) ([]model.SalesBucket, error)
+	UpdateProductSales(ctx context.Context, day, productID string, apply func(sales *model.ProductSales)) error
+	ListProductSales(ctx context.Context, fromDay, toDay string) ([]model.ProductSales, error)
+	UpdateCustomer(ctx context.Context, userID string, apply func(stats *model.CustomerStats)) error
+	MarkRefunded(ctx context.Context, orderID string) (bool, error)
+}
+
+type analyticsRepository struct {
+	client *db.Client
+}
+
+// NewAnalyticsRepository 創建報表彙總存儲實例
+func NewAnalyticsRepository(client *db.Client) AnalyticsRepository {
+	return &analyticsRepository{
+		client: client,
+	}
+}
+
+// UpdateSales 以 transaction 遞增區間的銷售彙總，多個實例同時更新時不會遺失
+func (r *analyticsRepository) UpdateSales(ctx context.Context, interval model.AnalyticsInterval, period string, apply func(bucket *model.SalesBucket)) error {
+	ref := r.client.NewRef("analytics/sales").Child(string(interval)).Child(period)
+	err := ref.Transaction(ctx, func(tn db.TransactionNode) (interface{}, error) {
+		var bucket model.SalesBucket
+		if err := tn.Unmarshal(&bucket); err != nil {
+			return nil, err
+		}
+		bucket.Period = period
+		apply(&bucket)
+		bucket.UpdatedAt = time.Now()
+		return &bucket, nil
+	})
+	if err != nil {
+		return fmt.Errorf("error updating sales bucket: %v", err)
+	}
+	return nil
+}
+
+// ListSales 依區間鍵值順序獲取 [fromPeriod, toPeriod] 的銷售彙總，沒有資料的區間不返回
+func (r *analyticsRepository) ListSales(ctx context.Context, interval model.AnalyticsInterval, fromPeriod, toPeriod string) ([]model.SalesBucket, error) {
+	var buckets map[string]model.SalesBucket
+	err := r.client.NewRef("analytics/sales").Child(string(interval)).
+		OrderByKey().StartAt(fromPeriod).EndAt(toPeriod).Get(ctx, &buckets)
+	if err != nil {
+		return nil, fmt.Errorf("error getting sales buckets: %v", err)
+	}
+
+	result := make([]model.SalesBucket, 0, len(buckets))
+	for period, bucket := range buckets {
+		bucket.Period = period
+		result = append(result, bucket)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Period < result[j].Period
+	})
+	return result, nil
+}
+
+// UpdateProductSales 以 transaction 遞增商品當日的銷售彙總
+func (r *analyticsRepository) UpdateProductSales(ctx context.Context, day, productID string, apply func(sales *model.ProductSales)) error {
+	ref := r.client.NewRef("analytics/products").Child(day).Child(productID)
+	err := ref.Transaction(ctx, func(tn db.TransactionNode) (interface{}, error) {
+		var sales model.ProductSales
+		if err := tn.Unmarshal(&sales); err != nil {
+			return nil, err
+		}
+		sales.ProductID = productID
+		apply(&sales)
+		return &sales, nil
+	})
+	if err != nil {
+		return fmt.Errorf("error updating product sales: %v", err)
+	}
+	return nil
+}
+
+// ListProductSales 獲取 [fromDay, toDay] 各日的商品銷售彙總，同一商品在不同日分別返回
+func (r *analyticsRepository) ListProductSales(ctx context.Context, fromDay, toDay string) ([]model.ProductSales, error) {
+	var days map[string]map[string]model.ProductSales
+	err := r.client.NewRef("analytics/products").OrderByKey().StartAt(fromDay).EndAt(toDay).Get(ctx, &days)
+	if err != nil {
+		return nil, fmt.Errorf("error getting product sales: %v", err)
+	}
+
+	var result []model.ProductSales
+	for _, products := range days {
+		for productID, sales := range products {
+			sales.ProductID = productID
+			result = append(result, sales)
+		}
+	}
+	return result, nil
+}
+
+// UpdateCustomer 以 transaction 更新顧客付款紀錄；apply 可能因衝突重複執行，需只依傳入的紀錄計算
+func (r *analyticsRepository) UpdateCustomer(ctx context.Context, userID string, apply func(stats *model.CustomerStats)) error {
+	err := r.client.NewRef("analytics/customers").Child(userID).Transaction(ctx, func(tn db.TransactionNode) (interface{}, error) {
+		var stats model.CustomerStats
+		if err := tn.Unmarshal(&stats); err != nil {
+			return nil, err
+		}
+		apply(&stats)
+		return &stats, nil
+	})
+	if err != nil {
+		return fmt.Errorf("error updating customer stats: %v", err)
+	}
+	return nil
+}
+
+// MarkRefunded 以 transaction 標記訂單已有退款，存於 analytics/refunded_orders/{orderId}；
+// 返回是否為第一次標記，用於同一訂單的多次部分退款只計一筆退款訂單
+func (r *analyticsRepository) MarkRefunded(ctx context.Context, orderID string) (bool, error) {
+	first := false
+	err := r.client.NewRef("analytics/refunded_orders").Child(orderID).Transaction(ctx, func(tn db.TransactionNode) (interface{}, error) {
+		var markedAt string
+		if err := tn.Unmarshal(&markedAt); err != nil {
+			return nil, err
+		}
+		first = markedAt == ""
+		if !first {
+			return markedAt, nil
+		}
+		return time.Now().Format(time.RFC3339), nil
+	})
+	if err != nil {
+		return false, fmt.Errorf("error marking refunded order: %v", err)
+	}
+	return first, nil
+}
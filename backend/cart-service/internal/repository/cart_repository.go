@@ -16,9 +16,14 @@ type CartRepository interface {
 	UpdateCartItems(ctx context.Context, userID string, items []model.CartItem) error
 	AddItem(ctx context.Context, userID string, item model.CartItem) error
 	RemoveItem(ctx context.Context, userID string, productID string) error
+	RemoveItems(ctx context.Context, userID string, productIDs []string) error
 	UpdateQuantity(ctx context.Context, userID string, productID string, quantity int) error
 	SelectItems(ctx context.Context, userID string, productIDs []string) error
 	ClearCart(ctx context.Context, userID string) error
+	SaveForLater(ctx context.Context, userID string, productID string) error
+	MoveToCart(ctx context.Context, userID string, productID string) error
+	RemoveSavedItem(ctx context.Context, userID string, productID string) error
+	ListIdleCarts(ctx context.Context, before time.Time) ([]model.Cart, error)
 }
 
 type cartRepository struct {
@@ -42,6 +47,7 @@ func (r *cartRepository) GetCart(ctx context.Context, userID string) (*model.Car
 }
 
 func (r *cartRepository) SaveCart(ctx context.Context, cart *model.Cart) error {
+	cart.UpdatedAtMillis = cart.UpdatedAt.UnixMilli()
 	return r.client.NewRef("carts").Child(cart.UserID).Set(ctx, cart)
 }
 
@@ -121,6 +127,31 @@ func (r *cartRepository) RemoveItem(ctx context.Context, userID string, productI
 	}
 
 	cart.Items = updatedItems
+	cart.UpdatedAt = time.Now()
+	return r.SaveCart(ctx, cart)
+}
+
+// RemoveItems 一次移除多個商品
+func (r *cartRepository) RemoveItems(ctx context.Context, userID string, productIDs []string) error {
+	cart, err := r.GetCart(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	remove := make(map[string]bool, len(productIDs))
+	for _, productID := range productIDs {
+		remove[productID] = true
+	}
+
+	var updatedItems []model.CartItem
+	for _, item := range cart.Items {
+		if !remove[item.ProductID] {
+			updatedItems = append(updatedItems, item)
+		}
+	}
+
+	cart.Items = updatedItems
+	cart.UpdatedAt = time.Now()
 	return r.SaveCart(ctx, cart)
 }
 
@@ -133,11 +164,12 @@ func (r *cartRepository) UpdateQuantity(ctx context.Context, userID string, prod
 	for i, item := range cart.Items {
 		if item.ProductID == productID {
 			cart.Items[i].Quantity = quantity
+			cart.UpdatedAt = time.Now()
 			return r.SaveCart(ctx, cart)
 		}
 	}
 
-	return fmt.Errorf("product not found in cart")
+	return ErrItemNotFound
 }
 
 func (r *cartRepository) SelectItems(ctx context.Context, userID string, productIDs []string) error {
@@ -155,9 +187,123 @@ func (r *cartRepository) SelectItems(ctx context.Context, userID string, product
 		cart.Items[i].Selected = selectedProducts[cart.Items[i].ProductID]
 	}
 
+	cart.UpdatedAt = time.Now()
 	return r.SaveCart(ctx, cart)
 }
 
+// ClearCart 清空購物車商品，稍後購買的商品會保留
 func (r *cartRepository) ClearCart(ctx context.Context, userID string) error {
-	return r.DeleteCart(ctx, userID)
+	cart, err := r.GetCart(ctx, userID)
+	if err != nil || len(cart.SavedItems) == 0 {
+		return r.DeleteCart(ctx, userID)
+	}
+
+	cart.Items = nil
+	cart.UpdatedAt = time.Now()
+	return r.SaveCart(ctx, cart)
+}
+
+// SaveForLater 將商品從購物車移到稍後購買，保留數量
+func (r *cartRepository) SaveForLater(ctx context.Context, userID string, productID string) error {
+	cart, err := r.GetCart(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	var moved bool
+	cart.Items, cart.SavedItems, moved = moveCartItem(cart.Items, cart.SavedItems, productID)
+	if !moved {
+		return ErrItemNotFound
+	}
+
+	cart.UpdatedAt = time.Now()
+	return r.SaveCart(ctx, cart)
+}
+
+// MoveToCart 將商品從稍後購買移回購物車，保留數量
+func (r *cartRepository) MoveToCart(ctx context.Context, userID string, productID string) error {
+	cart, err := r.GetCart(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	var moved bool
+	cart.SavedItems, cart.Items, moved = moveCartItem(cart.SavedItems, cart.Items, productID)
+	if !moved {
+		return ErrItemNotFound
+	}
+
+	cart.UpdatedAt = time.Now()
+	return r.SaveCart(ctx, cart)
+}
+
+// RemoveSavedItem 從稍後購買中移除商品
+func (r *cartRepository) RemoveSavedItem(ctx context.Context, userID string, productID string) error {
+	cart, err := r.GetCart(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	var updatedItems []model.CartItem
+	for _, item := range cart.SavedItems {
+		if item.ProductID != productID {
+			updatedItems = append(updatedItems, item)
+		}
+	}
+	if len(updatedItems) == len(cart.SavedItems) {
+		return ErrItemNotFound
+	}
+
+	cart.SavedItems = updatedItems
+	cart.UpdatedAt = time.Now()
+	return r.SaveCart(ctx, cart)
+}
+
+// ListIdleCarts 獲取最後更新時間早於 before 的購物車
+// 需要在 Realtime Database 規則中為 carts 設置 ".indexOn": "UpdatedAtMillis"
+func (r *cartRepository) ListIdleCarts(ctx context.Context, before time.Time) ([]model.Cart, error) {
+	var carts map[string]model.Cart
+	if err := r.client.NewRef("carts").OrderByChild("UpdatedAtMillis").EndAt(before.UnixMilli()).Get(ctx, &carts); err != nil {
+		return nil, fmt.Errorf("failed to list idle carts: %v", err)
+	}
+
+	result := make([]model.Cart, 0, len(carts))
+	for userID, cart := range carts {
+		// 尚未寫入 UpdatedAtMillis 的舊購物車排序在最前面，會一併返回，這裡以時間比較一次
+		if cart.UpdatedAt.IsZero() || !cart.UpdatedAt.Before(before) {
+			continue
+		}
+		if cart.UserID == "" {
+			cart.UserID = userID
+		}
+		result = append(result, cart)
+	}
+	return result, nil
+}
+
+// moveCartItem 將商品從 from 移到 to，若 to 已有該商品則合併數量
+func moveCartItem(from, to []model.CartItem, productID string) ([]model.CartItem, []model.CartItem, bool) {
+	index := -1
+	for i, item := range from {
+		if item.ProductID == productID {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return from, to, false
+	}
+
+	item := from[index]
+	item.UpdatedAt = time.Now()
+	from = append(from[:index], from[index+1:]...)
+
+	for i := range to {
+		if to[i].ProductID == productID {
+			to[i].Quantity += item.Quantity
+			to[i].UpdatedAt = item.UpdatedAt
+			return from, to, true
+		}
+	}
+	return from, append(to, item), true
 }
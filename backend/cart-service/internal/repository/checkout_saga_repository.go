@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"firebase.google.com/go/db"
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/model"
+)
+
+// CheckoutSagaRepository 結帳流程狀態存儲接口
+type CheckoutSagaRepository interface {
+	Save(ctx context.Context, saga *model.CheckoutSaga) error
+	GetByID(ctx context.Context, id string) (*model.CheckoutSaga, error)
+	ListByStatus(ctx context.Context, status model.SagaStatus) ([]model.CheckoutSaga, error)
+	Claim(ctx context.Context, id, owner string, until time.Time) (*model.CheckoutSaga, error)
+	Release(ctx context.Context, id, owner string) error
+}
+
+type checkoutSagaRepository struct {
+	client *db.Client
+}
+
+// NewCheckoutSagaRepository 創建結帳流程狀態存儲實例
+func NewCheckoutSagaRepository(client *db.Client) CheckoutSagaRepository {
+	return &checkoutSagaRepository{
+		client: client,
+	}
+}
+
+// Save 以 transaction 保存流程狀態；已存在的流程只有持有租約者可以保存，
+// 租約已被其他執行者接手時返回 ErrSagaLeaseLost
+func (r *checkoutSagaRepository) Save(ctx context.Context, saga *model.CheckoutSaga) error {
+	saga.UpdatedAt = time.Now()
+	err := r.client.NewRef("checkout_sagas").Child(saga.ID).Transaction(ctx, func(tn db.TransactionNode) (interface{}, error) {
+		var current model.CheckoutSaga
+		if err := tn.Unmarshal(&current); err != nil {
+			return nil, err
+		}
+		if current.ID != "" && current.LeaseOwner != saga.LeaseOwner {
+			return nil, ErrSagaLeaseLost
+		}
+		return saga, nil
+	})
+	if err != nil {
+		if err == ErrSagaLeaseLost {
+			return err
+		}
+		return fmt.Errorf("error saving checkout saga: %v", err)
+	}
+	return nil
+}
+
+// GetByID 獲取流程狀態，不存在時返回 nil
+func (r *checkoutSagaRepository) GetByID(ctx context.Context, id string) (*model.CheckoutSaga, error) {
+	var saga model.CheckoutSaga
+	if err := r.client.NewRef("checkout_sagas").Child(id).Get(ctx, &saga); err != nil {
+		return nil, fmt.Errorf("error getting checkout saga: %v", err)
+	}
+	if saga.ID == "" {
+		return nil, nil
+	}
+	return &saga, nil
+}
+
+// Claim 以 transaction 取得流程的租約至 until，返回最新的流程狀態；
+// 其他執行者的租約尚未到期時返回 ErrSagaLeaseHeld
+func (r *checkoutSagaRepository) Claim(ctx context.Context, id, owner string, until time.Time) (*model.CheckoutSaga, error) {
+	var saga model.CheckoutSaga
+	err := r.client.NewRef("checkout_sagas").Child(id).Transaction(ctx, func(tn db.TransactionNode) (interface{}, error) {
+		saga = model.CheckoutSaga{}
+		if err := tn.Unmarshal(&saga); err != nil {
+			return nil, err
+		}
+		if saga.ID == "" {
+			return nil, ErrSagaNotFound
+		}
+		if saga.LeaseOwner != "" && saga.LeaseOwner != owner && saga.LeaseExpiresAt != nil && time.Now().Before(*saga.LeaseExpiresAt) {
+			return nil, ErrSagaLeaseHeld
+		}
+		saga.LeaseOwner = owner
+		saga.LeaseExpiresAt = &until
+		return &saga, nil
+	})
+	if err != nil {
+		if err == ErrSagaNotFound || err == ErrSagaLeaseHeld {
+			return nil, err
+		}
+		return nil, fmt.Errorf("error claiming checkout saga: %v", err)
+	}
+	return &saga, nil
+}
+
+// Release 釋放 owner 持有的租約，租約已被其他執行者接手時返回 ErrSagaLeaseLost
+func (r *checkoutSagaRepository) Release(ctx context.Context, id, owner string) error {
+	err := r.client.NewRef("checkout_sagas").Child(id).Transaction(ctx, func(tn db.TransactionNode) (interface{}, error) {
+		var saga model.CheckoutSaga
+		if err := tn.Unmarshal(&saga); err != nil {
+			return nil, err
+		}
+		if saga.ID == "" || saga.LeaseOwner != owner {
+			return nil, ErrSagaLeaseLost
+		}
+		saga.LeaseOwner = ""
+		saga.LeaseExpiresAt = nil
+		return &saga, nil
+	})
+	if err != nil {
+		if err == ErrSagaLeaseLost {
+			return err
+		}
+		return fmt.Errorf("error releasing checkout saga: %v", err)
+	}
+	return nil
+}
+
+// ListByStatus 獲取指定狀態的流程
+func (r *checkoutSagaRepository) ListByStatus(ctx context.Context, status model.SagaStatus) ([]model.CheckoutSaga, error) {
+	var sagas map[string]model.CheckoutSaga
+	if err := r.client.NewRef("checkout_sagas").OrderByChild("status").EqualTo(string(status)).Get(ctx, &sagas); err != nil {
+		return nil, fmt.Errorf("error getting checkout sagas by status: %v", err)
+	}
+
+	result := make([]model.CheckoutSaga, 0, len(sagas))
+	for _, saga := range sagas {
+		result = append(result, saga)
+	}
+	return result, nil
+}
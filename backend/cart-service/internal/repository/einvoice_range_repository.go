@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"firebase.google.com/go/db"
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/model"
+)
+
+// errRangeUsedUp 區間在 transaction 中發現已用完，改用下一個區間
+var errRangeUsedUp = errors.New("e-invoice number range used up")
+
+// EInvoiceRangeRepository 電子發票字軌號碼區間存儲接口
+type EInvoiceRangeRepository interface {
+	Create(ctx context.Context, numberRange *model.EInvoiceNumberRange) error
+	ListByPeriod(ctx context.Context, period string) ([]model.EInvoiceNumberRange, error)
+	Allocate(ctx context.Context, period string) (string, error)
+	Void(ctx context.Context, void *model.EInvoiceVoid) error
+}
+
+type eInvoiceRangeRepository struct {
+	client *db.Client
+}
+
+// NewEInvoiceRangeRepository 創建字軌號碼區間存儲實例
+func NewEInvoiceRangeRepository(client *db.Client) EInvoiceRangeRepository {
+	return &eInvoiceRangeRepository{
+		client: client,
+	}
+}
+
+// Create 新增號碼區間
+func (r *eInvoiceRangeRepository) Create(ctx context.Context, numberRange *model.EInvoiceNumberRange) error {
+	numberRange.CreatedAt = time.Now()
+	if err := r.client.NewRef("einvoice_ranges").Child(numberRange.ID).Set(ctx, numberRange); err != nil {
+		return fmt.Errorf("error creating e-invoice number range: %v", err)
+	}
+	return nil
+}
+
+// ListByPeriod 依建立順序獲取期別的號碼區間
+func (r *eInvoiceRangeRepository) ListByPeriod(ctx context.Context, period string) ([]model.EInvoiceNumberRange, error) {
+	var ranges map[string]model.EInvoiceNumberRange
+	if err := r.client.NewRef("einvoice_ranges").OrderByChild("period").EqualTo(period).Get(ctx, &ranges); err != nil {
+		return nil, fmt.Errorf("error getting e-invoice number ranges: %v", err)
+	}
+
+	result := make([]model.EInvoiceNumberRange, 0, len(ranges))
+	for _, numberRange := range ranges {
+		result = append(result, numberRange)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CreatedAt.Before(result[j].CreatedAt)
+	})
+	return result, nil
+}
+
+// Allocate 依建立順序從期別的號碼區間以 transaction 配發下一個號碼，返回字軌加八位號碼；
+// 所有區間皆已用完時返回 ErrEInvoiceExhausted
+func (r *eInvoiceRangeRepository) Allocate(ctx context.Context, period string) (string, error) {
+	ranges, err := r.ListByPeriod(ctx, period)
+	if err != nil {
+		return "", err
+	}
+
+	for _, numberRange := range ranges {
+		if numberRange.Next > numberRange.End {
+			continue
+		}
+		var allocated model.EInvoiceNumberRange
+		var number int64
+		err := r.client.NewRef("einvoice_ranges").Child(numberRange.ID).Transaction(ctx, func(tn db.TransactionNode) (interface{}, error) {
+			allocated = model.EInvoiceNumberRange{}
+			if err := tn.Unmarshal(&allocated); err != nil {
+				return nil, err
+			}
+			if allocated.ID == "" || allocated.Next > allocated.End {
+				return nil, errRangeUsedUp
+			}
+			number = allocated.Next
+			allocated.Next++
+			return &allocated, nil
+		})
+		if err == errRangeUsedUp {
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("error allocating e-invoice number: %v", err)
+		}
+		return fmt.Sprintf("%s%08d", allocated.Track, number), nil
+	}
+	return "", ErrEInvoiceExhausted
+}
+
+// Void 記錄已配發但未使用的號碼
+func (r *eInvoiceRangeRepository) Void(ctx context.Context, void *model.EInvoiceVoid) error {
+	void.VoidedAt = time.Now()
+	if err := r.client.NewRef("einvoice_voids").Child(void.Period).Child(void.Number).Set(ctx, void); err != nil {
+		return fmt.Errorf("error voiding e-invoice number: %v", err)
+	}
+	return nil
+}
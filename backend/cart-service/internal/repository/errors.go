@@ -0,0 +1,20 @@
+package repository
+
+import "errors"
+
+var (
+	ErrItemNotFound         = errors.New("product not found in cart")
+	ErrOrderNotFound        = errors.New("order not found")
+	ErrOrderStatusStale     = errors.New("order status changed concurrently")
+	ErrReturnNotFound       = errors.New("return request not found")
+	ErrReturnStatusStale    = errors.New("return status changed concurrently")
+	ErrEInvoiceExhausted    = errors.New("no e-invoice numbers left for period")
+	ErrEInvoiceClaimHeld    = errors.New("e-invoice is being issued by another request")
+	ErrEInvoiceClaimLost    = errors.New("e-invoice claim taken over by another request")
+	ErrSubscriptionNotFound = errors.New("subscription not found")
+	ErrSagaNotFound         = errors.New("checkout saga not found")
+	ErrSagaLeaseHeld        = errors.New("checkout saga is being run by another worker")
+	ErrSagaLeaseLost        = errors.New("checkout saga lease taken over by another worker")
+	ErrWishlistListNotFound = errors.New("wishlist list not found")
+	ErrWishlistListLimit    = errors.New("too many wishlist lists")
+)
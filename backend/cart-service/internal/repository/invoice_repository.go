@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"firebase.google.com/go/db"
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/model"
+)
+
+// InvoiceRepository 發票存儲接口
+type InvoiceRepository interface {
+	GetByID(ctx context.Context, id string) (*model.Invoice, error)
+	Claim(ctx context.Context, series, invoiceID string) (int64, error)
+	Complete(ctx context.Context, invoice *model.Invoice) (*model.Invoice, error)
+}
+
+type invoiceRepository struct {
+	client *db.Client
+}
+
+// NewInvoiceRepository 創建發票存儲實例
+func NewInvoiceRepository(client *db.Client) InvoiceRepository {
+	return &invoiceRepository{
+		client: client,
+	}
+}
+
+// invoiceNumbers 序列的號碼分配狀態，存於 invoice_numbers/{series}
+type invoiceNumbers struct {
+	Last     int64            `json:"last"`
+	Assigned map[string]int64 `json:"assigned,omitempty"` // 發票ID 對應已分配的號碼
+}
+
+// GetByID 獲取發票，不存在時返回 nil
+func (r *invoiceRepository) GetByID(ctx context.Context, id string) (*model.Invoice, error) {
+	var invoice model.Invoice
+	if err := r.client.NewRef("invoices").Child(id).Get(ctx, &invoice); err != nil {
+		return nil, fmt.Errorf("error getting invoice: %v", err)
+	}
+	if invoice.ID == "" {
+		return nil, nil
+	}
+	return &invoice, nil
+}
+
+// Claim 以 transaction 為發票ID佔用序列的號碼：已佔用時返回原號碼，否則分配下一個連續號碼；
+// 佔用與分配在同一個 transaction 內完成，並發或中斷後重試的開立都取得同一個號碼，不會跳號。
+// 新序列沿用舊版 invoice_sequences/{series} 的最後號碼
+func (r *invoiceRepository) Claim(ctx context.Context, series, invoiceID string) (int64, error) {
+	var legacy int64
+	if err := r.client.NewRef("invoice_sequences").Child(series).Get(ctx, &legacy); err != nil {
+		return 0, fmt.Errorf("error getting invoice sequence: %v", err)
+	}
+
+	var number int64
+	err := r.client.NewRef("invoice_numbers").Child(series).Transaction(ctx, func(tn db.TransactionNode) (interface{}, error) {
+		var numbers invoiceNumbers
+		if err := tn.Unmarshal(&numbers); err != nil {
+			return nil, err
+		}
+		if assigned, ok := numbers.Assigned[invoiceID]; ok {
+			number = assigned
+			return &numbers, nil
+		}
+		if numbers.Last < legacy {
+			numbers.Last = legacy
+		}
+		if numbers.Assigned == nil {
+			numbers.Assigned = make(map[string]int64)
+		}
+		numbers.Last++
+		numbers.Assigned[invoiceID] = numbers.Last
+		number = numbers.Last
+		return &numbers, nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("error claiming invoice number: %v", err)
+	}
+	return number, nil
+}
+
+// Complete 以 transaction 寫入已編號的發票；已由其他請求寫入時返回既有發票
+func (r *invoiceRepository) Complete(ctx context.Context, invoice *model.Invoice) (*model.Invoice, error) {
+	var current model.Invoice
+	err := r.client.NewRef("invoices").Child(invoice.ID).Transaction(ctx, func(tn db.TransactionNode) (interface{}, error) {
+		current = model.Invoice{}
+		if err := tn.Unmarshal(&current); err != nil {
+			return nil, err
+		}
+		if current.Number != "" {
+			return &current, nil
+		}
+		current = *invoice
+		return invoice, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error saving invoice: %v", err)
+	}
+	return &current, nil
+}
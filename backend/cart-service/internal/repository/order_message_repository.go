@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"firebase.google.com/go/db"
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/model"
+)
+
+// OrderMessageRepository 訂單訊息存儲接口
+type OrderMessageRepository interface {
+	AddMessage(ctx context.Context, message *model.OrderMessage) error
+	ListMessages(ctx context.Context, orderID string) ([]model.OrderMessage, error)
+	GetThread(ctx context.Context, orderID string) (*model.OrderThread, error)
+	UpdateThread(ctx context.Context, orderID string, apply func(thread *model.OrderThread)) (*model.OrderThread, error)
+	ListThreadsByUserID(ctx context.Context, userID string) ([]model.OrderThread, error)
+	ListThreadsAwaitingStaff(ctx context.Context) ([]model.OrderThread, error)
+}
+
+type orderMessageRepository struct {
+	client *db.Client
+}
+
+// NewOrderMessageRepository 創建訂單訊息存儲實例
+func NewOrderMessageRepository(client *db.Client) OrderMessageRepository {
+	return &orderMessageRepository{
+		client: client,
+	}
+}
+
+// AddMessage 新增訂單訊息
+func (r *orderMessageRepository) AddMessage(ctx context.Context, message *model.OrderMessage) error {
+	if err := r.client.NewRef("order_messages").Child(message.OrderID).Child(message.ID).Set(ctx, message); err != nil {
+		return fmt.Errorf("error adding order message: %v", err)
+	}
+	return nil
+}
+
+// ListMessages 依建立時間獲取訂單訊息
+func (r *orderMessageRepository) ListMessages(ctx context.Context, orderID string) ([]model.OrderMessage, error) {
+	var messages map[string]model.OrderMessage
+	if err := r.client.NewRef("order_messages").Child(orderID).Get(ctx, &messages); err != nil {
+		return nil, fmt.Errorf("error getting order messages: %v", err)
+	}
+
+	result := make([]model.OrderMessage, 0, len(messages))
+	for _, message := range messages {
+		result = append(result, message)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CreatedAt.Before(result[j].CreatedAt)
+	})
+	return result, nil
+}
+
+// GetThread 獲取訊息串摘要，尚無訊息時返回 nil
+func (r *orderMessageRepository) GetThread(ctx context.Context, orderID string) (*model.OrderThread, error) {
+	var thread model.OrderThread
+	if err := r.client.NewRef("order_threads").Child(orderID).Get(ctx, &thread); err != nil {
+		return nil, fmt.Errorf("error getting order thread: %v", err)
+	}
+	if thread.OrderID == "" {
+		return nil, nil
+	}
+	return &thread, nil
+}
+
+// UpdateThread 以 transaction 更新訊息串摘要，不存在時以空白摘要傳入 apply
+func (r *orderMessageRepository) UpdateThread(ctx context.Context, orderID string, apply func(thread *model.OrderThread)) (*model.OrderThread, error) {
+	var thread model.OrderThread
+	err := r.client.NewRef("order_threads").Child(orderID).Transaction(ctx, func(tn db.TransactionNode) (interface{}, error) {
+		thread = model.OrderThread{}
+		if err := tn.Unmarshal(&thread); err != nil {
+			return nil, err
+		}
+		thread.OrderID = orderID
+		apply(&thread)
+		return &thread, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error updating order thread: %v", err)
+	}
+	return &thread, nil
+}
+
+// ListThreadsByUserID 依最後訊息時間由新到舊獲取用戶的訊息串
+func (r *orderMessageRepository) ListThreadsByUserID(ctx context.Context, userID string) ([]model.OrderThread, error) {
+	var threads map[string]model.OrderThread
+	if err := r.client.NewRef("order_threads").OrderByChild("userId").EqualTo(userID).Get(ctx, &threads); err != nil {
+		return nil, fmt.Errorf("error getting order threads by user ID: %v", err)
+	}
+	return sortThreads(threads), nil
+}
+
+// ListThreadsAwaitingStaff 依最後訊息時間由新到舊獲取客服尚有未讀訊息的訊息串
+func (r *orderMessageRepository) ListThreadsAwaitingStaff(ctx context.Context) ([]model.OrderThread, error) {
+	var threads map[string]model.OrderThread
+	if err := r.client.NewRef("order_threads").OrderByChild("staffUnread").StartAt(1).Get(ctx, &threads); err != nil {
+		return nil, fmt.Errorf("error getting order threads awaiting staff: %v", err)
+	}
+	return sortThreads(threads), nil
+}
+
+// sortThreads 將訊息串依最後訊息時間由新到舊排序
+func sortThreads(threads map[string]model.OrderThread) []model.OrderThread {
+	result := make([]model.OrderThread, 0, len(threads))
+	for _, thread := range threads {
+		result = append(result, thread)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].LastMessageAt.After(result[j].LastMessageAt)
+	})
+	return result
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"sort"
+	"strings"
 	"time"
 
 	"firebase.google.com/go/db"
@@ -12,12 +14,23 @@ import (
 
 // OrderRepository 訂單倉庫接口
 type OrderRepository interface {
-	Create(ctx context.Context, order *model.Order) error
+	Create(ctx context.Context, order *model.Order, children ...model.Order) error
 	GetByID(ctx context.Context, orderID string) (*model.Order, error)
-	GetByUserID(ctx context.Context, userID string, offset, limit int) ([]model.Order, error)
-	UpdateStatus(ctx context.Context, orderID string, status model.OrderStatus) error
+	Query(ctx context.Context, query *model.OrderQuery) ([]model.Order, *model.OrderCursor, error)
+	UpdateStatus(ctx context.Context, orderID string, change *model.OrderStatusChange) (*model.Order, error)
+	ListByStatus(ctx context.Context, status model.OrderStatus) ([]model.Order, error)
+	BackfillIndex(ctx context.Context) (int, error)
+	AddNote(ctx context.Context, orderID string, note *model.OrderNote) error
+	ListNotes(ctx context.Context, orderID string) ([]model.OrderNote, error)
+	ClaimEInvoice(ctx context.Context, orderID, owner string) (*model.EInvoice, error)
+	AssignEInvoice(ctx context.Context, orderID, owner string, einvoice *model.EInvoice) (*model.EInvoice, error)
+	SaveEInvoice(ctx context.Context, orderID, owner string, einvoice *model.EInvoice) error
+	ReleaseEInvoice(ctx context.Context, orderID, owner string) error
 }
 
+// EInvoiceClaimTimeout 開立中的電子發票超過此時間仍未完成即可由其他請求接手
+const EInvoiceClaimTimeout = 2 * time.Minute
+
 type orderRepository struct {
 	client *db.Client
 }
@@ -29,15 +42,37 @@ func NewOrderRepository(client *db.Client) OrderRepository {
 	}
 }
 
-// Create 創建訂單
-func (r *orderRepository) Create(ctx context.Context, order *model.Order) error {
+// Create 創建訂單，拆單時與子訂單一併寫入
+func (r *orderRepository) Create(ctx context.Context, order *model.Order, children ...model.Order) error {
 	// 設置創建和更新時間
 	now := time.Now()
-	order.CreatedAt = now
-	order.UpdatedAt = now
 
-	// 保存訂單
-	return r.client.NewRef("orders").Child(order.ID).Set(ctx, order)
+	// 以多路徑更新同時寫入訂單與查詢索引
+	updates := make(map[string]interface{})
+	orders := []*model.Order{order}
+	for i := range children {
+		orders = append(orders, &children[i])
+	}
+	for _, o := range orders {
+		o.CreatedAt = now
+		o.UpdatedAt = now
+
+		// 記錄初始狀態
+		if len(o.StatusHistory) == 0 {
+			o.StatusHistory = []model.OrderStatusChange{{
+				To:    o.Status,
+				Actor: o.UserID,
+				At:    now,
+			}}
+		}
+
+		updates["orders/"+o.ID] = o
+		entry := model.NewOrderIndexEntry(o)
+		for _, path := range orderIndexPaths(o) {
+			updates[path] = entry
+		}
+	}
+	return r.client.NewRef("/").Update(ctx, updates)
 }
 
 // GetByID 根據ID獲取訂單
@@ -49,37 +84,368 @@ func (r *orderRepository) GetByID(ctx context.Context, orderID string) (*model.O
 	return &order, nil
 }
 
-// GetByUserID 根據用戶ID獲取訂單
-func (r *orderRepository) GetByUserID(ctx context.Context, userID string, offset, limit int) ([]model.Order, error) {
+// Query 依索引查詢用戶訂單，建立時間範圍由 RTDB 過濾，其餘條件於索引上過濾後再讀取當頁訂單；
+// 返回的游標為下一頁的起點，沒有下一頁時為 nil
+func (r *orderRepository) Query(ctx context.Context, query *model.OrderQuery) ([]model.Order, *model.OrderCursor, error) {
+	indexRef := r.client.NewRef("order_index_all")
+	if query.UserID != "" {
+		indexRef = r.client.NewRef("order_index").Child(query.UserID)
+	}
+	q := indexRef.OrderByChild("createdAt")
+	if query.CreatedFrom != nil {
+		q = q.StartAt(query.CreatedFrom.UnixMilli())
+	}
+	if query.CreatedTo != nil {
+		q = q.EndAt(query.CreatedTo.UnixMilli())
+	}
+
+	var index map[string]model.OrderIndexEntry
+	if err := q.Get(ctx, &index); err != nil {
+		return nil, nil, fmt.Errorf("error querying order index: %v", err)
+	}
+
+	entries := make([]model.OrderIndexEntry, 0, len(index))
+	for _, entry := range index {
+		if matchOrderQuery(&entry, query) {
+			entries = append(entries, entry)
+		}
+	}
+
+	// 依建立時間排序，時間相同時以訂單ID排序確保分頁穩定
+	less := func(a, b model.OrderIndexEntry) bool {
+		if a.CreatedAt != b.CreatedAt {
+			return a.CreatedAt < b.CreatedAt
+		}
+		return a.OrderID < b.OrderID
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if query.Ascending {
+			return less(entries[i], entries[j])
+		}
+		return less(entries[j], entries[i])
+	})
+
+	start := 0
+	if query.After != nil {
+		after := model.OrderIndexEntry{CreatedAt: query.After.CreatedAt, OrderID: query.After.OrderID}
+		start = sort.Search(len(entries), func(i int) bool {
+			if query.Ascending {
+				return less(after, entries[i])
+			}
+			return less(entries[i], after)
+		})
+	}
+	end := start + query.Limit
+	if end > len(entries) {
+		end = len(entries)
+	}
+	page := entries[start:end]
+
+	orders := make([]model.Order, 0, len(page))
+	for _, entry := range page {
+		var order model.Order
+		if err := r.client.NewRef("orders").Child(entry.OrderID).Get(ctx, &order); err != nil {
+			return nil, nil, fmt.Errorf("failed to get order: %v", err)
+		}
+		if order.ID == "" {
+			log.Printf("Order %s in index but not found", entry.OrderID)
+			continue
+		}
+		orders = append(orders, order)
+	}
+
+	var next *model.OrderCursor
+	if end < len(entries) && len(page) > 0 {
+		last := page[len(page)-1]
+		next = &model.OrderCursor{CreatedAt: last.CreatedAt, OrderID: last.OrderID}
+	}
+	return orders, next, nil
+}
+
+// matchOrderQuery 檢查索引項目是否符合建立時間以外的查詢條件
+func matchOrderQuery(entry *model.OrderIndexEntry, query *model.OrderQuery) bool {
+	if len(query.Statuses) > 0 {
+		matched := false
+		for _, status := range query.Statuses {
+			if entry.Status == status {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if query.MinAmount != nil && entry.TotalAmount < *query.MinAmount {
+		return false
+	}
+	if query.MaxAmount != nil && entry.TotalAmount > *query.MaxAmount {
+		return false
+	}
+	if query.ProductID != "" {
+		matched := false
+		for _, productID := range entry.ProductIDs {
+			if productID == query.ProductID {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if query.Text != "" && !strings.Contains(entry.ItemNames, strings.ToLower(query.Text)) {
+		return false
+	}
+	return true
+}
+
+// UpdateStatus 以 transaction 更新訂單狀態並追加變更紀錄，目前狀態與 change.From 不同時返回 ErrOrderStatusStale
+func (r *orderRepository) UpdateStatus(ctx context.Context, orderID string, change *model.OrderStatusChange) (*model.Order, error) {
+	var order model.Order
+	err := r.client.NewRef("orders").Child(orderID).Transaction(ctx, func(tn db.TransactionNode) (interface{}, error) {
+		order = model.Order{}
+		if err := tn.Unmarshal(&order); err != nil {
+			return nil, err
+		}
+		if order.ID == "" {
+			return nil, ErrOrderNotFound
+		}
+		if order.Status != change.From {
+			return nil, ErrOrderStatusStale
+		}
+		order.Status = change.To
+		order.StatusHistory = append(order.StatusHistory, *change)
+		order.UpdatedAt = change.At
+		return &order, nil
+	})
+	if err != nil {
+		if err == ErrOrderNotFound || err == ErrOrderStatusStale {
+			return nil, err
+		}
+		return nil, fmt.Errorf("error updating order status: %v", err)
+	}
+
+	// 寫入完整的索引項目，索引缺漏時一併補上，避免只寫入 status 留下不完整的項目
+	updates := make(map[string]interface{})
+	entry := model.NewOrderIndexEntry(&order)
+	for _, path := range orderIndexPaths(&order) {
+		updates[path] = entry
+	}
+	if err := r.client.NewRef("/").Update(ctx, updates); err != nil {
+		log.Printf("Failed to update order index status for %s: %v", order.ID, err)
+	}
+	return &order, nil
+}
+
+// ListByStatus 獲取指定狀態的所有訂單
+func (r *orderRepository) ListByStatus(ctx context.Context, status model.OrderStatus) ([]model.Order, error) {
 	var orders map[string]model.Order
-	if err := r.client.NewRef("orders").OrderByChild("userId").EqualTo(userID).Get(ctx, &orders); err != nil {
-		log.Printf("Error getting orders by user ID: %v", err)
-		return nil, fmt.Errorf("error getting orders by user ID: %v", err)
+	if err := r.client.NewRef("orders").OrderByChild("status").EqualTo(string(status)).Get(ctx, &orders); err != nil {
+		return nil, fmt.Errorf("error getting orders by status: %v", err)
 	}
 
 	result := make([]model.Order, 0, len(orders))
 	for _, order := range orders {
 		result = append(result, order)
 	}
+	return result, nil
+}
+
+// BackfillIndex 為缺少索引或索引狀態過期的訂單重建用戶與全域索引，返回修正的筆數；
+// 完成後記錄於 migrations/order_index，之後啟動不再掃描，需重建時刪除該記錄後重新啟動
+func (r *orderRepository) BackfillIndex(ctx context.Context) (int, error) {
+	markerRef := r.client.NewRef("migrations/order_index")
+	var backfilledAt string
+	if err := markerRef.Get(ctx, &backfilledAt); err != nil {
+		return 0, fmt.Errorf("error getting migration marker: %v", err)
+	}
+	if backfilledAt != "" {
+		return 0, nil
+	}
+
+	var orders map[string]model.Order
+	if err := r.client.NewRef("orders").Get(ctx, &orders); err != nil {
+		return 0, fmt.Errorf("error getting orders: %v", err)
+	}
+	var index map[string]map[string]model.OrderIndexEntry
+	if err := r.client.NewRef("order_index").Get(ctx, &index); err != nil {
+		return 0, fmt.Errorf("error getting order index: %v", err)
+	}
+	var globalIndex map[string]model.OrderIndexEntry
+	if err := r.client.NewRef("order_index_all").Get(ctx, &globalIndex); err != nil {
+		return 0, fmt.Errorf("error getting order index: %v", err)
+	}
+
+	updates := make(map[string]interface{})
+	fixed := 0
+	for id, order := range orders {
+		if order.ID == "" || order.UserID == "" {
+			continue
+		}
+		globalEntry, globalOK := globalIndex[id]
+		entry, ok := index[order.UserID][id]
+		if order.ParentOrderID != "" {
+			// 子訂單不在用戶索引中
+			entry, ok = globalEntry, true
+		}
+		if ok && globalOK && entry.Status == order.Status && globalEntry.Status == order.Status {
+			continue
+		}
+		order := order
+		for _, path := range orderIndexPaths(&order) {
+			updates[path] = model.NewOrderIndexEntry(&order)
+		}
+		fixed++
+	}
+	if len(updates) > 0 {
+		if err := r.client.NewRef("/").Update(ctx, updates); err != nil {
+			return 0, fmt.Errorf("error writing order index: %v", err)
+		}
+	}
+
+	if err := markerRef.Set(ctx, time.Now().Format(time.RFC3339)); err != nil {
+		return fixed, fmt.Errorf("error setting migration marker: %v", err)
+	}
+	return fixed, nil
+}
 
-	// 應用分頁
-	start := offset
-	end := offset + limit
-	if end > len(result) {
-		end = len(result)
+// AddNote 新增訂單內部備註，存於 order_notes/{orderId}，不隨訂單返回給顧客
+func (r *orderRepository) AddNote(ctx context.Context, orderID string, note *model.OrderNote) error {
+	if err := r.client.NewRef("order_notes").Child(orderID).Child(note.ID).Set(ctx, note); err != nil {
+		return fmt.Errorf("error adding order note: %v", err)
 	}
-	if start >= len(result) {
-		return []model.Order{}, nil
+	return nil
+}
+
+// ListNotes 依建立時間獲取訂單內部備註
+func (r *orderRepository) ListNotes(ctx context.Context, orderID string) ([]model.OrderNote, error) {
+	var notes map[string]model.OrderNote
+	if err := r.client.NewRef("order_notes").Child(orderID).Get(ctx, &notes); err != nil {
+		return nil, fmt.Errorf("error getting order notes: %v", err)
 	}
 
-	return result[start:end], nil
+	result := make([]model.OrderNote, 0, len(notes))
+	for _, note := range notes {
+		result = append(result, note)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CreatedAt.Before(result[j].CreatedAt)
+	})
+	return result, nil
 }
 
-// UpdateStatus 更新訂單狀態
-func (r *orderRepository) UpdateStatus(ctx context.Context, orderID string, status model.OrderStatus) error {
-	updates := map[string]interface{}{
-		"status":    status,
-		"updatedAt": time.Now(),
+// ClaimEInvoice 以 transaction 佔用訂單的電子發票開立，返回目前的電子發票（可能尚未配號）；
+// 已開立時不佔用並返回原發票，其他請求開立中且未逾時時返回 ErrEInvoiceClaimHeld
+func (r *orderRepository) ClaimEInvoice(ctx context.Context, orderID, owner string) (*model.EInvoice, error) {
+	var current model.EInvoice
+	err := r.client.NewRef("orders").Child(orderID).Child("eInvoice").Transaction(ctx, func(tn db.TransactionNode) (interface{}, error) {
+		current = model.EInvoice{}
+		if err := tn.Unmarshal(&current); err != nil {
+			return nil, err
+		}
+		if current.Status == model.EInvoiceStatusIssued {
+			return &current, nil
+		}
+		now := time.Now()
+		if current.ClaimedBy != "" && current.ClaimedAt != nil && now.Sub(*current.ClaimedAt) < EInvoiceClaimTimeout {
+			return nil, ErrEInvoiceClaimHeld
+		}
+		current.ClaimedBy = owner
+		current.ClaimedAt = &now
+		return &current, nil
+	})
+	if err != nil {
+		if err == ErrEInvoiceClaimHeld {
+			return nil, err
+		}
+		return nil, fmt.Errorf("error claiming e-invoice: %v", err)
+	}
+	return &current, nil
+}
+
+// AssignEInvoice 以 transaction 為佔用中的訂單寫入已配號的電子發票，佔用已被接手時返回 ErrEInvoiceClaimLost
+func (r *orderRepository) AssignEInvoice(ctx context.Context, orderID, owner string, einvoice *model.EInvoice) (*model.EInvoice, error) {
+	var assigned model.EInvoice
+	err := r.client.NewRef("orders").Child(orderID).Child("eInvoice").Transaction(ctx, func(tn db.TransactionNode) (interface{}, error) {
+		var current model.EInvoice
+		if err := tn.Unmarshal(&current); err != nil {
+			return nil, err
+		}
+		if current.ClaimedBy != owner || current.Number != "" {
+			return nil, ErrEInvoiceClaimLost
+		}
+		assigned = *einvoice
+		assigned.ClaimedBy = current.ClaimedBy
+		assigned.ClaimedAt = current.ClaimedAt
+		return &assigned, nil
+	})
+	if err != nil {
+		if err == ErrEInvoiceClaimLost {
+			return nil, err
+		}
+		return nil, fmt.Errorf("error assigning e-invoice: %v", err)
+	}
+	return &assigned, nil
+}
+
+// SaveEInvoice 以 transaction 寫入電子發票開立結果並解除佔用，佔用已被接手時返回 ErrEInvoiceClaimLost
+func (r *orderRepository) SaveEInvoice(ctx context.Context, orderID, owner string, einvoice *model.EInvoice) error {
+	err := r.client.NewRef("orders").Child(orderID).Child("eInvoice").Transaction(ctx, func(tn db.TransactionNode) (interface{}, error) {
+		var current model.EInvoice
+		if err := tn.Unmarshal(&current); err != nil {
+			return nil, err
+		}
+		if current.ClaimedBy != owner {
+			return nil, ErrEInvoiceClaimLost
+		}
+		saved := *einvoice
+		saved.ClaimedBy = ""
+		saved.ClaimedAt = nil
+		return &saved, nil
+	})
+	if err != nil {
+		if err == ErrEInvoiceClaimLost {
+			return err
+		}
+		return fmt.Errorf("error saving e-invoice: %v", err)
+	}
+	return nil
+}
+
+// ReleaseEInvoice 以 transaction 解除開立失敗的佔用，保留已配發的號碼供重試沿用；尚未配號時移除佔用紀錄
+func (r *orderRepository) ReleaseEInvoice(ctx context.Context, orderID, owner string) error {
+	err := r.client.NewRef("orders").Child(orderID).Child("eInvoice").Transaction(ctx, func(tn db.TransactionNode) (interface{}, error) {
+		var current model.EInvoice
+		if err := tn.Unmarshal(&current); err != nil {
+			return nil, err
+		}
+		if current.ClaimedBy != owner {
+			return nil, ErrEInvoiceClaimLost
+		}
+		if current.Number == "" {
+			return nil, nil
+		}
+		current.ClaimedBy = ""
+		current.ClaimedAt = nil
+		return &current, nil
+	})
+	if err != nil {
+		if err == ErrEInvoiceClaimLost {
+			return err
+		}
+		return fmt.Errorf("error releasing e-invoice: %v", err)
+	}
+	return nil
+}
+
+// orderIndexPaths 訂單在用戶索引與全域索引中的路徑；子訂單只列於全域索引，用戶看到的是父訂單
+func orderIndexPaths(order *model.Order) []string {
+	if order.ParentOrderID != "" {
+		return []string{"order_index_all/" + order.ID}
+	}
+	return []string{
+		"order_index/" + order.UserID + "/" + order.ID,
+		"order_index_all/" + order.ID,
 	}
-	return r.client.NewRef("orders").Child(orderID).Update(ctx, updates)
 }
@@ -0,0 +1,190 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"firebase.google.com/go/db"
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/model"
+)
+
+// ReturnRepository 退貨申請存儲接口
+type ReturnRepository interface {
+	Create(ctx context.Context, ret *model.ReturnRequest) error
+	GetByID(ctx context.Context, id string) (*model.ReturnRequest, error)
+	ListByOrderID(ctx context.Context, orderID string) ([]model.ReturnRequest, error)
+	ListByStatus(ctx context.Context, status model.ReturnStatus) ([]model.ReturnRequest, error)
+	UpdateStatus(ctx context.Context, id string, change *model.ReturnStatusChange, apply func(ret *model.ReturnRequest)) (*model.ReturnRequest, error)
+	UpdateOutcome(ctx context.Context, ret *model.ReturnRequest) error
+	ReserveItems(ctx context.Context, orderID string, seed map[string]int, reserve func(returned map[string]int) ([]model.ReturnItem, error)) ([]model.ReturnItem, error)
+	ReleaseItems(ctx context.Context, orderID string, items []model.ReturnItem) error
+}
+
+type returnRepository struct {
+	client *db.Client
+}
+
+// NewReturnRepository 創建退貨申請存儲實例
+func NewReturnRepository(client *db.Client) ReturnRepository {
+	return &returnRepository{
+		client: client,
+	}
+}
+
+// Create 創建退貨申請
+func (r *returnRepository) Create(ctx context.Context, ret *model.ReturnRequest) error {
+	now := time.Now()
+	ret.CreatedAt = now
+	ret.UpdatedAt = now
+	if err := r.client.NewRef("returns").Child(ret.ID).Set(ctx, ret); err != nil {
+		return fmt.Errorf("error creating return request: %v", err)
+	}
+	return nil
+}
+
+// GetByID 獲取退貨申請，不存在時返回 nil
+func (r *returnRepository) GetByID(ctx context.Context, id string) (*model.ReturnRequest, error) {
+	var ret model.ReturnRequest
+	if err := r.client.NewRef("returns").Child(id).Get(ctx, &ret); err != nil {
+		return nil, fmt.Errorf("error getting return request: %v", err)
+	}
+	if ret.ID == "" {
+		return nil, nil
+	}
+	return &ret, nil
+}
+
+// ListByOrderID 依建立時間獲取訂單的所有退貨申請
+func (r *returnRepository) ListByOrderID(ctx context.Context, orderID string) ([]model.ReturnRequest, error) {
+	var returns map[string]model.ReturnRequest
+	if err := r.client.NewRef("returns").OrderByChild("orderId").EqualTo(orderID).Get(ctx, &returns); err != nil {
+		return nil, fmt.Errorf("error getting return requests by order ID: %v", err)
+	}
+	return sortReturns(returns), nil
+}
+
+// ListByStatus 依建立時間獲取指定狀態的退貨申請
+func (r *returnRepository) ListByStatus(ctx context.Context, status model.ReturnStatus) ([]model.ReturnRequest, error) {
+	var returns map[string]model.ReturnRequest
+	if err := r.client.NewRef("returns").OrderByChild("status").EqualTo(string(status)).Get(ctx, &returns); err != nil {
+		return nil, fmt.Errorf("error getting return requests by status: %v", err)
+	}
+	return sortReturns(returns), nil
+}
+
+// UpdateStatus 以 transaction 更新退貨狀態並追加變更紀錄，apply 可同時修改其他欄位；
+// 目前狀態與 change.From 不同時返回 ErrReturnStatusStale
+func (r *returnRepository) UpdateStatus(ctx context.Context, id string, change *model.ReturnStatusChange, apply func(ret *model.ReturnRequest)) (*model.ReturnRequest, error) {
+	var ret model.ReturnRequest
+	err := r.client.NewRef("returns").Child(id).Transaction(ctx, func(tn db.TransactionNode) (interface{}, error) {
+		ret = model.ReturnRequest{}
+		if err := tn.Unmarshal(&ret); err != nil {
+			return nil, err
+		}
+		if ret.ID == "" {
+			return nil, ErrReturnNotFound
+		}
+		if ret.Status != change.From {
+			return nil, ErrReturnStatusStale
+		}
+		ret.Status = change.To
+		ret.History = append(ret.History, *change)
+		ret.UpdatedAt = change.At
+		if apply != nil {
+			apply(&ret)
+		}
+		return &ret, nil
+	})
+	if err != nil {
+		if err == ErrReturnNotFound || err == ErrReturnStatusStale {
+			return nil, err
+		}
+		return nil, fmt.Errorf("error updating return status: %v", err)
+	}
+	return &ret, nil
+}
+
+// UpdateOutcome 保存回補庫存與退款結果，只寫入這些欄位以免覆蓋並行的狀態變更
+func (r *returnRepository) UpdateOutcome(ctx context.Context, ret *model.ReturnRequest) error {
+	ret.UpdatedAt = time.Now()
+	updates := map[string]interface{}{
+		"restockFailed": ret.RestockFailed,
+		"refundError":   ret.RefundError,
+		"updatedAt":     ret.UpdatedAt,
+	}
+	if err := r.client.NewRef("returns").Child(ret.ID).Update(ctx, updates); err != nil {
+		return fmt.Errorf("error saving return outcome: %v", err)
+	}
+	return nil
+}
+
+// ReserveItems 以 transaction 佔用訂單的可退數量，存於 returned_quantities/{orderId}；
+// reserve 依已申請退貨的數量決定本次退貨的商品，返回的錯誤原樣返回。
+// 尚無紀錄的舊訂單以 seed（既有未被拒絕申請的數量）初始化
+func (r *returnRepository) ReserveItems(ctx context.Context, orderID string, seed map[string]int, reserve func(returned map[string]int) ([]model.ReturnItem, error)) ([]model.ReturnItem, error) {
+	var items []model.ReturnItem
+	var reserveErr error
+	err := r.client.NewRef("returned_quantities").Child(orderID).Transaction(ctx, func(tn db.TransactionNode) (interface{}, error) {
+		var returned map[string]int
+		if err := tn.Unmarshal(&returned); err != nil {
+			return nil, err
+		}
+		if returned == nil {
+			returned = make(map[string]int, len(seed))
+			for productID, quantity := range seed {
+				returned[productID] = quantity
+			}
+		}
+		if items, reserveErr = reserve(returned); reserveErr != nil {
+			return nil, reserveErr
+		}
+		for _, item := range items {
+			returned[item.ProductID] += item.Quantity
+		}
+		return returned, nil
+	})
+	if err != nil {
+		if err == reserveErr {
+			return nil, err
+		}
+		return nil, fmt.Errorf("error reserving return items: %v", err)
+	}
+	return items, nil
+}
+
+// ReleaseItems 以 transaction 歸還被拒絕或建立失敗的申請所佔用的可退數量
+func (r *returnRepository) ReleaseItems(ctx context.Context, orderID string, items []model.ReturnItem) error {
+	err := r.client.NewRef("returned_quantities").Child(orderID).Transaction(ctx, func(tn db.TransactionNode) (interface{}, error) {
+		var returned map[string]int
+		if err := tn.Unmarshal(&returned); err != nil {
+			return nil, err
+		}
+		if returned == nil {
+			return nil, nil
+		}
+		for _, item := range items {
+			if returned[item.ProductID] -= item.Quantity; returned[item.ProductID] <= 0 {
+				delete(returned, item.ProductID)
+			}
+		}
+		return returned, nil
+	})
+	if err != nil {
+		return fmt.Errorf("error releasing return items: %v", err)
+	}
+	return nil
+}
+
+// sortReturns 將退貨申請依建立時間排序
+func sortReturns(returns map[string]model.ReturnRequest) []model.ReturnRequest {
+	result := make([]model.ReturnRequest, 0, len(returns))
+	for _, ret := range returns {
+		result = append(result, ret)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CreatedAt.Before(result[j].CreatedAt)
+	})
+	return result
+}
@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"firebase.google.com/go/db"
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/model"
+)
+
+// ShipmentRepository 貨件存儲接口
+type ShipmentRepository interface {
+	Save(ctx context.Context, shipment *model.Shipment) error
+	GetByID(ctx context.Context, id string) (*model.Shipment, error)
+	ListByOrderID(ctx context.Context, orderID string) ([]model.Shipment, error)
+	ListByStatus(ctx context.Context, status model.ShipmentStatus) ([]model.Shipment, error)
+	ReserveItems(ctx context.Context, orderID string, seed map[string]int, reserve func(shipped map[string]int) ([]model.ShipmentItem, error)) ([]model.ShipmentItem, error)
+	ReleaseItems(ctx context.Context, orderID string, items []model.ShipmentItem) error
+}
+
+type shipmentRepository struct {
+	client *db.Client
+}
+
+// NewShipmentRepository 創建貨件存儲實例
+func NewShipmentRepository(client *db.Client) ShipmentRepository {
+	return &shipmentRepository{
+		client: client,
+	}
+}
+
+// Save 保存貨件
+func (r *shipmentRepository) Save(ctx context.Context, shipment *model.Shipment) error {
+	shipment.UpdatedAt = time.Now()
+	if err := r.client.NewRef("shipments").Child(shipment.ID).Set(ctx, shipment); err != nil {
+		return fmt.Errorf("error saving shipment: %v", err)
+	}
+	return nil
+}
+
+// GetByID 獲取貨件，不存在時返回 nil
+func (r *shipmentRepository) GetByID(ctx context.Context, id string) (*model.Shipment, error) {
+	var shipment model.Shipment
+	if err := r.client.NewRef("shipments").Child(id).Get(ctx, &shipment); err != nil {
+		return nil, fmt.Errorf("error getting shipment: %v", err)
+	}
+	if shipment.ID == "" {
+		return nil, nil
+	}
+	return &shipment, nil
+}
+
+// ListByOrderID 依建立時間獲取訂單的所有貨件
+func (r *shipmentRepository) ListByOrderID(ctx context.Context, orderID string) ([]model.Shipment, error) {
+	var shipments map[string]model.Shipment
+	if err := r.client.NewRef("shipments").OrderByChild("orderId").EqualTo(orderID).Get(ctx, &shipments); err != nil {
+		return nil, fmt.Errorf("error getting shipments by order ID: %v", err)
+	}
+
+	result := make([]model.Shipment, 0, len(shipments))
+	for _, shipment := range shipments {
+		result = append(result, shipment)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CreatedAt.Before(result[j].CreatedAt)
+	})
+	return result, nil
+}
+
+// ReserveItems 以 transaction 佔用訂單的出貨數量，存於 shipped_quantities/{orderId}；
+// reserve 依已出貨數量決定本次出貨的商品，返回的錯誤原樣返回。
+// 尚無紀錄的舊訂單以 seed（既有貨件的出貨數量）初始化
+func (r *shipmentRepository) ReserveItems(ctx context.Context, orderID string, seed map[string]int, reserve func(shipped map[string]int) ([]model.ShipmentItem, error)) ([]model.ShipmentItem, error) {
+	var items []model.ShipmentItem
+	var reserveErr error
+	err := r.client.NewRef("shipped_quantities").Child(orderID).Transaction(ctx, func(tn db.TransactionNode) (interface{}, error) {
+		var shipped map[string]int
+		if err := tn.Unmarshal(&shipped); err != nil {
+			return nil, err
+		}
+		if shipped == nil {
+			shipped = make(map[string]int, len(seed))
+			for productID, quantity := range seed {
+				shipped[productID] = quantity
+			}
+		}
+		if items, reserveErr = reserve(shipped); reserveErr != nil {
+			return nil, reserveErr
+		}
+		for _, item := range items {
+			shipped[item.ProductID] += item.Quantity
+		}
+		return shipped, nil
+	})
+	if err != nil {
+		if err == reserveErr {
+			return nil, err
+		}
+		return nil, fmt.Errorf("error reserving shipment items: %v", err)
+	}
+	return items, nil
+}
+
+// ReleaseItems 以 transaction 歸還貨件建立失敗時佔用的出貨數量
+func (r *shipmentRepository) ReleaseItems(ctx context.Context, orderID string, items []model.ShipmentItem) error {
+	err := r.client.NewRef("shipped_quantities").Child(orderID).Transaction(ctx, func(tn db.TransactionNode) (interface{}, error) {
+		var shipped map[string]int
+		if err := tn.Unmarshal(&shipped); err != nil {
+			return nil, err
+		}
+		if shipped == nil {
+			return nil, nil
+		}
+		for _, item := range items {
+			if shipped[item.ProductID] -= item.Quantity; shipped[item.ProductID] <= 0 {
+				delete(shipped, item.ProductID)
+			}
+		}
+		return shipped, nil
+	})
+	if err != nil {
+		return fmt.Errorf("error releasing shipment items: %v", err)
+	}
+	return nil
+}
+
+// ListByStatus 獲取指定狀態的貨件
+func (r *shipmentRepository) ListByStatus(ctx context.Context, status model.ShipmentStatus) ([]model.Shipment, error) {
+	var shipments map[string]model.Shipment
+	if err := r.client.NewRef("shipments").OrderByChild("status").EqualTo(string(status)).Get(ctx, &shipments); err != nil {
+		return nil, fmt.Errorf("error getting shipments by status: %v", err)
+	}
+
+	result := make([]model.Shipment, 0, len(shipments))
+	for _, shipment := range shipments {
+		result = append(result, shipment)
+	}
+	return result, nil
+}
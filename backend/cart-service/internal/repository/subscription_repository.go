@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"firebase.google.com/go/db"
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/model"
+)
+
+// SubscriptionRepository 訂閱存儲接口
+type SubscriptionRepository interface {
+	Create(ctx context.Context, sub *model.Subscription) error
+	GetByID(ctx context.Context, id string) (*model.Subscription, error)
+	ListByUserID(ctx context.Context, userID string) ([]model.Subscription, error)
+	ListByStatus(ctx context.Context, status model.SubscriptionStatus) ([]model.Subscription, error)
+	Update(ctx context.Context, id string, apply func(sub *model.Subscription) error) (*model.Subscription, error)
+}
+
+type subscriptionRepository struct {
+	client *db.Client
+}
+
+// NewSubscriptionRepository 創建訂閱存儲實例
+func NewSubscriptionRepository(client *db.Client) SubscriptionRepository {
+	return &subscriptionRepository{
+		client: client,
+	}
+}
+
+// Create 創建訂閱
+func (r *subscriptionRepository) Create(ctx context.Context, sub *model.Subscription) error {
+	now := time.Now()
+	sub.CreatedAt = now
+	sub.UpdatedAt = now
+	if err := r.client.NewRef("subscriptions").Child(sub.ID).Set(ctx, sub); err != nil {
+		return fmt.Errorf("error creating subscription: %v", err)
+	}
+	return nil
+}
+
+// GetByID 獲取訂閱，不存在時返回 nil
+func (r *subscriptionRepository) GetByID(ctx context.Context, id string) (*model.Subscription, error) {
+	var sub model.Subscription
+	if err := r.client.NewRef("subscriptions").Child(id).Get(ctx, &sub); err != nil {
+		return nil, fmt.Errorf("error getting subscription: %v", err)
+	}
+	if sub.ID == "" {
+		return nil, nil
+	}
+	return &sub, nil
+}
+
+// ListByUserID 依建立時間獲取用戶的所有訂閱
+func (r *subscriptionRepository) ListByUserID(ctx context.Context, userID string) ([]model.Subscription, error) {
+	var subs map[string]model.Subscription
+	if err := r.client.NewRef("subscriptions").OrderByChild("userId").EqualTo(userID).Get(ctx, &subs); err != nil {
+		return nil, fmt.Errorf("error getting subscriptions by user ID: %v", err)
+	}
+	return sortSubscriptions(subs), nil
+}
+
+// ListByStatus 獲取指定狀態的訂閱
+func (r *subscriptionRepository) ListByStatus(ctx context.Context, status model.SubscriptionStatus) ([]model.Subscription, error) {
+	var subs map[string]model.Subscription
+	if err := r.client.NewRef("subscriptions").OrderByChild("status").EqualTo(string(status)).Get(ctx, &subs); err != nil {
+		return nil, fmt.Errorf("error getting subscriptions by status: %v", err)
+	}
+	return sortSubscriptions(subs), nil
+}
+
+// Update 以 transaction 讀取並修改訂閱，避免用戶操作與排程同時寫入互相覆蓋；
+// apply 返回的錯誤原樣返回，訂閱不存在時返回 ErrSubscriptionNotFound
+func (r *subscriptionRepository) Update(ctx context.Context, id string, apply func(sub *model.Subscription) error) (*model.Subscription, error) {
+	var sub model.Subscription
+	var applyErr error
+	err := r.client.NewRef("subscriptions").Child(id).Transaction(ctx, func(tn db.TransactionNode) (interface{}, error) {
+		sub = model.Subscription{}
+		if err := tn.Unmarshal(&sub); err != nil {
+			return nil, err
+		}
+		if sub.ID == "" {
+			return nil, ErrSubscriptionNotFound
+		}
+		if applyErr = apply(&sub); applyErr != nil {
+			return nil, applyErr
+		}
+		sub.UpdatedAt = time.Now()
+		return &sub, nil
+	})
+	if err != nil {
+		if err == ErrSubscriptionNotFound || err == applyErr {
+			return nil, err
+		}
+		return nil, fmt.Errorf("error updating subscription: %v", err)
+	}
+	return &sub, nil
+}
+
+// sortSubscriptions 將訂閱依建立時間排序
+func sortSubscriptions(subs map[string]model.Subscription) []model.Subscription {
+	result := make([]model.Subscription, 0, len(subs))
+	for _, sub := range subs {
+		result = append(result, sub)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CreatedAt.Before(result[j].CreatedAt)
+	})
+	return result
+}
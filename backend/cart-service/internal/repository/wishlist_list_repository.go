@@ -0,0 +1,170 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"firebase.google.com/go/db"
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/model"
+)
+
+// WishlistListRepository 具名收藏清單存儲接口
+type WishlistListRepository interface {
+	Create(ctx context.Context, list *model.WishlistList, limit int) error
+	GetByID(ctx context.Context, userID, listID string) (*model.WishlistList, error)
+	ListByUserID(ctx context.Context, userID string) ([]model.WishlistList, error)
+	Update(ctx context.Context, userID, listID string, apply func(list *model.WishlistList) error) (*model.WishlistList, error)
+	Delete(ctx context.Context, list *model.WishlistList) error
+	SetShare(ctx context.Context, list *model.WishlistList, previousToken string) error
+	GetShare(ctx context.Context, token string) (*model.WishlistShare, error)
+}
+
+type wishlistListRepository struct {
+	client *db.Client
+}
+
+// NewWishlistListRepository 創建具名收藏清單存儲實例
+func NewWishlistListRepository(client *db.Client) WishlistListRepository {
+	return &wishlistListRepository{
+		client: client,
+	}
+}
+
+// Create 以 transaction 檢查數量上限並建立清單，預設收藏清單不計入上限；
+// 已達上限時返回 ErrWishlistListLimit，公開清單建立後再寫入分享連結
+func (r *wishlistListRepository) Create(ctx context.Context, list *model.WishlistList, limit int) error {
+	data, err := json.Marshal(list)
+	if err != nil {
+		return fmt.Errorf("error encoding wishlist list: %v", err)
+	}
+	// 其他清單以原始 JSON 保留，寫回時不會改變內容
+	err = r.client.NewRef("wishlist_lists").Child(list.UserID).Transaction(ctx, func(tn db.TransactionNode) (interface{}, error) {
+		var lists map[string]json.RawMessage
+		if err := tn.Unmarshal(&lists); err != nil {
+			return nil, err
+		}
+		count := len(lists)
+		if _, ok := lists[model.DefaultWishlistListID]; ok {
+			count--
+		}
+		if count >= limit {
+			return nil, ErrWishlistListLimit
+		}
+		if lists == nil {
+			lists = make(map[string]json.RawMessage)
+		}
+		lists[list.ID] = data
+		return lists, nil
+	})
+	if err != nil {
+		if err == ErrWishlistListLimit {
+			return err
+		}
+		return fmt.Errorf("error creating wishlist list: %v", err)
+	}
+	return r.SetShare(ctx, list, "")
+}
+
+// GetByID 獲取清單，不存在時返回 nil
+func (r *wishlistListRepository) GetByID(ctx context.Context, userID, listID string) (*model.WishlistList, error) {
+	var list model.WishlistList
+	if err := r.client.NewRef("wishlist_lists").Child(userID).Child(listID).Get(ctx, &list); err != nil {
+		return nil, fmt.Errorf("error getting wishlist list: %v", err)
+	}
+	if list.ID == "" {
+		return nil, nil
+	}
+	return &list, nil
+}
+
+// ListByUserID 依建立時間由舊到新獲取用戶的清單
+func (r *wishlistListRepository) ListByUserID(ctx context.Context, userID string) ([]model.WishlistList, error) {
+	var lists map[string]model.WishlistList
+	if err := r.client.NewRef("wishlist_lists").Child(userID).Get(ctx, &lists); err != nil {
+		return nil, fmt.Errorf("error getting wishlist lists: %v", err)
+	}
+
+	result := make([]model.WishlistList, 0, len(lists))
+	for _, list := range lists {
+		result = append(result, list)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CreatedAt.Before(result[j].CreatedAt)
+	})
+	return result, nil
+}
+
+// Update 以 transaction 讀取並修改清單並更新 UpdatedAt；
+// apply 返回的錯誤原樣返回，清單不存在時返回 ErrWishlistListNotFound
+func (r *wishlistListRepository) Update(ctx context.Context, userID, listID string, apply func(list *model.WishlistList) error) (*model.WishlistList, error) {
+	var list model.WishlistList
+	var applyErr error
+	err := r.client.NewRef("wishlist_lists").Child(userID).Child(listID).Transaction(ctx, func(tn db.TransactionNode) (interface{}, error) {
+		list = model.WishlistList{}
+		if err := tn.Unmarshal(&list); err != nil {
+			return nil, err
+		}
+		if list.ID == "" {
+			return nil, ErrWishlistListNotFound
+		}
+		if applyErr = apply(&list); applyErr != nil {
+			return nil, applyErr
+		}
+		list.UpdatedAt = time.Now()
+		return &list, nil
+	})
+	if err != nil {
+		if err == ErrWishlistListNotFound || err == applyErr {
+			return nil, err
+		}
+		return nil, fmt.Errorf("error updating wishlist list: %v", err)
+	}
+	return &list, nil
+}
+
+// Delete 刪除清單與其分享連結
+func (r *wishlistListRepository) Delete(ctx context.Context, list *model.WishlistList) error {
+	updates := map[string]interface{}{
+		"wishlist_lists/" + list.UserID + "/" + list.ID: nil,
+	}
+	if list.ShareToken != "" {
+		updates["wishlist_shares/"+list.ShareToken] = nil
+	}
+	if err := r.client.NewRef("/").Update(ctx, updates); err != nil {
+		return fmt.Errorf("error deleting wishlist list: %v", err)
+	}
+	return nil
+}
+
+// SetShare 將清單的分享連結更新為 list.ShareToken，並刪除舊連結；ShareToken 為空時只刪除舊連結
+func (r *wishlistListRepository) SetShare(ctx context.Context, list *model.WishlistList, previousToken string) error {
+	updates := make(map[string]interface{})
+	if previousToken != "" && previousToken != list.ShareToken {
+		updates["wishlist_shares/"+previousToken] = nil
+	}
+	if list.ShareToken != "" {
+		updates["wishlist_shares/"+list.ShareToken] = model.WishlistShare{UserID: list.UserID, ListID: list.ID}
+	}
+	if len(updates) == 0 {
+		return nil
+	}
+	if err := r.client.NewRef("/").Update(ctx, updates); err != nil {
+		return fmt.Errorf("error updating wishlist share: %v", err)
+	}
+	return nil
+}
+
+// GetShare 依分享連結獲取對應的清單，不存在時返回 nil
+func (r *wishlistListRepository) GetShare(ctx context.Context, token string) (*model.WishlistShare, error) {
+	var share model.WishlistShare
+	if err := r.client.NewRef("wishlist_shares").Child(token).Get(ctx, &share); err != nil {
+		return nil, fmt.Errorf("error getting wishlist share: %v", err)
+	}
+	if share.ListID == "" {
+		return nil, nil
+	}
+	return &share, nil
+}
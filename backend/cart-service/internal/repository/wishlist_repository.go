@@ -3,18 +3,21 @@ package repository
 import (
 	"context"
 	"fmt"
+	"sort"
 	"time"
 
 	"firebase.google.com/go/db"
 	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/model"
 )
 
-// WishlistRepository 提供收藏清單相關操作
+// WishlistRepository 提供預設收藏清單相關操作，資料存於 wishlist_lists/{userId}/default
 type WishlistRepository interface {
 	AddToWishlist(ctx context.Context, userId, productId string) error
 	RemoveFromWishlist(ctx context.Context, userId, productId string) error
 	GetWishlist(ctx context.Context, userId string, page, limit int) (*model.WishlistResponse, error)
 	IsProductInWishlist(ctx context.Context, userId, productId string) (bool, error)
+	GetWishlistIds(ctx context.Context, userId string) ([]string, error)
+	MigrateLegacyItems(ctx context.Context) (int, error)
 }
 
 // wishlistRepository 實現 WishlistRepository 接口
@@ -27,39 +30,42 @@ func NewWishlistRepository(db *db.Client) WishlistRepository {
 	return &wishlistRepository{db: db}
 }
 
-// AddToWishlist 添加商品到收藏清單
+// AddToWishlist 添加商品到預設收藏清單，清單不存在時一併建立
 func (r *wishlistRepository) AddToWishlist(ctx context.Context, userId, productId string) error {
 	if userId == "" || productId == "" {
 		return fmt.Errorf("userId and productId cannot be empty")
 	}
 
-	// 建立收藏項目
-	wishlistItem := model.WishlistItem{
-		UserId:    userId,
-		ProductId: productId,
-		CreatedAt: time.Now(),
-		ID:        userId + "_" + productId,
+	// 以 transaction 寫入 wishlist_lists/{userId}/default，避免與具名清單的修改互相覆蓋
+	err := r.defaultListRef(userId).Transaction(ctx, func(tn db.TransactionNode) (interface{}, error) {
+		var list model.WishlistList
+		if err := tn.Unmarshal(&list); err != nil {
+			return nil, err
+		}
+		now := time.Now()
+		initDefaultList(&list, userId, now)
+		if _, ok := list.Items[productId]; !ok {
+			list.Items[productId] = model.WishlistListItem{ProductID: productId, Quantity: 1, AddedAt: now}
+			list.UpdatedAt = now
+		}
+		return &list, nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to add wishlist item: %w", err)
 	}
-
-	// 儲存到 Firebase Realtime Database
-	key := fmt.Sprintf("%s_%s", userId, productId)
-	ref := r.db.NewRef("wishlists").Child(key)
-	return ref.Set(ctx, wishlistItem)
+	return nil
 }
 
-// RemoveFromWishlist 從收藏清單移除商品
+// RemoveFromWishlist 從預設收藏清單移除商品
 func (r *wishlistRepository) RemoveFromWishlist(ctx context.Context, userId, productId string) error {
 	if userId == "" || productId == "" {
 		return fmt.Errorf("userId and productId cannot be empty")
 	}
 
-	// 從 Firebase Realtime Database 刪除
-	key := fmt.Sprintf("%s_%s", userId, productId)
-	ref := r.db.NewRef("wishlists").Child(key)
-	return ref.Delete(ctx)
+	return r.defaultListRef(userId).Child("items").Child(productId).Delete(ctx)
 }
 
-// GetWishlist 獲取使用者的收藏清單
+// GetWishlist 獲取使用者預設收藏清單的商品
 func (r *wishlistRepository) GetWishlist(ctx context.Context, userId string, page, limit int) (*model.WishlistResponse, error) {
 	if userId == "" {
 		return nil, fmt.Errorf("userId cannot be empty")
@@ -73,47 +79,32 @@ func (r *wishlistRepository) GetWishlist(ctx context.Context, userId string, pag
 		limit = 10
 	}
 
-	// 查詢使用者的收藏項目
-	ref := r.db.NewRef("wishlists")
-	// Firebase RTDB 沒有直接的 WHERE 查詢，所以我們需要獲取所有數據然後在內存中過濾
-	var allItems map[string]model.WishlistItem
-	if err := ref.Get(ctx, &allItems); err != nil {
-		// 如果是數據不存在的錯誤，返回空結果
-		if err.Error() == "client: response error: data at path \"wishlists\" does not exist" {
-			return &model.WishlistResponse{
-				Wishlist: []model.WishlistItem{},
-				Total:    0,
-				Page:     page,
-				Limit:    limit,
-			}, nil
-		}
+	// 只讀取預設清單的商品
+	var items map[string]model.WishlistListItem
+	if err := r.defaultListRef(userId).Child("items").Get(ctx, &items); err != nil {
 		return nil, fmt.Errorf("failed to get wishlist items: %w", err)
 	}
 
-	// 過濾出特定用戶的收藏
-	var userItems []model.WishlistItem
-	for key, item := range allItems {
-		if item.UserId == userId {
-			// 使用Firebase生成的完整key作為ID
-			item.ID = key
-			userItems = append(userItems, item)
-		}
+	userItems := make([]model.WishlistItem, 0, len(items))
+	for productId, item := range items {
+		userItems = append(userItems, model.WishlistItem{
+			ID:        productId,
+			UserId:    userId,
+			ProductId: productId,
+			CreatedAt: item.AddedAt,
+		})
 	}
 
 	// 計算總數
 	total := len(userItems)
 
-	// 排序 - 按創建時間降序（最新的排在前面）
-	if len(userItems) > 1 {
-		// 使用冒泡排序進行簡單排序
-		for i := 0; i < len(userItems)-1; i++ {
-			for j := 0; j < len(userItems)-i-1; j++ {
-				if userItems[j].CreatedAt.Before(userItems[j+1].CreatedAt) {
-					userItems[j], userItems[j+1] = userItems[j+1], userItems[j]
-				}
-			}
+	// 排序 - 按創建時間降序（最新的排在前面），時間相同時以商品ID排序確保分頁穩定
+	sort.Slice(userItems, func(i, j int) bool {
+		if !userItems[i].CreatedAt.Equal(userItems[j].CreatedAt) {
+			return userItems[i].CreatedAt.After(userItems[j].CreatedAt)
 		}
-	}
+		return userItems[i].ProductId < userItems[j].ProductId
+	})
 
 	// 分頁
 	start := (page - 1) * limit
@@ -126,7 +117,7 @@ func (r *wishlistRepository) GetWishlist(ctx context.Context, userId string, pag
 		end = total
 	}
 
-	var pagedItems []model.WishlistItem
+	pagedItems := []model.WishlistItem{}
 	if start < end {
 		pagedItems = userItems[start:end]
 	}
@@ -140,24 +131,168 @@ func (r *wishlistRepository) GetWishlist(ctx context.Context, userId string, pag
 	}, nil
 }
 
-// IsProductInWishlist 檢查商品是否在收藏清單中
+// IsProductInWishlist 檢查商品是否在預設收藏清單中
 func (r *wishlistRepository) IsProductInWishlist(ctx context.Context, userId, productId string) (bool, error) {
 	if userId == "" || productId == "" {
 		return false, fmt.Errorf("userId and productId cannot be empty")
 	}
 
-	// 檢查商品是否在收藏清單中
-	ref := r.db.NewRef("wishlists")
-	key := fmt.Sprintf("%s_%s", userId, productId)
-	var item model.WishlistItem
-	if err := ref.Child(key).Get(ctx, &item); err != nil {
-		// 如果是數據不存在的錯誤，返回false表示不在清單中
-		if err.Error() == "client: response error: data at path \"wishlists/"+key+"\" does not exist" {
-			return false, nil
-		}
+	var item model.WishlistListItem
+	if err := r.defaultListRef(userId).Child("items").Child(productId).Get(ctx, &item); err != nil {
 		return false, fmt.Errorf("failed to get wishlist item: %w", err)
 	}
 
-	// 如果找到了項目且ProductId不為空，則表示商品在收藏清單中
-	return item.ProductId != "", nil
+	// 如果找到了項目且ProductID不為空，則表示商品在收藏清單中
+	return item.ProductID != "", nil
+}
+
+// GetWishlistIds 獲取使用者預設收藏清單的商品ID，以淺層讀取只下載鍵值
+func (r *wishlistRepository) GetWishlistIds(ctx context.Context, userId string) ([]string, error) {
+	if userId == "" {
+		return nil, fmt.Errorf("userId cannot be empty")
+	}
+
+	var keys map[string]interface{}
+	if err := r.defaultListRef(userId).Child("items").GetShallow(ctx, &keys); err != nil {
+		return nil, fmt.Errorf("failed to get wishlist ids: %w", err)
+	}
+
+	ids := make([]string, 0, len(keys))
+	for productId := range keys {
+		ids = append(ids, productId)
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// defaultListRef 返回用戶預設收藏清單的位置
+func (r *wishlistRepository) defaultListRef(userId string) *db.Ref {
+	return r.db.NewRef("wishlist_lists").Child(userId).Child(model.DefaultWishlistListID)
+}
+
+// initDefaultList 清單尚未建立時填入預設收藏清單的欄位
+func initDefaultList(list *model.WishlistList, userId string, now time.Time) {
+	if list.ID == "" {
+		*list = model.WishlistList{
+			ID:         model.DefaultWishlistListID,
+			UserID:     userId,
+			Name:       model.DefaultWishlistListName,
+			Visibility: model.WishlistVisibilityPrivate,
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		}
+	}
+	if list.Items == nil {
+		list.Items = make(map[string]model.WishlistListItem)
+	}
+}
+
+// MigrateLegacyItems 將舊的 wishlists 項目搬移至各用戶的預設收藏清單：先將 wishlists/{userId}_{productId}
+// 搬移至 wishlists/{userId}/{productId}，再併入 wishlist_lists/{userId}/default；
+// 每個步驟完成後記錄於 migrations 下，之後啟動不再掃描；返回搬移至預設清單的筆數
+func (r *wishlistRepository) MigrateLegacyItems(ctx context.Context) (int, error) {
+	if _, err := r.migratePerUser(ctx); err != nil {
+		return 0, err
+	}
+	return r.migrateToDefaultList(ctx)
+}
+
+// migratePerUser 將舊格式 wishlists/{userId}_{productId} 的項目搬移至 wishlists/{userId}/{productId}，
+// 完成後記錄於 migrations/wishlists_per_user
+func (r *wishlistRepository) migratePerUser(ctx context.Context) (int, error) {
+	markerRef := r.db.NewRef("migrations/wishlists_per_user")
+	var migratedAt string
+	if err := markerRef.Get(ctx, &migratedAt); err != nil {
+		return 0, fmt.Errorf("failed to get migration marker: %w", err)
+	}
+	if migratedAt != "" {
+		return 0, nil
+	}
+
+	// 舊格式的值本身即為收藏項目，新格式的值為以商品ID為鍵的項目集合
+	var nodes map[string]map[string]interface{}
+	if err := r.db.NewRef("wishlists").Get(ctx, &nodes); err != nil {
+		return 0, fmt.Errorf("failed to get wishlists: %w", err)
+	}
+
+	updates := make(map[string]interface{})
+	migrated := 0
+	for key, node := range nodes {
+		userId, _ := node["userId"].(string)
+		productId, _ := node["productId"].(string)
+		if userId == "" || productId == "" || key != userId+"_"+productId {
+			continue
+		}
+		item := model.WishlistItem{
+			ID:        productId,
+			UserId:    userId,
+			ProductId: productId,
+		}
+		if createdAt, ok := node["createdAt"].(string); ok {
+			item.CreatedAt, _ = time.Parse(time.RFC3339Nano, createdAt)
+		}
+		// 以多路徑更新同時寫入新位置並刪除舊項目
+		updates["wishlists/"+userId+"/"+productId] = item
+		updates["wishlists/"+key] = nil
+		migrated++
+	}
+	if len(updates) > 0 {
+		if err := r.db.NewRef("/").Update(ctx, updates); err != nil {
+			return 0, fmt.Errorf("failed to migrate wishlist items: %w", err)
+		}
+	}
+
+	if err := markerRef.Set(ctx, time.Now().Format(time.RFC3339)); err != nil {
+		return migrated, fmt.Errorf("failed to set migration marker: %w", err)
+	}
+	return migrated, nil
+}
+
+// migrateToDefaultList 將 wishlists/{userId}/{productId} 的項目併入各用戶的預設收藏清單並刪除舊資料，
+// 預設清單已有的商品保留不變；完成後記錄於 migrations/wishlists_default_list
+func (r *wishlistRepository) migrateToDefaultList(ctx context.Context) (int, error) {
+	markerRef := r.db.NewRef("migrations/wishlists_default_list")
+	var migratedAt string
+	if err := markerRef.Get(ctx, &migratedAt); err != nil {
+		return 0, fmt.Errorf("failed to get migration marker: %w", err)
+	}
+	if migratedAt != "" {
+		return 0, nil
+	}
+
+	var users map[string]map[string]model.WishlistItem
+	if err := r.db.NewRef("wishlists").Get(ctx, &users); err != nil {
+		return 0, fmt.Errorf("failed to get wishlists: %w", err)
+	}
+
+	migrated := 0
+	for userId, items := range users {
+		// 以 transaction 合併，避免覆蓋搬移期間經由新端點加入的商品
+		err := r.defaultListRef(userId).Transaction(ctx, func(tn db.TransactionNode) (interface{}, error) {
+			var list model.WishlistList
+			if err := tn.Unmarshal(&list); err != nil {
+				return nil, err
+			}
+			initDefaultList(&list, userId, time.Now())
+			for productId, item := range items {
+				if _, ok := list.Items[productId]; ok {
+					continue
+				}
+				list.Items[productId] = model.WishlistListItem{ProductID: productId, Quantity: 1, AddedAt: item.CreatedAt}
+			}
+			return &list, nil
+		})
+		if err != nil {
+			return migrated, fmt.Errorf("failed to migrate wishlist of user %s: %w", userId, err)
+		}
+		if err := r.db.NewRef("wishlists").Child(userId).Delete(ctx); err != nil {
+			return migrated, fmt.Errorf("failed to delete legacy wishlist of user %s: %w", userId, err)
+		}
+		migrated += len(items)
+	}
+
+	if err := markerRef.Set(ctx, time.Now().Format(time.RFC3339)); err != nil {
+		return migrated, fmt.Errorf("failed to set migration marker: %w", err)
+	}
+	return migrated, nil
 }
@@ -0,0 +1,173 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/client"
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/model"
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/repository"
+)
+
+// AbandonedCartServiceConfig 棄置購物車偵測配置
+type AbandonedCartServiceConfig struct {
+	IdleTimeout        time.Duration
+	ReminderTemplateID string
+}
+
+// AbandonedCartService 棄置購物車偵測與提醒
+type AbandonedCartService interface {
+	ScanAbandonedCarts(ctx context.Context) error
+	MarkRecovered(ctx context.Context, userID, orderID string) error
+	GetStats(ctx context.Context) (*model.AbandonedCartStats, error)
+}
+
+type abandonedCartService struct {
+	cartRepo           repository.CartRepository
+	abandonedRepo      repository.AbandonedCartRepository
+	notificationClient client.NotificationClient
+	config             *AbandonedCartServiceConfig
+}
+
+// NewAbandonedCartService 創建棄置購物車服務實例
+func NewAbandonedCartService(cartRepo repository.CartRepository, abandonedRepo repository.AbandonedCartRepository, notificationClient client.NotificationClient, config *AbandonedCartServiceConfig) AbandonedCartService {
+	if config == nil {
+		config = &AbandonedCartServiceConfig{
+			IdleTimeout: 24 * time.Hour,
+		}
+	}
+
+	return &abandonedCartService{
+		cartRepo:           cartRepo,
+		abandonedRepo:      abandonedRepo,
+		notificationClient: notificationClient,
+		config:             config,
+	}
+}
+
+// ScanAbandonedCarts 掃描閒置超過設定時間的購物車，標記為棄置並發送提醒
+func (s *abandonedCartService) ScanAbandonedCarts(ctx context.Context) error {
+	cutoff := time.Now().Add(-s.config.IdleTimeout)
+	carts, err := s.cartRepo.ListIdleCarts(ctx, cutoff)
+	if err != nil {
+		return err
+	}
+
+	for _, cart := range carts {
+		if len(cart.Items) == 0 {
+			continue
+		}
+
+		record, err := s.abandonedRepo.Get(ctx, cart.UserID)
+		if err != nil {
+			log.Printf("Error getting abandoned cart record for user %s: %v", cart.UserID, err)
+			continue
+		}
+		// 同一次閒置只處理一次，購物車有更新後才會重新判定
+		if record != nil && record.CartUpdatedAt.Equal(cart.UpdatedAt) {
+			continue
+		}
+
+		if err := s.markAbandoned(ctx, &cart); err != nil {
+			log.Printf("Error marking cart abandoned for user %s: %v", cart.UserID, err)
+		}
+	}
+
+	return nil
+}
+
+// markAbandoned 建立棄置記錄並發送提醒
+func (s *abandonedCartService) markAbandoned(ctx context.Context, cart *model.Cart) error {
+	record := &model.AbandonedCart{
+		UserID:        cart.UserID,
+		Status:        model.AbandonedCartStatusAbandoned,
+		CartUpdatedAt: cart.UpdatedAt,
+		AbandonedAt:   time.Now(),
+	}
+
+	items := make([]map[string]interface{}, 0, len(cart.Items))
+	names := make([]string, 0, len(cart.Items))
+	for _, item := range cart.Items {
+		record.ItemCount += item.Quantity
+		record.CartAmount += item.Price * float64(item.Quantity)
+		items = append(items, map[string]interface{}{
+			"productId": item.ProductID,
+			"name":      item.Name,
+			"quantity":  item.Quantity,
+			"price":     item.Price,
+		})
+		names = append(names, item.Name)
+	}
+
+	if err := s.abandonedRepo.Save(ctx, record); err != nil {
+		return fmt.Errorf("save abandoned cart failed: %w", err)
+	}
+	if err := s.abandonedRepo.IncrementStat(ctx, model.AbandonedCartStatusAbandoned); err != nil {
+		log.Printf("Error updating abandoned cart stats: %v", err)
+	}
+
+	// 未設定模板時只記錄，不發送提醒
+	if s.config.ReminderTemplateID == "" {
+		return nil
+	}
+
+	err := s.notificationClient.SendTemplate(ctx, &client.TemplateNotificationRequest{
+		UserID:     cart.UserID,
+		TemplateID: s.config.ReminderTemplateID,
+		Priority:   "normal",
+		Variables: map[string]interface{}{
+			"userId":      cart.UserID,
+			"items":       items,
+			"itemNames":   names,
+			"itemCount":   record.ItemCount,
+			"totalAmount": record.CartAmount,
+		},
+		Metadata: map[string]interface{}{
+			"type":          "abandoned_cart",
+			"cartUpdatedAt": cart.UpdatedAt,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("send reminder failed: %w", err)
+	}
+
+	now := time.Now()
+	record.Status = model.AbandonedCartStatusReminded
+	record.ReminderSentAt = &now
+	if err := s.abandonedRepo.Save(ctx, record); err != nil {
+		return fmt.Errorf("save abandoned cart failed: %w", err)
+	}
+	if err := s.abandonedRepo.IncrementStat(ctx, model.AbandonedCartStatusReminded); err != nil {
+		log.Printf("Error updating abandoned cart stats: %v", err)
+	}
+
+	return nil
+}
+
+// MarkRecovered 用戶結帳時，將棄置的購物車標記為回流
+func (s *abandonedCartService) MarkRecovered(ctx context.Context, userID, orderID string) error {
+	record, err := s.abandonedRepo.Get(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if record == nil || record.Status == model.AbandonedCartStatusRecovered {
+		return nil
+	}
+
+	now := time.Now()
+	record.Status = model.AbandonedCartStatusRecovered
+	record.RecoveredAt = &now
+	record.OrderID = orderID
+	if err := s.abandonedRepo.Save(ctx, record); err != nil {
+		return fmt.Errorf("save abandoned cart failed: %w", err)
+	}
+
+	return s.abandonedRepo.IncrementStat(ctx, model.AbandonedCartStatusRecovered)
+}
+
+// GetStats 獲取棄置購物車統計
+func (s *abandonedCartService) GetStats(ctx context.Context) (*model.AbandonedCartStats, error) {
+	return s.abandonedRepo.GetStats(ctx)
+}
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/client"
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/model"
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/repository"
+)
+
+// AdminOrderDetail 管理員查看的訂單詳情，支付與顧客資訊取得失敗時為空並記錄於 Warnings
+type AdminOrderDetail struct {
+	Order    *model.Order        `json:"order"`
+	Payment  *client.PaymentInfo `json:"payment,omitempty"`
+	Customer *client.UserInfo    `json:"customer,omitempty"`
+	Notes    []model.OrderNote   `json:"notes"`
+	Warnings []string            `json:"warnings,omitempty"`
+}
+
+// AdminOrderService 管理員訂單管理：跨用戶查詢、詳情、內部備註與狀態轉換
+type AdminOrderService interface {
+	QueryOrders(ctx context.Context, query *model.OrderQuery, cursor string) (*model.OrderPage, error)
+	GetOrderDetail(ctx context.Context, orderID string) (*AdminOrderDetail, error)
+	AddNote(ctx context.Context, orderID, adminID, body string) (*model.OrderNote, error)
+	UpdateStatus(ctx context.Context, orderID string, status model.OrderStatus, adminID, reason string) error
+	BulkUpdateStatus(ctx context.Context, req *model.BulkOrderStatusRequest, adminID string) *model.BulkOrderStatusResponse
+}
+
+type adminOrderService struct {
+	orderRepo     repository.OrderRepository
+	orderService  OrderService
+	paymentClient client.PaymentClient
+	authClient    client.AuthClient
+}
+
+// NewAdminOrderService 創建管理員訂單服務實例
+func NewAdminOrderService(orderRepo repository.OrderRepository, orderService OrderService, paymentClient client.PaymentClient, authClient client.AuthClient) AdminOrderService {
+	return &adminOrderService{
+		orderRepo:     orderRepo,
+		orderService:  orderService,
+		paymentClient: paymentClient,
+		authClient:    authClient,
+	}
+}
+
+// QueryOrders 查詢所有用戶的訂單，query.UserID 可指定單一用戶
+func (s *adminOrderService) QueryOrders(ctx context.Context, query *model.OrderQuery, cursor string) (*model.OrderPage, error) {
+	return s.orderService.QueryOrders(ctx, query, cursor)
+}
+
+// GetOrderDetail 獲取訂單及其支付、顧客資訊與內部備註
+func (s *adminOrderService) GetOrderDetail(ctx context.Context, orderID string) (*AdminOrderDetail, error) {
+	order, err := s.orderService.GetOrderWithChildren(ctx, orderID)
+	if err != nil || order.ID == "" {
+		return nil, ErrOrderNotFound
+	}
+
+	notes, err := s.orderRepo.ListNotes(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	detail := &AdminOrderDetail{
+		Order: order,
+		Notes: notes,
+	}
+
+	payment, err := s.paymentClient.GetPaymentByOrderID(ctx, order.PaymentOrderID())
+	switch {
+	case err == nil:
+		detail.Payment = payment
+	case errors.Is(err, client.ErrPaymentNotFound):
+		// 尚未建立支付
+	default:
+		log.Printf("Failed to get payment for order %s: %v", orderID, err)
+		detail.Warnings = append(detail.Warnings, "payment unavailable")
+	}
+
+	customer, err := s.authClient.GetUser(ctx, order.UserID)
+	if err != nil {
+		log.Printf("Failed to get customer %s for order %s: %v", order.UserID, orderID, err)
+		detail.Warnings = append(detail.Warnings, "customer unavailable")
+	} else {
+		detail.Customer = customer
+	}
+
+	return detail, nil
+}
+
+// AddNote 新增訂單內部備註
+func (s *adminOrderService) AddNote(ctx context.Context, orderID, adminID, body string) (*model.OrderNote, error) {
+	order, err := s.orderService.GetOrder(ctx, orderID)
+	if err != nil || order.ID == "" {
+		return nil, ErrOrderNotFound
+	}
+
+	note := &model.OrderNote{
+		ID:        uuid.New().String(),
+		Author:    adminID,
+		Body:      body,
+		CreatedAt: time.Now(),
+	}
+	if err := s.orderRepo.AddNote(ctx, orderID, note); err != nil {
+		return nil, err
+	}
+	return note, nil
+}
+
+// UpdateStatus 經由狀態機變更訂單狀態，記錄操作的管理員
+func (s *adminOrderService) UpdateStatus(ctx context.Context, orderID string, status model.OrderStatus, adminID, reason string) error {
+	return s.orderService.UpdateOrderStatus(ctx, orderID, status, adminID, reason)
+}
+
+// BulkUpdateStatus 逐筆變更訂單狀態，單筆失敗不影響其他訂單
+func (s *adminOrderService) BulkUpdateStatus(ctx context.Context, req *model.BulkOrderStatusRequest, adminID string) *model.BulkOrderStatusResponse {
+	resp := &model.BulkOrderStatusResponse{
+		Results: make([]model.BulkOrderStatusResult, 0, len(req.OrderIDs)),
+	}
+	seen := make(map[string]bool, len(req.OrderIDs))
+	for _, orderID := range req.OrderIDs {
+		if seen[orderID] {
+			continue
+		}
+		seen[orderID] = true
+
+		result := model.BulkOrderStatusResult{OrderID: orderID, Success: true}
+		if err := s.orderService.UpdateOrderStatus(ctx, orderID, req.Status, adminID, req.Reason); err != nil {
+			result.Success = false
+			result.Error = err.Error()
+			resp.Failed++
+		} else {
+			resp.Succeeded++
+		}
+		resp.Results = append(resp.Results, result)
+	}
+	return resp
+}
@@ -0,0 +1,307 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/model"
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/repository"
+)
+
+var (
+	ErrInvalidAnalyticsQuery = errors.New("invalid analytics query")
+)
+
+// 排行排序依據
+const (
+	AnalyticsSortRevenue  = "revenue"
+	AnalyticsSortQuantity = "quantity"
+)
+
+// AnalyticsService 銷售報表服務接口
+type AnalyticsService interface {
+	RecordTransition(ctx context.Context, order *model.Order, from, to model.OrderStatus)
+	RecordRefund(ctx context.Context, order *model.Order, amount float64)
+	SalesReport(ctx context.Context, interval model.AnalyticsInterval, from, to time.Time) (*model.SalesReport, error)
+	TopProducts(ctx context.Context, from, to time.Time, sortBy string, limit int) ([]model.ProductSales, error)
+	TopCategories(ctx context.Context, from, to time.Time, sortBy string, limit int) ([]model.CategorySales, error)
+}
+
+// AnalyticsServiceConfig 銷售報表配置
+type AnalyticsServiceConfig struct {
+	Location *time.Location // 劃分日、週、月的時區
+}
+
+type analyticsService struct {
+	analyticsRepo repository.AnalyticsRepository
+	config        *AnalyticsServiceConfig
+}
+
+// NewAnalyticsService 創建銷售報表服務實例
+func NewAnalyticsService(analyticsRepo repository.AnalyticsRepository, config *AnalyticsServiceConfig) AnalyticsService {
+	if config.Location == nil {
+		config.Location = time.Local
+	}
+	return &analyticsService{
+		analyticsRepo: analyticsRepo,
+		config:        config,
+	}
+}
+
+// RecordTransition 依訂單狀態變更遞增報表彙總，訂單依建立時間歸入日、週、月區間；
+// 狀態轉換由 UpdateStatus 的 transaction 保證只成功一次，因此不需去重。
+// 退款由 RecordRefund 依實際退款金額記錄，轉為 refunded 時不再計入。
+// 彙總失敗不影響訂單，僅記錄錯誤
+func (s *analyticsService) RecordTransition(ctx context.Context, order *model.Order, from, to model.OrderStatus) {
+	// 子訂單金額已計入父訂單
+	if order.ParentOrderID != "" {
+		return
+	}
+	created := order.CreatedAt.In(s.config.Location)
+	periods := make(map[model.AnalyticsInterval]string, len(model.AnalyticsIntervals))
+	for _, interval := range model.AnalyticsIntervals {
+		periods[interval] = interval.Period(created)
+	}
+
+	var newCustomer bool
+	var returning map[model.AnalyticsInterval]bool
+	if to == model.OrderStatusPaid {
+		err := s.analyticsRepo.UpdateCustomer(ctx, order.UserID, func(stats *model.CustomerStats) {
+			newCustomer = stats.PaidOrders == 0
+			returning = make(map[model.AnalyticsInterval]bool)
+			if stats.LastPeriods == nil {
+				stats.LastPeriods = make(map[model.AnalyticsInterval]string)
+			}
+			for interval, period := range periods {
+				// 同一區間內的多筆訂單只計一次
+				if !newCustomer && stats.LastPeriods[interval] != period {
+					returning[interval] = true
+				}
+				stats.LastPeriods[interval] = period
+			}
+			if newCustomer {
+				now := time.Now()
+				stats.FirstPaidAt = &now
+			}
+			stats.PaidOrders++
+		})
+		if err != nil {
+			log.Printf("Failed to update customer stats for order %s: %v", order.ID, err)
+			newCustomer, returning = false, nil
+		}
+	}
+
+	for interval, period := range periods {
+		interval := interval
+		err := s.analyticsRepo.UpdateSales(ctx, interval, period, func(bucket *model.SalesBucket) {
+			if from == model.OrderStatusPending {
+				bucket.PlacedOrders++
+			}
+			switch to {
+			case model.OrderStatusPaid:
+				bucket.PaidOrders++
+				bucket.Revenue += order.TotalAmount
+				if newCustomer {
+					bucket.NewCustomers++
+				} else if returning[interval] {
+					bucket.ReturningCustomers++
+				}
+			case model.OrderStatusCancelled:
+				bucket.CancelledOrders++
+			}
+		})
+		if err != nil {
+			log.Printf("Failed to update %s sales for order %s: %v", interval, order.ID, err)
+		}
+	}
+
+	if to == model.OrderStatusPaid {
+		s.recordProducts(ctx, order, periods[model.AnalyticsIntervalDay])
+	}
+}
+
+// RecordRefund 記錄一筆實際退款，金額計入訂單建立時間所屬的區間；
+// 同一訂單（子訂單歸入父訂單）第一次退款時遞增退款訂單數。彙總失敗僅記錄錯誤
+func (s *analyticsService) RecordRefund(ctx context.Context, order *model.Order, amount float64) {
+	if amount <= 0 {
+		return
+	}
+	orderID := order.PaymentOrderID()
+	first, err := s.analyticsRepo.MarkRefunded(ctx, orderID)
+	if err != nil {
+		log.Printf("Failed to mark order %s as refunded: %v", orderID, err)
+	}
+
+	created := order.CreatedAt.In(s.config.Location)
+	for _, interval := range model.AnalyticsIntervals {
+		err := s.analyticsRepo.UpdateSales(ctx, interval, interval.Period(created), func(bucket *model.SalesBucket) {
+			if first {
+				bucket.RefundedOrders++
+			}
+			bucket.RefundedAmount += amount
+		})
+		if err != nil {
+			log.Printf("Failed to update %s refunds for order %s: %v", interval, orderID, err)
+		}
+	}
+}
+
+// recordProducts 遞增已付款訂單中各商品的當日銷售
+func (s *analyticsService) recordProducts(ctx context.Context, order *model.Order, day string) {
+	merged := make(map[string]*model.ProductSales)
+	var productIDs []string
+	for _, item := range order.Items {
+		total := item.TotalPrice
+		if total == 0 {
+			total = item.Price * float64(item.Quantity)
+		}
+		sales, ok := merged[item.ProductID]
+		if !ok {
+			sales = &model.ProductSales{ProductID: item.ProductID, Name: item.Name, CategoryID: item.CategoryID}
+			merged[item.ProductID] = sales
+			productIDs = append(productIDs, item.ProductID)
+		}
+		sales.Quantity += item.Quantity
+		sales.Revenue += total
+	}
+
+	for _, productID := range productIDs {
+		delta := merged[productID]
+		err := s.analyticsRepo.UpdateProductSales(ctx, day, productID, func(sales *model.ProductSales) {
+			sales.Name = delta.Name
+			sales.CategoryID = delta.CategoryID
+			sales.Quantity += delta.Quantity
+			sales.Revenue += delta.Revenue
+			sales.Orders++
+		})
+		if err != nil {
+			log.Printf("Failed to update product sales %s for order %s: %v", productID, order.ID, err)
+		}
+	}
+}
+
+// SalesReport 依區間返回 [from, to] 的營收、訂單數、平均客單價、取消與退款率及新舊顧客數
+func (s *analyticsService) SalesReport(ctx context.Context, interval model.AnalyticsInterval, from, to time.Time) (*model.SalesReport, error) {
+	if !interval.IsValid() || to.Before(from) {
+		return nil, ErrInvalidAnalyticsQuery
+	}
+	fromPeriod := interval.Period(from.In(s.config.Location))
+	toPeriod := interval.Period(to.In(s.config.Location))
+	buckets, err := s.analyticsRepo.ListSales(ctx, interval, fromPeriod, toPeriod)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &model.SalesReport{
+		Interval: interval,
+		From:     fromPeriod,
+		To:       toPeriod,
+		Rows:     make([]model.SalesReportRow, 0, len(buckets)),
+	}
+	var total model.SalesBucket
+	for _, bucket := range buckets {
+		report.Rows = append(report.Rows, model.NewSalesReportRow(bucket))
+		total.PlacedOrders += bucket.PlacedOrders
+		total.PaidOrders += bucket.PaidOrders
+		total.Revenue += bucket.Revenue
+		total.CancelledOrders += bucket.CancelledOrders
+		total.RefundedOrders += bucket.RefundedOrders
+		total.RefundedAmount += bucket.RefundedAmount
+		total.NewCustomers += bucket.NewCustomers
+		total.ReturningCustomers += bucket.ReturningCustomers
+	}
+	report.Total = model.NewSalesReportRow(total)
+	return report, nil
+}
+
+// TopProducts 返回 [from, to] 依營收或數量排序的熱銷商品
+func (s *analyticsService) TopProducts(ctx context.Context, from, to time.Time, sortBy string, limit int) ([]model.ProductSales, error) {
+	daily, err := s.productSales(ctx, from, to, sortBy)
+	if err != nil {
+		return nil, err
+	}
+
+	merged := make(map[string]*model.ProductSales)
+	for i := range daily {
+		sales := &daily[i]
+		current, ok := merged[sales.ProductID]
+		if !ok {
+			merged[sales.ProductID] = sales
+			continue
+		}
+		current.Quantity += sales.Quantity
+		current.Revenue += sales.Revenue
+		current.Orders += sales.Orders
+	}
+
+	result := make([]model.ProductSales, 0, len(merged))
+	for _, sales := range merged {
+		result = append(result, *sales)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return rankBefore(sortBy, result[i].Revenue, result[j].Revenue, result[i].Quantity, result[j].Quantity, result[i].ProductID, result[j].ProductID)
+	})
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+	return result, nil
+}
+
+// TopCategories 返回 [from, to] 依營收或數量排序的熱銷分類
+func (s *analyticsService) TopCategories(ctx context.Context, from, to time.Time, sortBy string, limit int) ([]model.CategorySales, error) {
+	daily, err := s.productSales(ctx, from, to, sortBy)
+	if err != nil {
+		return nil, err
+	}
+
+	merged := make(map[string]*model.CategorySales)
+	for _, sales := range daily {
+		current, ok := merged[sales.CategoryID]
+		if !ok {
+			current = &model.CategorySales{CategoryID: sales.CategoryID}
+			merged[sales.CategoryID] = current
+		}
+		current.Quantity += sales.Quantity
+		current.Revenue += sales.Revenue
+		current.Orders += sales.Orders
+	}
+
+	result := make([]model.CategorySales, 0, len(merged))
+	for _, sales := range merged {
+		result = append(result, *sales)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return rankBefore(sortBy, result[i].Revenue, result[j].Revenue, result[i].Quantity, result[j].Quantity, result[i].CategoryID, result[j].CategoryID)
+	})
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+	return result, nil
+}
+
+// productSales 驗證查詢條件並獲取範圍內各日的商品銷售
+func (s *analyticsService) productSales(ctx context.Context, from, to time.Time, sortBy string) ([]model.ProductSales, error) {
+	if to.Before(from) || (sortBy != AnalyticsSortRevenue && sortBy != AnalyticsSortQuantity) {
+		return nil, ErrInvalidAnalyticsQuery
+	}
+	fromDay := model.AnalyticsIntervalDay.Period(from.In(s.config.Location))
+	toDay := model.AnalyticsIntervalDay.Period(to.In(s.config.Location))
+	return s.analyticsRepo.ListProductSales(ctx, fromDay, toDay)
+}
+
+// rankBefore 排行比較，數值相同時以ID排序確保結果穩定
+func rankBefore(sortBy string, revenueA, revenueB float64, quantityA, quantityB int, idA, idB string) bool {
+	if sortBy == AnalyticsSortQuantity && quantityA != quantityB {
+		return quantityA > quantityB
+	}
+	if revenueA != revenueB {
+		return revenueA > revenueB
+	}
+	if quantityA != quantityB {
+		return quantityA > quantityB
+	}
+	return idA < idB
+}
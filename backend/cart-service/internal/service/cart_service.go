@@ -16,6 +16,7 @@ import (
 var (
 	ErrProductNotFound = errors.New("product not found")
 	ErrInvalidStock    = errors.New("invalid stock quantity")
+	ErrItemNotInCart   = repository.ErrItemNotFound
 )
 
 // Add a Config type
@@ -24,25 +25,33 @@ type CartServiceConfig struct {
 }
 
 type CartService interface {
-	GetCart(ctx context.Context, userID string) (*model.CartResponse, error)
+	GetCart(ctx context.Context, userID, shippingMethod, region string) (*model.CartResponse, error)
 	GetCartItems(ctx context.Context, userID string) ([]model.CartItem, error)
 	AddItem(ctx context.Context, userID string, req *model.AddToCartRequest) error
 	RemoveItem(ctx context.Context, userID string, productID string) error
+	RemoveItems(ctx context.Context, userID string, productIDs []string) error
 	UpdateQuantity(ctx context.Context, userID string, req *model.UpdateQuantityRequest) error
 	ClearCart(ctx context.Context, userID string) error
 	SelectItems(ctx context.Context, userID string, req *model.SelectItemsRequest) error
-	CreateOrder(ctx context.Context, userID string) error
+	SaveForLater(ctx context.Context, userID string, productID string) error
+	MoveToCart(ctx context.Context, userID string, productID string) error
+	RemoveSavedItem(ctx context.Context, userID string, productID string) error
+	MoveToWishlist(ctx context.Context, userID string, productID string) error
+	BatchUpdate(ctx context.Context, userID string, req *model.BatchCartRequest) (*model.BatchCartResponse, error)
+	Reorder(ctx context.Context, userID string, order *model.Order) (*model.ReorderResponse, error)
+	AddItems(ctx context.Context, userID string, items []model.OrderItem) (*model.ReorderResponse, error)
 }
 
 type cartService struct {
 	cartRepo      repository.CartRepository
+	wishlistRepo  repository.WishlistRepository
 	productClient client.ProductClient
-	orderClient   client.OrderClient
+	pricing       PricingService
 	config        *CartServiceConfig // Add config field
 }
 
 // Update the constructor
-func NewCartService(cartRepo repository.CartRepository, productClient client.ProductClient, orderClient client.OrderClient, config *CartServiceConfig) CartService {
+func NewCartService(cartRepo repository.CartRepository, wishlistRepo repository.WishlistRepository, productClient client.ProductClient, pricing PricingService, config *CartServiceConfig) CartService {
 	// If config is nil, provide default values
 	if config == nil {
 		config = &CartServiceConfig{
@@ -52,31 +61,52 @@ func NewCartService(cartRepo repository.CartRepository, productClient client.Pro
 
 	return &cartService{
 		cartRepo:      cartRepo,
+		wishlistRepo:  wishlistRepo,
 		productClient: productClient,
-		orderClient:   orderClient,
+		pricing:       pricing,
 		config:        config,
 	}
 }
 
-func (s *cartService) GetCart(ctx context.Context, userID string) (*model.CartResponse, error) {
+func (s *cartService) GetCart(ctx context.Context, userID, shippingMethod, region string) (*model.CartResponse, error) {
 
 	cart, err := s.cartRepo.GetCart(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
 
+	return s.buildCartResponse(cart, shippingMethod, region)
+}
+
+// buildCartResponse 計算購物車的已選數量與金額明細
+func (s *cartService) buildCartResponse(cart *model.Cart, shippingMethod, region string) (*model.CartResponse, error) {
 	response := &model.CartResponse{
-		Items: cart.Items,
+		Items:      cart.Items,
+		SavedItems: cart.SavedItems,
 	}
 
-	// 計算已選商品的總數和總金額
+	// 計算已選商品的總數，並收集計價商品行
+	var lines []model.PricingLine
 	for _, item := range cart.Items {
 		if item.Selected {
 			response.TotalSelected += item.Quantity
-			response.TotalAmount += float64(item.Quantity) * item.Price
+			lines = append(lines, model.PricingLine{
+				ProductID: item.ProductID,
+				Price:     item.Price,
+				Quantity:  item.Quantity,
+				Weight:    item.Weight,
+			})
 		}
 	}
 
+	// 計算金額明細
+	pricing, err := s.pricing.Calculate(lines, shippingMethod, region)
+	if err != nil {
+		return nil, err
+	}
+	response.Pricing = pricing
+	response.TotalAmount = pricing.GrandTotal
+
 	return response, nil
 }
 
@@ -141,6 +171,23 @@ func (s *cartService) AddItem(ctx context.Context, userID string, req *model.Add
 			existingQuantity, req.Quantity, productInfo.Stock)
 	}
 
+	// 創建購物車項目
+	item := s.newCartItem(ctx, req.ProductID, productInfo, req.Quantity)
+
+	log.Printf("Cart item created: %+v", item)
+
+	// 添加到購物車
+	if err := s.cartRepo.AddItem(ctx, userID, item); err != nil {
+		log.Printf("Error adding item to cart: %v", err)
+		return fmt.Errorf("failed to add item to cart: %w", err)
+	}
+
+	log.Printf("Successfully added product %s to cart for user %s", req.ProductID, userID)
+	return nil
+}
+
+// newCartItem 依商品資訊建立購物車項目，並取得第一張圖片的 base64
+func (s *cartService) newCartItem(ctx context.Context, productID string, productInfo *client.ProductInfo, quantity int) model.CartItem {
 	// 獲取圖片 base64
 	var imageBase64 string
 	if len(productInfo.Images) > 0 {
@@ -155,6 +202,7 @@ func (s *cartService) AddItem(ctx context.Context, userID string, req *model.Add
 				imageURL = fmt.Sprintf("%s%s", s.config.ProductServiceBaseURL, imageURL)
 				log.Printf("Converted to absolute URL: %s", imageURL)
 			}
+			var err error
 			imageBase64, err = s.productClient.GetProductImageAsBase64(ctx, imageURL)
 			if err != nil {
 				log.Printf("Warning: Failed to get image as base64: %v", err)
@@ -164,37 +212,31 @@ func (s *cartService) AddItem(ctx context.Context, userID string, req *model.Add
 			}
 		}
 	} else {
-		fmt.Printf("Product %s has no images", req.ProductID)
+		fmt.Printf("Product %s has no images", productID)
 	}
 
-	// 創建購物車項目
-	item := model.CartItem{
-		ProductID:  req.ProductID,
+	return model.CartItem{
+		ProductID:  productID,
 		Name:       productInfo.Name,
 		Image:      imageBase64,
 		Price:      productInfo.Price,
-		Quantity:   req.Quantity,
+		Quantity:   quantity,
 		Selected:   true,
 		StockCount: productInfo.Stock,
+		Weight:     productInfo.Weight,
 		UpdatedAt:  time.Now(),
 	}
-
-	log.Printf("Cart item created: %+v", item)
-
-	// 添加到購物車
-	if err := s.cartRepo.AddItem(ctx, userID, item); err != nil {
-		log.Printf("Error adding item to cart: %v", err)
-		return fmt.Errorf("failed to add item to cart: %w", err)
-	}
-
-	log.Printf("Successfully added product %s to cart for user %s", req.ProductID, userID)
-	return nil
 }
 
 func (s *cartService) RemoveItem(ctx context.Context, userID string, productID string) error {
 	return s.cartRepo.RemoveItem(ctx, userID, productID)
 }
 
+// RemoveItems 一次移除多個商品（結帳後清除已選商品）
+func (s *cartService) RemoveItems(ctx context.Context, userID string, productIDs []string) error {
+	return s.cartRepo.RemoveItems(ctx, userID, productIDs)
+}
+
 func (s *cartService) UpdateQuantity(ctx context.Context, userID string, req *model.UpdateQuantityRequest) error {
 	// 調用 product service 檢查庫存
 	productInfo, err := s.productClient.GetProduct(ctx, req.ProductID)
@@ -217,13 +259,13 @@ func (s *cartService) ClearCart(ctx context.Context, userID string) error {
 }
 
 func (s *cartService) SelectItems(ctx context.Context, userID string, req *model.SelectItemsRequest) error {
-	// 檢查所有商品是否存在
+	// 一次檢查所有商品是否存在
+	products, err := s.productClient.GetProducts(ctx, req.ProductIDs)
+	if err != nil {
+		return fmt.Errorf("failed to get product info: %w", err)
+	}
 	for _, productID := range req.ProductIDs {
-		productInfo, err := s.productClient.GetProduct(ctx, productID)
-		if err != nil {
-			return fmt.Errorf("failed to get product info: %w", err)
-		}
-		if productInfo == nil {
+		if products[productID] == nil {
 			return fmt.Errorf("product not found: %s", productID)
 		}
 	}
@@ -231,49 +273,375 @@ func (s *cartService) SelectItems(ctx context.Context, userID string, req *model
 	return s.cartRepo.SelectItems(ctx, userID, req.ProductIDs)
 }
 
-func (s *cartService) CreateOrder(ctx context.Context, userID string) error {
+// 添加 GetCartItems 方法實現
+func (s *cartService) GetCartItems(ctx context.Context, userID string) ([]model.CartItem, error) {
 	cart, err := s.cartRepo.GetCart(ctx, userID)
 	if err != nil {
-		return fmt.Errorf("get cart failed: %w", err)
+		return nil, err
 	}
+	return cart.Items, nil
+}
 
-	var selectedItems []client.CartItemInfo
+// SaveForLater 將商品移到稍後購買
+func (s *cartService) SaveForLater(ctx context.Context, userID string, productID string) error {
+	return s.cartRepo.SaveForLater(ctx, userID, productID)
+}
+
+// MoveToCart 將稍後購買的商品移回購物車，並重新檢查庫存
+func (s *cartService) MoveToCart(ctx context.Context, userID string, productID string) error {
+	cart, err := s.cartRepo.GetCart(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	quantity := 0
+	for _, item := range cart.SavedItems {
+		if item.ProductID == productID {
+			quantity = item.Quantity
+			break
+		}
+	}
+	if quantity == 0 {
+		return ErrItemNotInCart
+	}
 	for _, item := range cart.Items {
-		if item.Selected {
-			selectedItems = append(selectedItems, client.CartItemInfo{
-				ProductID: item.ProductID,
-				Quantity:  item.Quantity,
-				Price:     item.Price,
-			})
+		if item.ProductID == productID {
+			quantity += item.Quantity
+			break
 		}
 	}
 
-	if len(selectedItems) == 0 {
-		return fmt.Errorf("no items selected")
+	productInfo, err := s.productClient.GetProduct(ctx, productID)
+	if err != nil {
+		return fmt.Errorf("failed to get product info: %w", err)
+	}
+	if productInfo == nil {
+		return ErrProductNotFound
+	}
+	if quantity > productInfo.Stock {
+		return ErrInvalidStock
 	}
 
-	_, err = s.orderClient.CreateOrder(ctx, &client.CreateOrderRequest{
-		UserID: userID,
-		Items:  selectedItems,
-	})
+	return s.cartRepo.MoveToCart(ctx, userID, productID)
+}
+
+// RemoveSavedItem 從稍後購買中移除商品
+func (s *cartService) RemoveSavedItem(ctx context.Context, userID string, productID string) error {
+	return s.cartRepo.RemoveSavedItem(ctx, userID, productID)
+}
+
+// MoveToWishlist 將購物車或稍後購買中的商品移到收藏清單
+func (s *cartService) MoveToWishlist(ctx context.Context, userID string, productID string) error {
+	cart, err := s.cartRepo.GetCart(ctx, userID)
 	if err != nil {
-		return fmt.Errorf("create order failed: %w", err)
+		return err
 	}
 
-	for _, item := range selectedItems {
-		if err := s.cartRepo.RemoveItem(ctx, userID, item.ProductID); err != nil {
+	inCart, inSaved := false, false
+	for _, item := range cart.Items {
+		if item.ProductID == productID {
+			inCart = true
+			break
+		}
+	}
+	for _, item := range cart.SavedItems {
+		if item.ProductID == productID {
+			inSaved = true
+			break
+		}
+	}
+	if !inCart && !inSaved {
+		return ErrItemNotInCart
+	}
+
+	exists, err := s.wishlistRepo.IsProductInWishlist(ctx, userID, productID)
+	if err != nil {
+		return fmt.Errorf("failed to check wishlist: %w", err)
+	}
+	if !exists {
+		if err := s.wishlistRepo.AddToWishlist(ctx, userID, productID); err != nil {
+			return fmt.Errorf("failed to add to wishlist: %w", err)
+		}
+	}
+
+	if inCart {
+		if err := s.cartRepo.RemoveItem(ctx, userID, productID); err != nil {
 			return fmt.Errorf("remove item failed: %w", err)
 		}
 	}
+	if inSaved {
+		if err := s.cartRepo.RemoveSavedItem(ctx, userID, productID); err != nil {
+			return fmt.Errorf("remove saved item failed: %w", err)
+		}
+	}
 
 	return nil
 }
 
-// 添加 GetCartItems 方法實現
-func (s *cartService) GetCartItems(ctx context.Context, userID string) ([]model.CartItem, error) {
+// BatchUpdate 在一次讀寫中套用多個購物車操作，任一操作驗證失敗則整批不套用
+func (s *cartService) BatchUpdate(ctx context.Context, userID string, req *model.BatchCartRequest) (*model.BatchCartResponse, error) {
+	if userID == "" {
+		return nil, fmt.Errorf("user ID cannot be empty")
+	}
+
+	// 一次查詢所有需要庫存資訊的商品
+	var productIDs []string
+	for _, op := range req.Operations {
+		if op.Op == model.CartOperationAdd || op.Op == model.CartOperationUpdate {
+			productIDs = append(productIDs, op.ProductID)
+		}
+	}
+	products, err := s.productClient.GetProducts(client.WithFreshProducts(ctx), productIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product info: %w", err)
+	}
+
 	cart, err := s.cartRepo.GetCart(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
-	return cart.Items, nil
+	if cart.UserID == "" {
+		cart.UserID = userID
+		cart.CreatedAt = time.Now()
+	}
+
+	// 在記憶體中依序套用，最後一次寫入
+	items := make([]model.CartItem, len(cart.Items))
+	copy(items, cart.Items)
+
+	response := &model.BatchCartResponse{
+		Applied: true,
+		Results: make([]model.CartOperationResult, len(req.Operations)),
+	}
+	for i, op := range req.Operations {
+		var opErr error
+		items, opErr = s.applyCartOperation(ctx, items, op, products[op.ProductID])
+
+		response.Results[i] = model.CartOperationResult{
+			Index:     i,
+			Op:        op.Op,
+			ProductID: op.ProductID,
+			Success:   opErr == nil,
+		}
+		if opErr != nil {
+			response.Results[i].Error = opErr.Error()
+			response.Applied = false
+		}
+	}
+
+	if !response.Applied {
+		return response, nil
+	}
+
+	cart.Items = items
+	cart.UpdatedAt = time.Now()
+	if err := s.cartRepo.SaveCart(ctx, cart); err != nil {
+		return nil, fmt.Errorf("failed to save cart: %w", err)
+	}
+
+	response.Cart, err = s.buildCartResponse(cart, "", "")
+	if err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// Reorder 將訂單商品以目前價格加入購物車
+func (s *cartService) Reorder(ctx context.Context, userID string, order *model.Order) (*model.ReorderResponse, error) {
+	response, err := s.AddItems(ctx, userID, order.Items)
+	if err != nil {
+		return nil, err
+	}
+	response.OrderID = order.ID
+	return response, nil
+}
+
+// AddItems 將商品以目前價格加入購物車：已刪除、下架或無庫存的商品略過，
+// 庫存不足時加入可購買的數量，並返回每項商品的處理結果
+func (s *cartService) AddItems(ctx context.Context, userID string, items []model.OrderItem) (*model.ReorderResponse, error) {
+	if userID == "" {
+		return nil, fmt.Errorf("user ID cannot be empty")
+	}
+
+	// 合併同一商品的數量，並保留原本的順序
+	var orderItems []model.OrderItem
+	positions := make(map[string]int, len(items))
+	productIDs := make([]string, 0, len(items))
+	for _, item := range items {
+		if i, ok := positions[item.ProductID]; ok {
+			orderItems[i].Quantity += item.Quantity
+			continue
+		}
+		positions[item.ProductID] = len(orderItems)
+		orderItems = append(orderItems, item)
+		productIDs = append(productIDs, item.ProductID)
+	}
+
+	// 可加入的數量取決於庫存，需略過快取
+	products, err := s.productClient.GetProducts(client.WithFreshProducts(ctx), productIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product info: %w", err)
+	}
+
+	cart, err := s.cartRepo.GetCart(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if cart.UserID == "" {
+		cart.UserID = userID
+		cart.CreatedAt = time.Now()
+	}
+
+	response := &model.ReorderResponse{
+		Items: make([]model.ReorderItemResult, 0, len(orderItems)),
+	}
+	for _, item := range orderItems {
+		result := model.ReorderItemResult{
+			ProductID:         item.ProductID,
+			Name:              item.Name,
+			Status:            model.ReorderItemSkipped,
+			RequestedQuantity: item.Quantity,
+			OrderedPrice:      item.Price,
+		}
+
+		productInfo := products[item.ProductID]
+		index := -1
+		for i, cartItem := range cart.Items {
+			if cartItem.ProductID == item.ProductID {
+				index = i
+				break
+			}
+		}
+		existingQuantity := 0
+		if index >= 0 {
+			existingQuantity = cart.Items[index].Quantity
+		}
+
+		switch {
+		case productInfo == nil:
+			result.Reason = "product no longer exists"
+		case productInfo.Status != "" && productInfo.Status != "active":
+			result.Reason = "product is not available"
+		case productInfo.Stock <= existingQuantity:
+			result.Reason = "out of stock"
+		default:
+			quantity := item.Quantity
+			if available := productInfo.Stock - existingQuantity; quantity > available {
+				quantity = available
+				result.Status = model.ReorderItemAdjusted
+				result.Reason = fmt.Sprintf("only %d available", available)
+			} else {
+				result.Status = model.ReorderItemAdded
+			}
+			result.Name = productInfo.Name
+			result.AddedQuantity = quantity
+			result.CurrentPrice = productInfo.Price
+
+			if index >= 0 {
+				cart.Items[index].Quantity += quantity
+				cart.Items[index].Price = productInfo.Price
+				cart.Items[index].StockCount = productInfo.Stock
+				cart.Items[index].Selected = true
+				cart.Items[index].UpdatedAt = time.Now()
+			} else {
+				cart.Items = append(cart.Items, s.newCartItem(ctx, item.ProductID, productInfo, quantity))
+			}
+		}
+
+		switch result.Status {
+		case model.ReorderItemAdded:
+			response.Added++
+		case model.ReorderItemAdjusted:
+			response.Adjusted++
+		default:
+			response.Skipped++
+		}
+		response.Items = append(response.Items, result)
+	}
+
+	if response.Added+response.Adjusted > 0 {
+		cart.UpdatedAt = time.Now()
+		if err := s.cartRepo.SaveCart(ctx, cart); err != nil {
+			return nil, fmt.Errorf("failed to save cart: %w", err)
+		}
+	}
+
+	response.Cart, err = s.buildCartResponse(cart, "", "")
+	if err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+// applyCartOperation 對購物車項目套用單一操作
+func (s *cartService) applyCartOperation(ctx context.Context, items []model.CartItem, op model.CartOperation, productInfo *client.ProductInfo) ([]model.CartItem, error) {
+	index := -1
+	for i, item := range items {
+		if item.ProductID == op.ProductID {
+			index = i
+			break
+		}
+	}
+
+	switch op.Op {
+	case model.CartOperationAdd:
+		if op.Quantity <= 0 {
+			return items, fmt.Errorf("quantity must be greater than 0")
+		}
+		if productInfo == nil {
+			return items, ErrProductNotFound
+		}
+		existingQuantity := 0
+		if index >= 0 {
+			existingQuantity = items[index].Quantity
+		}
+		if existingQuantity+op.Quantity > productInfo.Stock {
+			return items, fmt.Errorf("total quantity exceeds stock: cart has %d, adding %d, stock is %d",
+				existingQuantity, op.Quantity, productInfo.Stock)
+		}
+		if index >= 0 {
+			items[index].Quantity += op.Quantity
+			items[index].StockCount = productInfo.Stock
+			items[index].UpdatedAt = time.Now()
+			return items, nil
+		}
+		return append(items, s.newCartItem(ctx, op.ProductID, productInfo, op.Quantity)), nil
+
+	case model.CartOperationUpdate:
+		if index < 0 {
+			return items, ErrItemNotInCart
+		}
+		if productInfo == nil {
+			return items, ErrProductNotFound
+		}
+		if op.Quantity > productInfo.Stock {
+			return items, ErrInvalidStock
+		}
+		if op.Quantity == 0 {
+			return append(items[:index], items[index+1:]...), nil
+		}
+		items[index].Quantity = op.Quantity
+		items[index].StockCount = productInfo.Stock
+		items[index].UpdatedAt = time.Now()
+		return items, nil
+
+	case model.CartOperationRemove:
+		if index < 0 {
+			return items, ErrItemNotInCart
+		}
+		return append(items[:index], items[index+1:]...), nil
+
+	case model.CartOperationSelect:
+		if index < 0 {
+			return items, ErrItemNotInCart
+		}
+		if op.Selected == nil {
+			return items, fmt.Errorf("selected is required")
+		}
+		items[index].Selected = *op.Selected
+		return items, nil
+
+	default:
+		return items, fmt.Errorf("unsupported operation: %s", op.Op)
+	}
 }
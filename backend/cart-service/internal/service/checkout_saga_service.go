@@ -0,0 +1,668 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/client"
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/model"
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/repository"
+)
+
+var (
+	ErrNoItemsSelected = errors.New("no items selected")
+	ErrPaymentFailed   = errors.New("payment failed")
+	ErrSagaNotFound    = repository.ErrSagaNotFound
+	ErrSagaNotStuck    = errors.New("checkout saga is not stuck")
+)
+
+// CheckoutSagaServiceConfig 結帳流程配置
+type CheckoutSagaServiceConfig struct {
+	ServiceSecret          string        // 背景續跑時簽發代表用戶的服務 token，與用戶 token 的密鑰分開
+	DefaultCurrency        string        // 未指定幣別時使用
+	ReservationTTL         time.Duration // 庫存預留時間
+	ConfirmationTemplateID string        // 訂單確認通知模板，空白時不發送
+	ResumeAfter            time.Duration // 執行者的租約時間，逾期未更新視為中斷，由背景任務續跑
+	MaxAttempts            int           // 背景續跑次數上限，超過時未付款的流程執行補償，其餘標記為 failed
+	StepRetries            int           // 步驟遇到暫時性錯誤時在同一次執行中的重試次數
+	StepRetryBackoff       time.Duration // 步驟重試的初始等待時間，每次加倍
+}
+
+// CheckoutSagaService 結帳流程編排：預留庫存 → 建立訂單 → 建立支付 → 處理支付 → 確認訂單 → 清除已選商品 → 發送確認通知 → 開立電子發票
+type CheckoutSagaService interface {
+	Checkout(ctx context.Context, userID string, req *model.CheckoutRequest) (*model.CheckoutSaga, error)
+	CheckoutSubscription(ctx context.Context, sub *model.Subscription) (*model.CheckoutSaga, error)
+	GetSaga(ctx context.Context, id string) (*model.CheckoutSaga, error)
+	ResumeSagas(ctx context.Context) (int, error)
+	ListStuckSagas(ctx context.Context, olderThan time.Duration) ([]model.CheckoutSaga, error)
+	RetrySaga(ctx context.Context, id string) (*model.CheckoutSaga, error)
+}
+
+// sagaStep 流程步驟與其補償動作，compensate 為 nil 表示無需補償
+type sagaStep struct {
+	name       model.SagaStep
+	execute    func(ctx context.Context, saga *model.CheckoutSaga) error
+	compensate func(ctx context.Context, saga *model.CheckoutSaga) error
+}
+
+type checkoutSagaService struct {
+	sagaRepo           repository.CheckoutSagaRepository
+	orderRepo          repository.OrderRepository
+	orderService       OrderService
+	cartService        CartService
+	productClient      client.ProductClient
+	paymentClient      client.PaymentClient
+	notificationClient client.NotificationClient
+	pricing            PricingService
+	einvoiceService    EInvoiceService
+	config             *CheckoutSagaServiceConfig
+
+	steps []sagaStep
+	// pivot 之前的步驟失敗時執行補償；之後的步驟失敗則重試直到完成
+	pivot int
+}
+
+// NewCheckoutSagaService 創建結帳流程服務實例
+func NewCheckoutSagaService(
+	sagaRepo repository.CheckoutSagaRepository,
+	orderRepo repository.OrderRepository,
+	orderService OrderService,
+	cartService CartService,
+	productClient client.ProductClient,
+	paymentClient client.PaymentClient,
+	notificationClient client.NotificationClient,
+	pricing PricingService,
+	einvoiceService EInvoiceService,
+	config *CheckoutSagaServiceConfig,
+) CheckoutSagaService {
+	if config == nil {
+		config = &CheckoutSagaServiceConfig{}
+	}
+	if config.DefaultCurrency == "" {
+		config.DefaultCurrency = "TWD"
+	}
+	if config.ReservationTTL <= 0 {
+		config.ReservationTTL = 15 * time.Minute
+	}
+	if config.ResumeAfter <= 0 {
+		config.ResumeAfter = 2 * time.Minute
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = 5
+	}
+	if config.StepRetries <= 0 {
+		config.StepRetries = 2
+	}
+	if config.StepRetryBackoff <= 0 {
+		config.StepRetryBackoff = 500 * time.Millisecond
+	}
+
+	s := &checkoutSagaService{
+		sagaRepo:           sagaRepo,
+		orderRepo:          orderRepo,
+		orderService:       orderService,
+		cartService:        cartService,
+		productClient:      productClient,
+		paymentClient:      paymentClient,
+		notificationClient: notificationClient,
+		pricing:            pricing,
+		einvoiceService:    einvoiceService,
+		config:             config,
+	}
+	s.steps = []sagaStep{
+		{name: model.SagaStepReserveStock, execute: s.reserveStock, compensate: s.releaseStock},
+		{name: model.SagaStepCreateOrder, execute: s.createOrder, compensate: s.cancelOrder},
+		{name: model.SagaStepCreatePayment, execute: s.createPayment, compensate: s.cancelPayment},
+		{name: model.SagaStepProcessPayment, execute: s.processPayment, compensate: s.refundPayment},
+		{name: model.SagaStepConfirmOrder, execute: s.confirmOrder},
+		{name: model.SagaStepClearCart, execute: s.clearCart},
+		{name: model.SagaStepSendConfirmation, execute: s.sendConfirmation},
+		{name: model.SagaStepIssueEInvoice, execute: s.issueEInvoice},
+	}
+	s.pivot = 4 // confirm_order
+	return s
+}
+
+// Checkout 以購物車中已選商品的快照建立並執行結帳流程，失敗時返回流程與失敗原因
+func (s *checkoutSagaService) Checkout(ctx context.Context, userID string, req *model.CheckoutRequest) (*model.CheckoutSaga, error) {
+	items, err := s.orderService.SnapshotSelectedItems(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.start(ctx, userID, items, req, "")
+}
+
+// CheckoutSubscription 以訂閱商品的目前價格結帳，不影響購物車
+func (s *checkoutSagaService) CheckoutSubscription(ctx context.Context, sub *model.Subscription) (*model.CheckoutSaga, error) {
+	selected := make([]model.OrderItem, 0, len(sub.Items))
+	for _, item := range sub.Items {
+		selected = append(selected, model.OrderItem{ProductID: item.ProductID, Quantity: item.Quantity})
+	}
+	items, err := s.orderService.SnapshotItems(ctx, selected)
+	if err != nil {
+		return nil, err
+	}
+	shippingInfo := sub.ShippingInfo
+	return s.start(ctx, sub.UserID, items, &model.CheckoutRequest{
+		ShippingInfo:  &shippingInfo,
+		EInvoice:      sub.EInvoiceInfo,
+		PaymentMethod: sub.PaymentMethod,
+		Currency:      sub.Currency,
+	}, sub.ID)
+}
+
+// start 計算金額並建立、執行結帳流程
+func (s *checkoutSagaService) start(ctx context.Context, userID string, items []model.OrderItem, req *model.CheckoutRequest, subscriptionID string) (*model.CheckoutSaga, error) {
+	shippingInfo, err := s.orderService.ResolveShippingInfo(ctx, req.ShippingInfo)
+	if err != nil {
+		return nil, err
+	}
+	einvoiceInfo, err := s.orderService.ResolveEInvoiceInfo(req.EInvoice)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := make([]model.PricingLine, 0, len(items))
+	for _, item := range items {
+		lines = append(lines, model.PricingLine{
+			ProductID: item.ProductID,
+			Price:     item.Price,
+			Quantity:  item.Quantity,
+			Weight:    item.Weight,
+			Group:     item.FulfillmentGroup(),
+		})
+	}
+	// 付款金額需與拆單後各子訂單的運費合計一致
+	pricing, _, err := s.pricing.CalculateSplit(lines, shippingInfo.ShippingMethod, shippingInfo.Address.Country)
+	if err != nil {
+		return nil, err
+	}
+	shippingInfo.ShippingMethod = pricing.ShippingMethod
+
+	currency := req.Currency
+	if currency == "" {
+		currency = s.config.DefaultCurrency
+	}
+
+	now := time.Now()
+	saga := &model.CheckoutSaga{
+		ID:             uuid.New().String(),
+		UserID:         userID,
+		Status:         model.SagaStatusRunning,
+		Items:          items,
+		ShippingInfo:   shippingInfo,
+		EInvoiceInfo:   einvoiceInfo,
+		Pricing:        pricing,
+		PaymentMethod:  req.PaymentMethod,
+		Currency:       currency,
+		OrderID:        uuid.New().String(),
+		SubscriptionID: subscriptionID,
+		LeaseOwner:     uuid.New().String(),
+		CreatedAt:      now,
+	}
+	if err := s.save(ctx, saga); err != nil {
+		return nil, err
+	}
+
+	return saga, s.run(ctx, saga)
+}
+
+// GetSaga 獲取結帳流程
+func (s *checkoutSagaService) GetSaga(ctx context.Context, id string) (*model.CheckoutSaga, error) {
+	saga, err := s.sagaRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if saga == nil {
+		return nil, ErrSagaNotFound
+	}
+	return saga, nil
+}
+
+// ResumeSagas 續跑因服務重啟或暫時錯誤而中斷的流程，返回處理數量；
+// 流程須先取得租約，仍由其他請求或實例執行中的流程會略過
+func (s *checkoutSagaService) ResumeSagas(ctx context.Context) (int, error) {
+	cutoff := time.Now().Add(-s.config.ResumeAfter)
+	owner := uuid.New().String()
+	resumed := 0
+	for _, status := range []model.SagaStatus{model.SagaStatusRunning, model.SagaStatusCompensating} {
+		sagas, err := s.sagaRepo.ListByStatus(ctx, status)
+		if err != nil {
+			return resumed, err
+		}
+
+		for _, listed := range sagas {
+			if listed.UpdatedAt.After(cutoff) {
+				continue
+			}
+			saga, err := s.sagaRepo.Claim(ctx, listed.ID, owner, time.Now().Add(s.config.ResumeAfter))
+			if err != nil {
+				if err != repository.ErrSagaLeaseHeld && err != repository.ErrSagaNotFound {
+					log.Printf("Failed to claim checkout saga %s: %v", listed.ID, err)
+				}
+				continue
+			}
+			// 取得租約前流程可能已由其他執行者推進
+			if saga.Status != model.SagaStatusRunning && saga.Status != model.SagaStatusCompensating {
+				s.release(ctx, saga)
+				continue
+			}
+
+			saga.Attempts++
+			if saga.Attempts > s.config.MaxAttempts {
+				// 尚未確認訂單的流程放棄重試並執行補償，已付款的流程交由管理員處理
+				if saga.Status == model.SagaStatusRunning && len(saga.CompletedSteps) <= s.pivot {
+					saga.Status = model.SagaStatusCompensating
+				} else {
+					saga.Status = model.SagaStatusFailed
+					if err := s.save(ctx, saga); err != nil {
+						log.Printf("Failed to mark checkout saga %s as failed: %v", saga.ID, err)
+					}
+					s.release(ctx, saga)
+					continue
+				}
+			}
+
+			if err := s.run(ctx, saga); err != nil {
+				log.Printf("Checkout saga %s resumed with error: %v", saga.ID, err)
+			}
+			resumed++
+		}
+	}
+	return resumed, nil
+}
+
+// ListStuckSagas 列出需人工處理或長時間未推進的流程
+func (s *checkoutSagaService) ListStuckSagas(ctx context.Context, olderThan time.Duration) ([]model.CheckoutSaga, error) {
+	cutoff := time.Now().Add(-olderThan)
+	var stuck []model.CheckoutSaga
+	for _, status := range []model.SagaStatus{model.SagaStatusFailed, model.SagaStatusRunning, model.SagaStatusCompensating} {
+		sagas, err := s.sagaRepo.ListByStatus(ctx, status)
+		if err != nil {
+			return nil, err
+		}
+		for _, saga := range sagas {
+			if status == model.SagaStatusFailed || saga.UpdatedAt.Before(cutoff) {
+				stuck = append(stuck, saga)
+			}
+		}
+	}
+
+	sort.Slice(stuck, func(i, j int) bool { return stuck[i].UpdatedAt.Before(stuck[j].UpdatedAt) })
+	return stuck, nil
+}
+
+// RetrySaga 重新執行 failed 的流程：已通過確認訂單者繼續往前，否則繼續補償
+func (s *checkoutSagaService) RetrySaga(ctx context.Context, id string) (*model.CheckoutSaga, error) {
+	saga, err := s.GetSaga(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if saga.Status != model.SagaStatusFailed {
+		return nil, ErrSagaNotStuck
+	}
+	if saga, err = s.sagaRepo.Claim(ctx, id, uuid.New().String(), time.Now().Add(s.config.ResumeAfter)); err != nil {
+		if err == repository.ErrSagaLeaseHeld {
+			return nil, ErrSagaNotStuck
+		}
+		return nil, err
+	}
+	if saga.Status != model.SagaStatusFailed {
+		s.release(ctx, saga)
+		return nil, ErrSagaNotStuck
+	}
+
+	saga.Attempts = 0
+	saga.Status = model.SagaStatusCompensating
+	if len(saga.CompletedSteps) > s.pivot {
+		saga.Status = model.SagaStatusRunning
+	}
+	return saga, s.run(ctx, saga)
+}
+
+// run 依流程狀態往前執行或執行補償，結束後釋放租約；呼叫前須已持有租約
+func (s *checkoutSagaService) run(ctx context.Context, saga *model.CheckoutSaga) error {
+	defer s.release(ctx, saga)
+
+	ctx, err := s.withToken(ctx, saga)
+	if err != nil {
+		return err
+	}
+
+	var cause error
+	if saga.Status == model.SagaStatusRunning {
+		cause = s.forward(ctx, saga)
+		if cause == nil || saga.Status != model.SagaStatusCompensating {
+			return cause
+		}
+	}
+
+	if saga.Status == model.SagaStatusCompensating {
+		if err := s.compensate(ctx, saga); err != nil {
+			return err
+		}
+	}
+	if cause == nil && saga.Error != "" {
+		cause = errors.New(saga.Error)
+	}
+	return cause
+}
+
+// forward 從上次完成的步驟繼續執行
+func (s *checkoutSagaService) forward(ctx context.Context, saga *model.CheckoutSaga) error {
+	for i := len(saga.CompletedSteps); i < len(s.steps); i++ {
+		step := s.steps[i]
+		saga.CurrentStep = step.name
+		if err := s.execute(ctx, saga, step); err != nil {
+			saga.Error = err.Error()
+			// 暫時性錯誤保留 running 由背景任務續跑，重試次數用盡後才補償
+			if i <= s.pivot && !isTransientStepError(err) {
+				saga.Status = model.SagaStatusCompensating
+			}
+			if saveErr := s.save(ctx, saga); saveErr != nil {
+				log.Printf("Failed to save checkout saga %s: %v", saga.ID, saveErr)
+			}
+			return err
+		}
+
+		saga.CompletedSteps = append(saga.CompletedSteps, step.name)
+		saga.Error = ""
+		if err := s.save(ctx, saga); err != nil {
+			return err
+		}
+	}
+
+	saga.Status = model.SagaStatusCompleted
+	saga.CurrentStep = ""
+	return s.sagaRepo.Save(ctx, saga)
+}
+
+// compensate 依相反順序執行已完成步驟的補償
+func (s *checkoutSagaService) compensate(ctx context.Context, saga *model.CheckoutSaga) error {
+	for i := len(saga.CompletedSteps) - 1; i >= 0; i-- {
+		step := s.step(saga.CompletedSteps[i])
+		if step != nil && step.compensate != nil {
+			if err := step.compensate(ctx, saga); err != nil {
+				saga.Error = fmt.Sprintf("compensate %s: %v", step.name, err)
+				if saveErr := s.save(ctx, saga); saveErr != nil {
+					log.Printf("Failed to save checkout saga %s: %v", saga.ID, saveErr)
+				}
+				return err
+			}
+		}
+
+		saga.CompletedSteps = saga.CompletedSteps[:i]
+		if err := s.save(ctx, saga); err != nil {
+			return err
+		}
+	}
+
+	saga.Status = model.SagaStatusCompensated
+	saga.CurrentStep = ""
+	return s.sagaRepo.Save(ctx, saga)
+}
+
+// execute 執行步驟，暫時性錯誤依 StepRetries 以倍增的間隔重試
+func (s *checkoutSagaService) execute(ctx context.Context, saga *model.CheckoutSaga, step sagaStep) error {
+	backoff := s.config.StepRetryBackoff
+	for attempt := 0; ; attempt++ {
+		err := step.execute(ctx, saga)
+		if err == nil || !isTransientStepError(err) || attempt >= s.config.StepRetries {
+			return err
+		}
+		log.Printf("Checkout saga %s step %s failed, retrying: %v", saga.ID, step.name, err)
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		backoff *= 2
+	}
+}
+
+// isTransientStepError 判斷步驟錯誤是否可重試；庫存不足、付款失敗等業務錯誤重試也不會成功
+func isTransientStepError(err error) bool {
+	switch {
+	case errors.Is(err, ErrStockUnavailable),
+		errors.Is(err, ErrPaymentFailed),
+		errors.Is(err, ErrInvalidTransition),
+		errors.Is(err, ErrOrderNotFound),
+		errors.Is(err, ErrReservationExpired),
+		errors.Is(err, client.ErrInsufficientStock),
+		errors.Is(err, client.ErrProductNotFound),
+		errors.Is(err, client.ErrReservationClosed),
+		errors.Is(err, client.ErrInvalidPaymentStatus),
+		errors.Is(err, repository.ErrSagaLeaseLost):
+		return false
+	}
+	return true
+}
+
+// save 保存流程並延長租約
+func (s *checkoutSagaService) save(ctx context.Context, saga *model.CheckoutSaga) error {
+	expiresAt := time.Now().Add(s.config.ResumeAfter)
+	saga.LeaseExpiresAt = &expiresAt
+	return s.sagaRepo.Save(ctx, saga)
+}
+
+// release 釋放流程的租約，失敗時等待租約到期
+func (s *checkoutSagaService) release(ctx context.Context, saga *model.CheckoutSaga) {
+	if saga.LeaseOwner == "" {
+		return
+	}
+	// 請求已取消時仍須釋放租約
+	if err := s.sagaRepo.Release(context.WithoutCancel(ctx), saga.ID, saga.LeaseOwner); err != nil && err != repository.ErrSagaLeaseLost {
+		log.Printf("Failed to release checkout saga %s: %v", saga.ID, err)
+	}
+}
+
+func (s *checkoutSagaService) step(name model.SagaStep) *sagaStep {
+	for i := range s.steps {
+		if s.steps[i].name == name {
+			return &s.steps[i]
+		}
+	}
+	return nil
+}
+
+// withToken 請求未帶 token 時（背景續跑）簽發代表該用戶的服務 token
+func (s *checkoutSagaService) withToken(ctx context.Context, saga *model.CheckoutSaga) (context.Context, error) {
+	if token, _ := ctx.Value(client.TokenKey).(string); token != "" {
+		return ctx, nil
+	}
+	token, err := client.SignServiceToken(s.config.ServiceSecret, saga.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("sign service token failed: %w", err)
+	}
+	return context.WithValue(ctx, client.TokenKey, token), nil
+}
+
+// reserveStock 預留庫存；若預留成功但狀態未保存，舊的預留會由 product service 逾時歸還
+func (s *checkoutSagaService) reserveStock(ctx context.Context, saga *model.CheckoutSaga) error {
+	items := make([]client.ReservationItem, 0, len(saga.Items))
+	for _, item := range saga.Items {
+		items = append(items, client.ReservationItem{ProductID: item.ProductID, Quantity: item.Quantity})
+	}
+
+	reservation, err := s.productClient.ReserveStock(ctx, &client.ReserveStockRequest{
+		OrderID:    saga.OrderID,
+		Items:      items,
+		TTLSeconds: int(s.config.ReservationTTL / time.Second),
+	})
+	if err != nil {
+		if errors.Is(err, client.ErrInsufficientStock) || errors.Is(err, client.ErrProductNotFound) {
+			return ErrStockUnavailable
+		}
+		return err
+	}
+	saga.ReservationID = reservation.ID
+	saga.ReservationExpiresAt = &reservation.ExpiresAt
+	return nil
+}
+
+func (s *checkoutSagaService) releaseStock(ctx context.Context, saga *model.CheckoutSaga) error {
+	if saga.ReservationID == "" {
+		return nil
+	}
+	if _, err := s.productClient.ReleaseReservation(ctx, saga.ReservationID); err != nil && !errors.Is(err, client.ErrReservationClosed) {
+		return err
+	}
+	return nil
+}
+
+// createOrder 建立待付款訂單並依出貨分組拆單，訂單ID於流程開始時產生，重複執行會覆寫同一筆訂單
+func (s *checkoutSagaService) createOrder(ctx context.Context, saga *model.CheckoutSaga) error {
+	order := &model.Order{
+		ID:                   saga.OrderID,
+		UserID:               saga.UserID,
+		Items:                saga.Items,
+		Status:               model.OrderStatusPending,
+		ShippingInfo:         saga.ShippingInfo,
+		EInvoiceInfo:         saga.EInvoiceInfo,
+		SubscriptionID:       saga.SubscriptionID,
+		ReservationID:        saga.ReservationID,
+		ReservationExpiresAt: saga.ReservationExpiresAt,
+	}
+	children, err := s.orderService.SplitOrder(order)
+	if err != nil {
+		return err
+	}
+	saga.Pricing = order.Pricing
+	return s.orderRepo.Create(ctx, order, children...)
+}
+
+func (s *checkoutSagaService) cancelOrder(ctx context.Context, saga *model.CheckoutSaga) error {
+	return s.orderService.UpdateOrderStatus(ctx, saga.OrderID, model.OrderStatusCancelled, model.OrderActorSystem, "checkout failed: "+saga.Error)
+}
+
+// createPayment 建立支付，已存在同一訂單的支付時直接沿用
+func (s *checkoutSagaService) createPayment(ctx context.Context, saga *model.CheckoutSaga) error {
+	payment, err := s.paymentClient.GetPaymentByOrderID(ctx, saga.OrderID)
+	if err != nil {
+		if err != client.ErrPaymentNotFound {
+			return err
+		}
+		payment, err = s.paymentClient.CreatePayment(ctx, &client.CreatePaymentRequest{
+			OrderID:  saga.OrderID,
+			Amount:   saga.Pricing.GrandTotal,
+			Currency: saga.Currency,
+			Method:   saga.PaymentMethod,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	saga.PaymentID = payment.ID
+	return nil
+}
+
+func (s *checkoutSagaService) cancelPayment(ctx context.Context, saga *model.CheckoutSaga) error {
+	if saga.PaymentID == "" {
+		return nil
+	}
+	// 已處理的支付無法取消，退款由 process_payment 的補償處理
+	if err := s.paymentClient.CancelPayment(ctx, saga.PaymentID); err != nil && !errors.Is(err, client.ErrInvalidPaymentStatus) {
+		return err
+	}
+	return nil
+}
+
+// processPayment 處理支付並確認結果，支付失敗時返回 ErrPaymentFailed
+func (s *checkoutSagaService) processPayment(ctx context.Context, saga *model.CheckoutSaga) error {
+	payment, err := s.paymentClient.GetPayment(ctx, saga.PaymentID)
+	if err != nil {
+		return err
+	}
+	if payment.Status == client.PaymentStatusPending {
+		if err := s.paymentClient.ProcessPayment(ctx, saga.PaymentID); err != nil && !errors.Is(err, client.ErrInvalidPaymentStatus) {
+			return err
+		}
+		if payment, err = s.paymentClient.GetPayment(ctx, saga.PaymentID); err != nil {
+			return err
+		}
+	}
+
+	if payment.Status != client.PaymentStatusSuccess {
+		return fmt.Errorf("%w: %s", ErrPaymentFailed, payment.ErrorMessage)
+	}
+	return nil
+}
+
+func (s *checkoutSagaService) refundPayment(ctx context.Context, saga *model.CheckoutSaga) error {
+	payment, err := s.paymentClient.GetPayment(ctx, saga.PaymentID)
+	if err != nil {
+		return err
+	}
+	if payment.Status != client.PaymentStatusSuccess {
+		return nil
+	}
+	// payment service 只接受管理員或服務 token 退款，不使用顧客的 token
+	token, err := client.SignServiceToken(s.config.ServiceSecret, saga.UserID)
+	if err != nil {
+		return fmt.Errorf("sign service token failed: %w", err)
+	}
+	return s.paymentClient.RefundPayment(context.WithValue(ctx, client.TokenKey, token), &client.RefundPaymentRequest{
+		PaymentID: payment.ID,
+		Amount:    payment.Amount,
+		Reason:    "checkout rolled back",
+	})
+}
+
+// confirmOrder 將訂單標記為已付款並確認庫存預留；預留已逾時時觸發補償退款
+func (s *checkoutSagaService) confirmOrder(ctx context.Context, saga *model.CheckoutSaga) error {
+	return s.orderService.UpdateOrderStatus(ctx, saga.OrderID, model.OrderStatusPaid, model.OrderActorSystem, "payment "+saga.PaymentID+" succeeded")
+}
+
+func (s *checkoutSagaService) clearCart(ctx context.Context, saga *model.CheckoutSaga) error {
+	if saga.SubscriptionID != "" {
+		return nil
+	}
+	productIDs := make([]string, 0, len(saga.Items))
+	for _, item := range saga.Items {
+		productIDs = append(productIDs, item.ProductID)
+	}
+	return s.cartService.RemoveItems(ctx, saga.UserID, productIDs)
+}
+
+// sendConfirmation 發送訂單確認通知，通知失敗不影響結帳結果
+func (s *checkoutSagaService) sendConfirmation(ctx context.Context, saga *model.CheckoutSaga) error {
+	if s.config.ConfirmationTemplateID == "" {
+		return nil
+	}
+
+	err := s.notificationClient.SendTemplate(ctx, &client.TemplateNotificationRequest{
+		UserID:     saga.UserID,
+		TemplateID: s.config.ConfirmationTemplateID,
+		Priority:   "high",
+		Variables: map[string]interface{}{
+			"orderId":     saga.OrderID,
+			"itemCount":   len(saga.Items),
+			"totalAmount": saga.Pricing.GrandTotal,
+			"currency":    saga.Currency,
+		},
+		Metadata: map[string]interface{}{
+			"type":    "order_confirmation",
+			"orderId": saga.OrderID,
+		},
+	})
+	if err != nil {
+		log.Printf("Failed to send order confirmation for order %s: %v", saga.OrderID, err)
+	}
+	return nil
+}
+
+// issueEInvoice 開立電子發票，開立失敗不影響結帳結果，可由管理員補開
+func (s *checkoutSagaService) issueEInvoice(ctx context.Context, saga *model.CheckoutSaga) error {
+	if _, err := s.einvoiceService.IssueForOrder(ctx, saga.OrderID); err != nil && err != ErrEInvoiceDisabled {
+		log.Printf("Failed to issue e-invoice for order %s: %v", saga.OrderID, err)
+	}
+	return nil
+}
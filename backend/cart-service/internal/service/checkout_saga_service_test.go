@@ -0,0 +1,272 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/client"
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/model"
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/repository"
+)
+
+const (
+	testUserID        = "user-1"
+	testUserToken     = "Bearer user-token"
+	testServiceSecret = "test-service-secret"
+)
+
+// sagaHarness 以模擬依賴組成的結帳流程
+type sagaHarness struct {
+	log      *callLog
+	sagas    *fakeSagaRepo
+	orders   *fakeOrderRepo
+	products *fakeProductClient
+	payments *fakePaymentClient
+	cart     *fakeCartService
+	service  CheckoutSagaService
+}
+
+func newSagaHarness() *sagaHarness {
+	log := &callLog{}
+	h := &sagaHarness{
+		log:    log,
+		sagas:  newFakeSagaRepo(),
+		orders: newFakeOrderRepo(),
+		products: newFakeProductClient(log,
+			&client.ProductInfo{ID: "p1", Name: "咖啡豆", Price: 300, Weight: 0.5, Status: "active"},
+			&client.ProductInfo{ID: "p2", Name: "濾杯", Price: 450, Weight: 0.3, Status: "active"},
+		),
+		payments: newFakePaymentClient(log),
+		cart:     &fakeCartService{log: log},
+	}
+	cartRepo := &fakeCartRepo{cart: model.Cart{
+		UserID: testUserID,
+		Items: []model.CartItem{
+			{ProductID: "p1", Quantity: 2, Selected: true},
+			{ProductID: "p2", Quantity: 1, Selected: true},
+			{ProductID: "p3", Quantity: 1},
+		},
+	}}
+	pricing := NewPricingService(nil)
+	orderService := NewOrderService(h.orders, cartRepo, h.products, nil, pricing, fakeAnalyticsService{})
+	h.service = NewCheckoutSagaService(h.sagas, h.orders, orderService, h.cart, h.products, h.payments, nil, pricing, fakeEInvoiceService{}, &CheckoutSagaServiceConfig{
+		ServiceSecret:    testServiceSecret,
+		StepRetries:      1,
+		StepRetryBackoff: time.Millisecond,
+	})
+	return h
+}
+
+func (h *sagaHarness) checkout() (*model.CheckoutSaga, error) {
+	ctx := context.WithValue(context.Background(), client.TokenKey, testUserToken)
+	return h.service.Checkout(ctx, testUserID, &model.CheckoutRequest{
+		ShippingInfo: &model.ShippingInfo{
+			RecipientName: "王小明",
+			PhoneNumber:   "0912-345-678",
+			Address:       model.Address{Street: "信義路五段7號", City: "台北市", PostalCode: "110"},
+		},
+		PaymentMethod: "credit_card",
+	})
+}
+
+func assertCalls(t *testing.T, log *callLog, want []string) {
+	t.Helper()
+	if got := log.list(); !reflect.DeepEqual(got, want) {
+		t.Errorf("calls = %v, want %v", got, want)
+	}
+}
+
+func TestCheckoutSagaCompletes(t *testing.T) {
+	h := newSagaHarness()
+
+	saga, err := h.checkout()
+	if err != nil {
+		t.Fatalf("Checkout: %v", err)
+	}
+	if saga.Status != model.SagaStatusCompleted {
+		t.Errorf("status = %s, want %s", saga.Status, model.SagaStatusCompleted)
+	}
+	if len(saga.Items) != 2 {
+		t.Errorf("got %d items, want only the 2 selected items", len(saga.Items))
+	}
+	assertCalls(t, h.log, []string{"reserve_stock", "create_payment", "process_payment", "commit_reservation", "remove_cart_items"})
+
+	stored := h.sagas.only()
+	if stored.Status != model.SagaStatusCompleted || len(stored.CompletedSteps) != 8 {
+		t.Errorf("stored saga = %s with %d steps, want completed with 8 steps", stored.Status, len(stored.CompletedSteps))
+	}
+	if stored.LeaseOwner != "" {
+		t.Errorf("lease owner = %q, want released", stored.LeaseOwner)
+	}
+	if status := h.orders.status(saga.OrderID); status != model.OrderStatusPaid {
+		t.Errorf("order status = %s, want %s", status, model.OrderStatusPaid)
+	}
+	if status := h.products.reservationStatus(saga.ReservationID); status != "committed" {
+		t.Errorf("reservation status = %s, want committed", status)
+	}
+}
+
+func TestCheckoutSagaCompensatesDeclinedPayment(t *testing.T) {
+	h := newSagaHarness()
+	h.payments.processResult = client.PaymentStatusFailed
+
+	saga, err := h.checkout()
+	if !errors.Is(err, ErrPaymentFailed) {
+		t.Fatalf("Checkout error = %v, want ErrPaymentFailed", err)
+	}
+	if saga.Status != model.SagaStatusCompensated {
+		t.Errorf("status = %s, want %s", saga.Status, model.SagaStatusCompensated)
+	}
+	if len(saga.CompletedSteps) != 0 {
+		t.Errorf("completed steps = %v, want all compensated", saga.CompletedSteps)
+	}
+	// 補償依相反順序：取消支付 → 取消訂單（釋放預留）→ 釋放庫存
+	assertCalls(t, h.log, []string{
+		"reserve_stock", "create_payment", "process_payment",
+		"cancel_payment", "release_reservation", "release_reservation",
+	})
+	if status := h.orders.status(saga.OrderID); status != model.OrderStatusCancelled {
+		t.Errorf("order status = %s, want %s", status, model.OrderStatusCancelled)
+	}
+	if status := h.products.reservationStatus(saga.ReservationID); status != "released" {
+		t.Errorf("reservation status = %s, want released", status)
+	}
+}
+
+func TestCheckoutSagaRefundsWhenReservationExpiresAtPivot(t *testing.T) {
+	h := newSagaHarness()
+	h.products.onReserve = h.products.expire
+
+	saga, err := h.checkout()
+	if !errors.Is(err, ErrReservationExpired) {
+		t.Fatalf("Checkout error = %v, want ErrReservationExpired", err)
+	}
+	if saga.Status != model.SagaStatusCompensated {
+		t.Errorf("status = %s, want %s", saga.Status, model.SagaStatusCompensated)
+	}
+	assertCalls(t, h.log, []string{
+		"reserve_stock", "create_payment", "process_payment", "commit_reservation",
+		"refund_payment", "cancel_payment", "release_reservation", "release_reservation",
+	})
+	if status := h.orders.status(saga.OrderID); status != model.OrderStatusCancelled {
+		t.Errorf("order status = %s, want %s", status, model.OrderStatusCancelled)
+	}
+
+	if len(h.payments.refunds) != 1 {
+		t.Fatalf("got %d refunds, want 1", len(h.payments.refunds))
+	}
+	if refund := h.payments.refunds[0]; refund.Amount != saga.Pricing.GrandTotal {
+		t.Errorf("refund amount = %v, want %v", refund.Amount, saga.Pricing.GrandTotal)
+	}
+	// payment service 只接受服務 token 退款
+	if token := h.payments.refundTokens[0]; token == "" || token == testUserToken {
+		t.Errorf("refund sent with token %q, want a service token", token)
+	}
+}
+
+func TestCheckoutSagaRetriesStepsAfterPivot(t *testing.T) {
+	h := newSagaHarness()
+	h.cart.removeErr = errors.New("firebase unavailable")
+
+	saga, err := h.checkout()
+	if err == nil {
+		t.Fatal("Checkout succeeded, want the clear cart error")
+	}
+	// 確認訂單後的步驟不補償，保留 running 由背景任務續跑
+	if saga.Status != model.SagaStatusRunning {
+		t.Errorf("status = %s, want %s", saga.Status, model.SagaStatusRunning)
+	}
+	if saga.CurrentStep != model.SagaStepClearCart {
+		t.Errorf("current step = %s, want %s", saga.CurrentStep, model.SagaStepClearCart)
+	}
+	assertCalls(t, h.log, []string{
+		"reserve_stock", "create_payment", "process_payment", "commit_reservation",
+		"remove_cart_items", "remove_cart_items",
+	})
+	if status := h.orders.status(saga.OrderID); status != model.OrderStatusPaid {
+		t.Errorf("order status = %s, want %s", status, model.OrderStatusPaid)
+	}
+}
+
+func TestCheckoutSagaStopsWhenLeaseTakenOver(t *testing.T) {
+	h := newSagaHarness()
+	h.products.onReserve = func(string) { h.sagas.takeOver("other-worker") }
+
+	_, err := h.checkout()
+	if !errors.Is(err, repository.ErrSagaLeaseLost) {
+		t.Fatalf("Checkout error = %v, want ErrSagaLeaseLost", err)
+	}
+	// 失去租約後不再執行後續步驟或補償，也不覆寫接手者的狀態
+	assertCalls(t, h.log, []string{"reserve_stock"})
+	stored := h.sagas.only()
+	if stored.LeaseOwner != "other-worker" {
+		t.Errorf("lease owner = %q, want other-worker", stored.LeaseOwner)
+	}
+	if stored.Status != model.SagaStatusRunning || len(stored.CompletedSteps) != 0 {
+		t.Errorf("stored saga = %s with steps %v, want untouched running saga", stored.Status, stored.CompletedSteps)
+	}
+}
+
+func TestResumeSagas(t *testing.T) {
+	stale := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+	past := time.Now().Add(-time.Minute)
+
+	for _, tc := range []struct {
+		name        string
+		updatedAt   time.Time
+		leaseOwner  string
+		leaseExpiry *time.Time
+		attempts    int
+		wantResumed int
+		wantStatus  model.SagaStatus
+	}{
+		{name: "recently updated", updatedAt: time.Now(), wantResumed: 0, wantStatus: model.SagaStatusRunning},
+		{name: "lease held by another worker", updatedAt: stale, leaseOwner: "other-worker", leaseExpiry: &future, wantResumed: 0, wantStatus: model.SagaStatusRunning},
+		{name: "lease expired", updatedAt: stale, leaseOwner: "crashed-worker", leaseExpiry: &past, wantResumed: 1, wantStatus: model.SagaStatusCompleted},
+		{name: "attempts exhausted before pivot", updatedAt: stale, attempts: 5, wantResumed: 1, wantStatus: model.SagaStatusCompensated},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			h := newSagaHarness()
+			h.sagas.sagas["saga-1"] = model.CheckoutSaga{
+				ID:             "saga-1",
+				UserID:         testUserID,
+				Status:         model.SagaStatusRunning,
+				Items:          []model.OrderItem{{ProductID: "p1", Name: "咖啡豆", Price: 300, Quantity: 1, TotalPrice: 300}},
+				ShippingInfo:   model.ShippingInfo{RecipientName: "王小明", ShippingMethod: "standard"},
+				PaymentMethod:  "credit_card",
+				Currency:       "TWD",
+				OrderID:        "order-1",
+				Attempts:       tc.attempts,
+				LeaseOwner:     tc.leaseOwner,
+				LeaseExpiresAt: tc.leaseExpiry,
+				UpdatedAt:      tc.updatedAt,
+			}
+
+			resumed, err := h.service.ResumeSagas(context.Background())
+			if err != nil {
+				t.Fatalf("ResumeSagas: %v", err)
+			}
+			if resumed != tc.wantResumed {
+				t.Errorf("resumed = %d, want %d", resumed, tc.wantResumed)
+			}
+			stored := h.sagas.only()
+			if stored.Status != tc.wantStatus {
+				t.Errorf("status = %s, want %s", stored.Status, tc.wantStatus)
+			}
+			if tc.wantResumed == 0 {
+				if calls := h.log.list(); len(calls) != 0 {
+					t.Errorf("skipped saga made calls %v", calls)
+				}
+				if stored.LeaseOwner != tc.leaseOwner {
+					t.Errorf("lease owner = %q, want %q", stored.LeaseOwner, tc.leaseOwner)
+				}
+			} else if stored.LeaseOwner != "" {
+				t.Errorf("lease owner = %q, want released", stored.LeaseOwner)
+			}
+		})
+	}
+}
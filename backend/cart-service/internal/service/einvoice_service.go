@@ -0,0 +1,214 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/einvoice"
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/model"
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/repository"
+)
+
+var (
+	ErrInvalidEInvoiceInfo  = model.ErrInvalidEInvoiceInfo
+	ErrInvalidEInvoiceRange = errors.New("invalid e-invoice number range")
+	ErrEInvoiceDisabled     = errors.New("e-invoice issuing is disabled")
+	ErrEInvoiceExhausted    = repository.ErrEInvoiceExhausted
+	ErrEInvoicePending      = errors.New("e-invoice is being issued")
+)
+
+// EInvoiceService 電子發票：管理字軌號碼區間，為已付款訂單配號並經由開立平台開立
+type EInvoiceService interface {
+	IssueForOrder(ctx context.Context, orderID string) (*model.EInvoice, error)
+	AddNumberRange(ctx context.Context, req *model.AddEInvoiceRangeRequest) (*model.EInvoiceNumberRange, error)
+	ListNumberRanges(ctx context.Context, period string) ([]model.EInvoiceNumberRange, error)
+}
+
+// EInvoiceServiceConfig 電子發票服務配置
+type EInvoiceServiceConfig struct {
+	SellerTaxID string // 賣方統一編號
+}
+
+type eInvoiceService struct {
+	rangeRepo repository.EInvoiceRangeRepository
+	orderRepo repository.OrderRepository
+	issuer    einvoice.Issuer
+	config    *EInvoiceServiceConfig
+}
+
+// NewEInvoiceService 創建電子發票服務實例，issuer 為 nil 時停用開立
+func NewEInvoiceService(rangeRepo repository.EInvoiceRangeRepository, orderRepo repository.OrderRepository, issuer einvoice.Issuer, config *EInvoiceServiceConfig) EInvoiceService {
+	return &eInvoiceService{
+		rangeRepo: rangeRepo,
+		orderRepo: orderRepo,
+		issuer:    issuer,
+		config:    config,
+	}
+}
+
+// IssueForOrder 為已付款訂單開立電子發票，已開立時返回原發票；
+// 先以 transaction 佔用訂單再配號，確保只有一個請求配號與上傳；號碼先寫入訂單再上傳，
+// 上傳失敗重試時沿用同一號碼，配號後未能寫入訂單的號碼記錄為作廢；子訂單改為開立父訂單的發票
+func (s *eInvoiceService) IssueForOrder(ctx context.Context, orderID string) (*model.EInvoice, error) {
+	if s.issuer == nil {
+		return nil, ErrEInvoiceDisabled
+	}
+	order, err := s.orderRepo.GetByID(ctx, orderID)
+	if err != nil || order.ID == "" {
+		return nil, ErrOrderNotFound
+	}
+	if order.ParentOrderID != "" {
+		return s.IssueForOrder(ctx, order.ParentOrderID)
+	}
+	switch order.Status {
+	case model.OrderStatusPaid, model.OrderStatusShipped, model.OrderStatusDelivered, model.OrderStatusRefunded:
+	default:
+		return nil, ErrInvoiceNotAvailable
+	}
+	if order.EInvoice != nil && order.EInvoice.Status == model.EInvoiceStatusIssued {
+		return order.EInvoice, nil
+	}
+
+	owner := uuid.New().String()
+	einv, err := s.orderRepo.ClaimEInvoice(ctx, orderID, owner)
+	if err != nil {
+		if err == repository.ErrEInvoiceClaimHeld {
+			return nil, ErrEInvoicePending
+		}
+		return nil, err
+	}
+	if einv.Status == model.EInvoiceStatusIssued {
+		return einv, nil
+	}
+
+	if einv.Number == "" {
+		einv, err = s.assign(ctx, orderID, owner)
+		if err != nil {
+			s.release(ctx, orderID, owner)
+			return nil, err
+		}
+	}
+
+	// 舊訂單沒有開立方式，開立個人發票並存入會員載具
+	if order.EInvoiceInfo == nil {
+		order.EInvoiceInfo = model.DefaultEInvoiceInfo()
+	}
+	issuedAt := time.Now()
+	if err := s.issuer.Issue(ctx, &einvoice.IssueRequest{
+		Number:      einv.Number,
+		RandomCode:  einv.RandomCode,
+		IssuedAt:    issuedAt,
+		SellerTaxID: s.config.SellerTaxID,
+		Order:       order,
+	}); err != nil {
+		s.release(ctx, orderID, owner)
+		return nil, fmt.Errorf("failed to issue e-invoice %s: %w", einv.Number, err)
+	}
+
+	einv.Status = model.EInvoiceStatusIssued
+	einv.Issuer = s.issuer.Name()
+	einv.IssuedAt = &issuedAt
+	if err := s.orderRepo.SaveEInvoice(ctx, orderID, owner, einv); err != nil {
+		if err == repository.ErrEInvoiceClaimLost {
+			return nil, ErrEInvoicePending
+		}
+		return nil, err
+	}
+	einv.ClaimedBy = ""
+	einv.ClaimedAt = nil
+	return einv, nil
+}
+
+// assign 為已佔用的訂單配號並寫入訂單，寫入失敗時將號碼記錄為作廢
+func (s *eInvoiceService) assign(ctx context.Context, orderID, owner string) (*model.EInvoice, error) {
+	period := model.EInvoicePeriod(time.Now())
+	number, err := s.rangeRepo.Allocate(ctx, period)
+	if err != nil {
+		return nil, err
+	}
+	code, err := randomCode()
+	if err == nil {
+		var einv *model.EInvoice
+		einv, err = s.orderRepo.AssignEInvoice(ctx, orderID, owner, &model.EInvoice{
+			Number:     number,
+			RandomCode: code,
+			Period:     period,
+			Status:     model.EInvoiceStatusAllocated,
+		})
+		if err == nil {
+			return einv, nil
+		}
+	}
+
+	if voidErr := s.rangeRepo.Void(context.WithoutCancel(ctx), &model.EInvoiceVoid{
+		Number:  number,
+		Period:  period,
+		OrderID: orderID,
+		Reason:  err.Error(),
+	}); voidErr != nil {
+		log.Printf("Failed to void unused e-invoice number %s for order %s: %v", number, orderID, voidErr)
+	}
+	if err == repository.ErrEInvoiceClaimLost {
+		return nil, ErrEInvoicePending
+	}
+	return nil, err
+}
+
+// release 解除開立失敗的佔用，失敗時僅記錄，佔用逾時後可由其他請求接手
+func (s *eInvoiceService) release(ctx context.Context, orderID, owner string) {
+	if err := s.orderRepo.ReleaseEInvoice(context.WithoutCancel(ctx), orderID, owner); err != nil {
+		log.Printf("Failed to release e-invoice claim of order %s: %v", orderID, err)
+	}
+}
+
+// AddNumberRange 新增財政部配發的字軌號碼區間，同期別同字軌的區間不可重疊
+func (s *eInvoiceService) AddNumberRange(ctx context.Context, req *model.AddEInvoiceRangeRequest) (*model.EInvoiceNumberRange, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidEInvoiceRange, err)
+	}
+	existing, err := s.rangeRepo.ListByPeriod(ctx, req.Period)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range existing {
+		if r.Track == req.Track && req.Start <= r.End && r.Start <= req.End {
+			return nil, fmt.Errorf("%w: overlaps %s%08d-%08d", ErrInvalidEInvoiceRange, r.Track, r.Start, r.End)
+		}
+	}
+
+	numberRange := &model.EInvoiceNumberRange{
+		ID:     uuid.New().String(),
+		Period: req.Period,
+		Track:  req.Track,
+		Start:  req.Start,
+		End:    req.End,
+		Next:   req.Start,
+	}
+	if err := s.rangeRepo.Create(ctx, numberRange); err != nil {
+		return nil, err
+	}
+	return numberRange, nil
+}
+
+// ListNumberRanges 獲取期別的號碼區間，period 空白時為目前期別
+func (s *eInvoiceService) ListNumberRanges(ctx context.Context, period string) ([]model.EInvoiceNumberRange, error) {
+	if period == "" {
+		period = model.EInvoicePeriod(time.Now())
+	}
+	return s.rangeRepo.ListByPeriod(ctx, period)
+}
+
+// randomCode 產生四位數隨機碼
+func randomCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(10000))
+	if err != nil {
+		return "", fmt.Errorf("failed to generate random code: %w", err)
+	}
+	return fmt.Sprintf("%04d", n.Int64()), nil
+}
@@ -0,0 +1,192 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/client"
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/export"
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/model"
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/repository"
+)
+
+// ExportService 訂單匯出服務接口
+type ExportService interface {
+	ExportOrders(ctx context.Context, query *model.OrderQuery, format export.Format, w io.Writer) (int, error)
+	ExportToDir(ctx context.Context, from, to time.Time) (string, int, error)
+}
+
+// ExportServiceConfig 訂單匯出配置
+type ExportServiceConfig struct {
+	Dir           string        // 排程匯出的輸出目錄
+	Format        export.Format // 排程匯出的檔案格式
+	PageSize      int           // 每次從資料庫讀取的訂單數
+	ServiceSecret string        // 排程匯出查詢支付時簽發服務 token
+}
+
+type exportService struct {
+	orderRepo     repository.OrderRepository
+	paymentClient client.PaymentClient
+	config        *ExportServiceConfig
+}
+
+// NewExportService 創建訂單匯出服務實例
+func NewExportService(orderRepo repository.OrderRepository, paymentClient client.PaymentClient, config *ExportServiceConfig) ExportService {
+	return &exportService{
+		orderRepo:     orderRepo,
+		paymentClient: paymentClient,
+		config:        config,
+	}
+}
+
+// exportHeader 匯出欄位，每列為一項訂單商品，訂單金額與支付欄位在同一訂單的各列重複
+var exportHeader = []interface{}{
+	"orderId", "createdAt", "status", "userId", "subOrderId", "sellerId", "warehouseId",
+	"productId", "productName", "unitPrice", "quantity", "lineTotal",
+	"subtotal", "discount", "shippingFee", "tax", "orderTotal",
+	"paymentId", "paymentStatus", "paymentMethod", "currency", "paidAmount", "refundedAmount",
+}
+
+// ExportOrders 依查詢條件分頁讀取訂單並逐頁寫出，不會一次載入整個範圍；
+// 拆單的子訂單以父訂單的商品列呈現，返回匯出的訂單數
+func (s *exportService) ExportOrders(ctx context.Context, query *model.OrderQuery, format export.Format, w io.Writer) (int, error) {
+	rw, err := export.NewRowWriter(format, w)
+	if err != nil {
+		return 0, err
+	}
+	if err := rw.WriteRow(exportHeader...); err != nil {
+		return 0, err
+	}
+
+	q := *query
+	q.Limit = s.config.PageSize
+	q.After = nil
+	count := 0
+	for {
+		orders, next, err := s.orderRepo.Query(ctx, &q)
+		if err != nil {
+			return count, err
+		}
+		payments := s.payments(ctx, orders)
+		for i := range orders {
+			order := &orders[i]
+			if order.ParentOrderID != "" {
+				continue
+			}
+			payment := payments[order.ID]
+			if payment == nil {
+				payment = &client.PaymentInfo{}
+			}
+			if err := s.writeOrder(rw, order, payment); err != nil {
+				return count, err
+			}
+			count++
+		}
+		if err := rw.Flush(); err != nil {
+			return count, err
+		}
+		if next == nil {
+			break
+		}
+		q.After = next
+	}
+	return count, rw.Close()
+}
+
+// payments 以一次批次查詢獲取該頁父訂單的支付，查詢失敗時支付欄位留白
+func (s *exportService) payments(ctx context.Context, orders []model.Order) map[string]*client.PaymentInfo {
+	orderIDs := make([]string, 0, len(orders))
+	for i := range orders {
+		if orders[i].ParentOrderID == "" {
+			orderIDs = append(orderIDs, orders[i].ID)
+		}
+	}
+	if len(orderIDs) == 0 {
+		return nil
+	}
+	payments, err := s.paymentClient.GetPaymentsByOrderIDs(ctx, orderIDs)
+	if err != nil {
+		log.Printf("Failed to get payments for %d orders: %v", len(orderIDs), err)
+		return nil
+	}
+	return payments
+}
+
+// writeOrder 寫出訂單的每項商品
+func (s *exportService) writeOrder(rw export.RowWriter, order *model.Order, payment *client.PaymentInfo) error {
+	pricing := order.Pricing
+	if pricing == nil {
+		pricing = &model.PriceBreakdown{Subtotal: order.TotalAmount, GrandTotal: order.TotalAmount}
+	}
+
+	// 依出貨分組找出商品所屬的子訂單
+	subOrders := make(map[string]string, len(order.SubOrders))
+	for _, sub := range order.SubOrders {
+		subOrders[sub.SellerID+"/"+sub.WarehouseID] = sub.OrderID
+	}
+
+	for i := range order.Items {
+		item := &order.Items[i]
+		total := item.TotalPrice
+		if total == 0 {
+			total = item.Price * float64(item.Quantity)
+		}
+		if err := rw.WriteRow(
+			order.ID, order.CreatedAt, string(order.Status), order.UserID,
+			subOrders[item.FulfillmentGroup()], item.SellerID, item.WarehouseID,
+			item.ProductID, item.Name, item.Price, item.Quantity, total,
+			pricing.Subtotal, pricing.Discount, pricing.ShippingFee, pricing.Tax, order.TotalAmount,
+			payment.ID, payment.Status, payment.Method, payment.Currency, payment.Amount, payment.RefundedAmount,
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ExportToDir 匯出建立時間在 [from, to) 的訂單至輸出目錄，先寫入暫存檔完成後再改名，
+// 返回檔案路徑與匯出的訂單數
+func (s *exportService) ExportToDir(ctx context.Context, from, to time.Time) (string, int, error) {
+	if err := os.MkdirAll(s.config.Dir, 0o755); err != nil {
+		return "", 0, fmt.Errorf("error creating export directory: %v", err)
+	}
+
+	// 背景執行時沒有用戶 token，以服務 token 查詢支付
+	if token, _ := ctx.Value(client.TokenKey).(string); token == "" {
+		token, err := client.SignServiceToken(s.config.ServiceSecret, "cart-service")
+		if err != nil {
+			return "", 0, fmt.Errorf("sign service token failed: %w", err)
+		}
+		ctx = context.WithValue(ctx, client.TokenKey, token)
+	}
+
+	name := fmt.Sprintf("orders-%s-%s.%s", from.UTC().Format("20060102T150405Z"), to.UTC().Format("20060102T150405Z"), s.config.Format)
+	path := filepath.Join(s.config.Dir, name)
+	file, err := os.CreateTemp(s.config.Dir, name+".*.tmp")
+	if err != nil {
+		return "", 0, fmt.Errorf("error creating export file: %v", err)
+	}
+	defer os.Remove(file.Name())
+
+	end := to.Add(-time.Millisecond)
+	count, err := s.ExportOrders(ctx, &model.OrderQuery{
+		CreatedFrom: &from,
+		CreatedTo:   &end,
+		Ascending:   true,
+	}, s.config.Format, file)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", count, err
+	}
+	if err := os.Rename(file.Name(), path); err != nil {
+		return "", count, fmt.Errorf("error saving export file: %v", err)
+	}
+	return path, count, nil
+}
@@ -0,0 +1,439 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/client"
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/model"
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/repository"
+)
+
+// callLog 依序記錄模擬依賴收到的呼叫，用於檢查步驟與補償的順序
+type callLog struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (l *callLog) add(call string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.calls = append(l.calls, call)
+}
+
+func (l *callLog) list() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]string(nil), l.calls...)
+}
+
+// fakeSagaRepo 以記憶體實作 CheckoutSagaRepository，租約規則與 Firebase 版本相同
+type fakeSagaRepo struct {
+	mu    sync.Mutex
+	sagas map[string]model.CheckoutSaga
+}
+
+func newFakeSagaRepo() *fakeSagaRepo {
+	return &fakeSagaRepo{sagas: make(map[string]model.CheckoutSaga)}
+}
+
+func (r *fakeSagaRepo) Save(ctx context.Context, saga *model.CheckoutSaga) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	saga.UpdatedAt = time.Now()
+	if current, ok := r.sagas[saga.ID]; ok && current.LeaseOwner != saga.LeaseOwner {
+		return repository.ErrSagaLeaseLost
+	}
+	r.sagas[saga.ID] = cloneSaga(saga)
+	return nil
+}
+
+func (r *fakeSagaRepo) GetByID(ctx context.Context, id string) (*model.CheckoutSaga, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	saga, ok := r.sagas[id]
+	if !ok {
+		return nil, nil
+	}
+	saga = cloneSaga(&saga)
+	return &saga, nil
+}
+
+func (r *fakeSagaRepo) ListByStatus(ctx context.Context, status model.SagaStatus) ([]model.CheckoutSaga, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var result []model.CheckoutSaga
+	for _, saga := range r.sagas {
+		if saga.Status == status {
+			result = append(result, cloneSaga(&saga))
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	return result, nil
+}
+
+func (r *fakeSagaRepo) Claim(ctx context.Context, id, owner string, until time.Time) (*model.CheckoutSaga, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	saga, ok := r.sagas[id]
+	if !ok {
+		return nil, repository.ErrSagaNotFound
+	}
+	if saga.LeaseOwner != "" && saga.LeaseOwner != owner && saga.LeaseExpiresAt != nil && time.Now().Before(*saga.LeaseExpiresAt) {
+		return nil, repository.ErrSagaLeaseHeld
+	}
+	saga.LeaseOwner = owner
+	saga.LeaseExpiresAt = &until
+	r.sagas[id] = saga
+	saga = cloneSaga(&saga)
+	return &saga, nil
+}
+
+func (r *fakeSagaRepo) Release(ctx context.Context, id, owner string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	saga, ok := r.sagas[id]
+	if !ok || saga.LeaseOwner != owner {
+		return repository.ErrSagaLeaseLost
+	}
+	saga.LeaseOwner = ""
+	saga.LeaseExpiresAt = nil
+	r.sagas[id] = saga
+	return nil
+}
+
+// only 返回唯一的流程，供不知道流程ID的測試使用
+func (r *fakeSagaRepo) only() model.CheckoutSaga {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, saga := range r.sagas {
+		return cloneSaga(&saga)
+	}
+	return model.CheckoutSaga{}
+}
+
+// takeOver 模擬其他執行者接手流程的租約
+func (r *fakeSagaRepo) takeOver(owner string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, saga := range r.sagas {
+		until := time.Now().Add(time.Hour)
+		saga.LeaseOwner = owner
+		saga.LeaseExpiresAt = &until
+		r.sagas[id] = saga
+	}
+}
+
+func cloneSaga(saga *model.CheckoutSaga) model.CheckoutSaga {
+	clone := *saga
+	clone.CompletedSteps = append([]model.SagaStep(nil), saga.CompletedSteps...)
+	return clone
+}
+
+// fakeOrderRepo 以記憶體實作測試用到的訂單存取，未實作的方法呼叫時 panic
+type fakeOrderRepo struct {
+	repository.OrderRepository
+
+	mu     sync.Mutex
+	orders map[string]model.Order
+	// beforeUpdate 於狀態比對前呼叫，可模擬其他請求同時變更狀態
+	beforeUpdate func(orderID string)
+}
+
+func newFakeOrderRepo(orders ...model.Order) *fakeOrderRepo {
+	r := &fakeOrderRepo{orders: make(map[string]model.Order)}
+	for _, order := range orders {
+		r.orders[order.ID] = order
+	}
+	return r
+}
+
+func (r *fakeOrderRepo) Create(ctx context.Context, order *model.Order, children ...model.Order) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.orders[order.ID] = *order
+	for _, child := range children {
+		r.orders[child.ID] = child
+	}
+	return nil
+}
+
+func (r *fakeOrderRepo) GetByID(ctx context.Context, orderID string) (*model.Order, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	order := r.orders[orderID]
+	return &order, nil
+}
+
+func (r *fakeOrderRepo) UpdateStatus(ctx context.Context, orderID string, change *model.OrderStatusChange) (*model.Order, error) {
+	if r.beforeUpdate != nil {
+		r.beforeUpdate(orderID)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	order, ok := r.orders[orderID]
+	if !ok {
+		return nil, repository.ErrOrderNotFound
+	}
+	if order.Status != change.From {
+		return nil, repository.ErrOrderStatusStale
+	}
+	order.Status = change.To
+	order.StatusHistory = append(order.StatusHistory, *change)
+	r.orders[orderID] = order
+	return &order, nil
+}
+
+func (r *fakeOrderRepo) setStatus(orderID string, status model.OrderStatus) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	order := r.orders[orderID]
+	order.Status = status
+	r.orders[orderID] = order
+}
+
+func (r *fakeOrderRepo) status(orderID string) model.OrderStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.orders[orderID].Status
+}
+
+// fakeCartRepo 只提供結帳讀取購物車
+type fakeCartRepo struct {
+	repository.CartRepository
+	cart model.Cart
+}
+
+func (r *fakeCartRepo) GetCart(ctx context.Context, userID string) (*model.Cart, error) {
+	cart := r.cart
+	return &cart, nil
+}
+
+// fakeCartService 記錄結帳後清除的商品
+type fakeCartService struct {
+	CartService
+	log *callLog
+	// removeErr 不為 nil 時清除購物車失敗
+	removeErr error
+}
+
+func (s *fakeCartService) RemoveItems(ctx context.Context, userID string, productIDs []string) error {
+	s.log.add("remove_cart_items")
+	return s.removeErr
+}
+
+// fakeProductClient 模擬 product service 的商品查詢與庫存預留
+type fakeProductClient struct {
+	client.ProductClient
+	log      *callLog
+	products map[string]*client.ProductInfo
+
+	mu           sync.Mutex
+	reservations map[string]string // 預留ID → pending、committed、released 或 expired
+	reserveErr   error
+	// onReserve 預留成功後呼叫
+	onReserve func(reservationID string)
+}
+
+func newFakeProductClient(log *callLog, products ...*client.ProductInfo) *fakeProductClient {
+	c := &fakeProductClient{
+		log:          log,
+		products:     make(map[string]*client.ProductInfo),
+		reservations: make(map[string]string),
+	}
+	for _, product := range products {
+		c.products[product.ID] = product
+	}
+	return c
+}
+
+func (c *fakeProductClient) GetProducts(ctx context.Context, productIDs []string) (map[string]*client.ProductInfo, error) {
+	result := make(map[string]*client.ProductInfo, len(productIDs))
+	for _, id := range productIDs {
+		if product, ok := c.products[id]; ok {
+			result[id] = product
+		}
+	}
+	return result, nil
+}
+
+func (c *fakeProductClient) ReserveStock(ctx context.Context, req *client.ReserveStockRequest) (*client.StockReservation, error) {
+	c.log.add("reserve_stock")
+	if c.reserveErr != nil {
+		return nil, c.reserveErr
+	}
+	c.mu.Lock()
+	id := "reservation-" + req.OrderID
+	c.reservations[id] = "pending"
+	c.mu.Unlock()
+	if c.onReserve != nil {
+		c.onReserve(id)
+	}
+	return &client.StockReservation{ID: id, OrderID: req.OrderID, Items: req.Items, Status: "pending", ExpiresAt: time.Now().Add(time.Hour)}, nil
+}
+
+func (c *fakeProductClient) CommitReservation(ctx context.Context, reservationID string) (*client.StockReservation, error) {
+	c.log.add("commit_reservation")
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch c.reservations[reservationID] {
+	case "pending", "committed":
+		c.reservations[reservationID] = "committed"
+		return &client.StockReservation{ID: reservationID, Status: "committed"}, nil
+	}
+	return nil, client.ErrReservationClosed
+}
+
+func (c *fakeProductClient) ReleaseReservation(ctx context.Context, reservationID string) (*client.StockReservation, error) {
+	c.log.add("release_reservation")
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch c.reservations[reservationID] {
+	case "pending", "released", "expired":
+		c.reservations[reservationID] = "released"
+		return &client.StockReservation{ID: reservationID, Status: "released"}, nil
+	}
+	return nil, client.ErrReservationClosed
+}
+
+func (c *fakeProductClient) RestockProduct(ctx context.Context, productID string, quantity int) error {
+	c.log.add("restock " + productID)
+	return nil
+}
+
+func (c *fakeProductClient) expire(reservationID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.reservations[reservationID] = "expired"
+}
+
+func (c *fakeProductClient) reservationStatus(reservationID string) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.reservations[reservationID]
+}
+
+// fakePaymentClient 模擬 payment service，processResult 決定處理後的支付狀態
+type fakePaymentClient struct {
+	client.PaymentClient
+	log *callLog
+
+	mu            sync.Mutex
+	payments      map[string]*client.PaymentInfo
+	processResult string
+	refunds       []client.RefundPaymentRequest
+	refundTokens  []string // 退款請求帶的 token
+}
+
+func newFakePaymentClient(log *callLog) *fakePaymentClient {
+	return &fakePaymentClient{
+		log:           log,
+		payments:      make(map[string]*client.PaymentInfo),
+		processResult: client.PaymentStatusSuccess,
+	}
+}
+
+func (c *fakePaymentClient) CreatePayment(ctx context.Context, req *client.CreatePaymentRequest) (*client.PaymentInfo, error) {
+	c.log.add("create_payment")
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	payment := &client.PaymentInfo{
+		ID:       "payment-" + req.OrderID,
+		OrderID:  req.OrderID,
+		Amount:   req.Amount,
+		Currency: req.Currency,
+		Method:   req.Method,
+		Status:   client.PaymentStatusPending,
+	}
+	c.payments[payment.ID] = payment
+	clone := *payment
+	return &clone, nil
+}
+
+func (c *fakePaymentClient) GetPayment(ctx context.Context, paymentID string) (*client.PaymentInfo, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	payment, ok := c.payments[paymentID]
+	if !ok {
+		return nil, client.ErrPaymentNotFound
+	}
+	clone := *payment
+	return &clone, nil
+}
+
+func (c *fakePaymentClient) GetPaymentByOrderID(ctx context.Context, orderID string) (*client.PaymentInfo, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, payment := range c.payments {
+		if payment.OrderID == orderID {
+			clone := *payment
+			return &clone, nil
+		}
+	}
+	return nil, client.ErrPaymentNotFound
+}
+
+func (c *fakePaymentClient) ProcessPayment(ctx context.Context, paymentID string) error {
+	c.log.add("process_payment")
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	payment, ok := c.payments[paymentID]
+	if !ok {
+		return client.ErrPaymentNotFound
+	}
+	if payment.Status != client.PaymentStatusPending {
+		return client.ErrInvalidPaymentStatus
+	}
+	payment.Status = c.processResult
+	return nil
+}
+
+func (c *fakePaymentClient) CancelPayment(ctx context.Context, paymentID string) error {
+	c.log.add("cancel_payment")
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	payment, ok := c.payments[paymentID]
+	if !ok {
+		return client.ErrPaymentNotFound
+	}
+	if payment.Status != client.PaymentStatusPending {
+		return client.ErrInvalidPaymentStatus
+	}
+	payment.Status = client.PaymentStatusCancelled
+	return nil
+}
+
+func (c *fakePaymentClient) RefundPayment(ctx context.Context, req *client.RefundPaymentRequest) error {
+	c.log.add("refund_payment")
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	payment, ok := c.payments[req.PaymentID]
+	if !ok {
+		return client.ErrPaymentNotFound
+	}
+	payment.Status = client.PaymentStatusRefunded
+	payment.RefundedAmount += req.Amount
+	c.refunds = append(c.refunds, *req)
+	token, _ := ctx.Value(client.TokenKey).(string)
+	c.refundTokens = append(c.refundTokens, token)
+	return nil
+}
+
+// fakeAnalyticsService 忽略所有統計
+type fakeAnalyticsService struct {
+	AnalyticsService
+}
+
+func (fakeAnalyticsService) RecordTransition(ctx context.Context, order *model.Order, from, to model.OrderStatus) {
+}
+
+// fakeEInvoiceService 模擬未啟用電子發票
+type fakeEInvoiceService struct {
+	EInvoiceService
+}
+
+func (fakeEInvoiceService) IssueForOrder(ctx context.Context, orderID string) (*model.EInvoice, error) {
+	return nil, ErrEInvoiceDisabled
+}
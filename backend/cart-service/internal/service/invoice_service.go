@@ -0,0 +1,219 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/client"
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/invoice"
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/model"
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/repository"
+)
+
+var ErrInvoiceNotAvailable = errors.New("invoice not available")
+
+// 發票號碼前綴，號碼格式為 {前綴}-{年度}-{六位序號}
+const (
+	invoiceNumberPrefix    = "INV"
+	creditNoteNumberPrefix = "CN"
+)
+
+// InvoiceService 發票與折讓單：首次下載時開立並連續編號，之後返回同一份快照
+type InvoiceService interface {
+	GetInvoice(ctx context.Context, userID, orderID string) (*model.Invoice, error)
+	GetCreditNote(ctx context.Context, userID, orderID, returnID string) (*model.Invoice, error)
+	IssueInvoice(ctx context.Context, orderID string) (*model.Invoice, error)
+	IssueCreditNote(ctx context.Context, returnID string) (*model.Invoice, error)
+	Render(w io.Writer, inv *model.Invoice) error
+}
+
+type invoiceService struct {
+	invoiceRepo   repository.InvoiceRepository
+	orderRepo     repository.OrderRepository
+	returnRepo    repository.ReturnRepository
+	paymentClient client.PaymentClient
+	renderer      *invoice.Renderer
+}
+
+// NewInvoiceService 創建發票服務實例
+func NewInvoiceService(invoiceRepo repository.InvoiceRepository, orderRepo repository.OrderRepository, returnRepo repository.ReturnRepository, paymentClient client.PaymentClient, renderer *invoice.Renderer) InvoiceService {
+	return &invoiceService{
+		invoiceRepo:   invoiceRepo,
+		orderRepo:     orderRepo,
+		returnRepo:    returnRepo,
+		paymentClient: paymentClient,
+		renderer:      renderer,
+	}
+}
+
+// GetInvoice 顧客獲取自己訂單的發票
+func (s *invoiceService) GetInvoice(ctx context.Context, userID, orderID string) (*model.Invoice, error) {
+	order, err := s.orderRepo.GetByID(ctx, orderID)
+	if err != nil || order.ID == "" {
+		return nil, ErrOrderNotFound
+	}
+	if order.UserID != userID {
+		return nil, ErrOrderNotOwned
+	}
+	return s.IssueInvoice(ctx, orderID)
+}
+
+// GetCreditNote 顧客獲取自己訂單退貨的折讓單
+func (s *invoiceService) GetCreditNote(ctx context.Context, userID, orderID, returnID string) (*model.Invoice, error) {
+	ret, err := s.returnRepo.GetByID(ctx, returnID)
+	if err != nil {
+		return nil, err
+	}
+	if ret == nil || ret.OrderID != orderID {
+		return nil, ErrReturnNotFound
+	}
+	if ret.UserID != userID {
+		return nil, ErrOrderNotOwned
+	}
+	return s.IssueCreditNote(ctx, returnID)
+}
+
+// IssueInvoice 開立已付款訂單的發票，已開立時返回原發票；子訂單返回父訂單的發票
+func (s *invoiceService) IssueInvoice(ctx context.Context, orderID string) (*model.Invoice, error) {
+	id := "invoice-" + orderID
+	if inv, err := s.issued(ctx, id); inv != nil || err != nil {
+		return inv, err
+	}
+
+	order, err := s.orderRepo.GetByID(ctx, orderID)
+	if err != nil || order.ID == "" {
+		return nil, ErrOrderNotFound
+	}
+	if order.ParentOrderID != "" {
+		// 拆單時一次付款，發票隨父訂單開立
+		return s.IssueInvoice(ctx, order.ParentOrderID)
+	}
+	switch order.Status {
+	case model.OrderStatusPaid, model.OrderStatusShipped, model.OrderStatusDelivered, model.OrderStatusRefunded:
+	default:
+		return nil, ErrInvoiceNotAvailable
+	}
+	payment, err := s.payment(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := make([]model.InvoiceLine, 0, len(order.Items))
+	for _, item := range order.Items {
+		total := item.TotalPrice
+		if total == 0 {
+			total = item.Price * float64(item.Quantity)
+		}
+		lines = append(lines, model.InvoiceLine{
+			ProductID: item.ProductID,
+			Name:      item.Name,
+			Price:     item.Price,
+			Quantity:  item.Quantity,
+			Total:     total,
+		})
+	}
+	return s.issue(ctx, &model.Invoice{
+		ID:            id,
+		Type:          model.InvoiceTypeInvoice,
+		OrderID:       order.ID,
+		UserID:        order.UserID,
+		Lines:         lines,
+		Pricing:       order.Pricing,
+		Amount:        order.TotalAmount,
+		Currency:      payment.Currency,
+		ShippingInfo:  order.ShippingInfo,
+		PaymentMethod: payment.Method,
+		TransactionID: payment.TransactionID,
+	}, invoiceNumberPrefix)
+}
+
+// IssueCreditNote 為已退款的退貨開立折讓單，引用原訂單發票號碼；已開立時返回原折讓單
+func (s *invoiceService) IssueCreditNote(ctx context.Context, returnID string) (*model.Invoice, error) {
+	id := "credit-" + returnID
+	if inv, err := s.issued(ctx, id); inv != nil || err != nil {
+		return inv, err
+	}
+
+	ret, err := s.returnRepo.GetByID(ctx, returnID)
+	if err != nil {
+		return nil, err
+	}
+	if ret == nil {
+		return nil, ErrReturnNotFound
+	}
+	if ret.Status != model.ReturnStatusRefunded {
+		return nil, ErrInvoiceNotAvailable
+	}
+	original, err := s.IssueInvoice(ctx, ret.OrderID)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := make([]model.InvoiceLine, 0, len(ret.Items))
+	for _, item := range ret.Items {
+		lines = append(lines, model.InvoiceLine{
+			ProductID: item.ProductID,
+			Name:      item.Name,
+			Price:     item.Price,
+			Quantity:  item.Quantity,
+			Total:     item.Price * float64(item.Quantity),
+		})
+	}
+	return s.issue(ctx, &model.Invoice{
+		ID:              id,
+		Type:            model.InvoiceTypeCreditNote,
+		OrderID:         ret.OrderID,
+		UserID:          ret.UserID,
+		ReturnID:        ret.ID,
+		ReferenceNumber: original.Number,
+		Lines:           lines,
+		Amount:          ret.RefundAmount,
+		Currency:        original.Currency,
+		ShippingInfo:    original.ShippingInfo,
+		PaymentMethod:   original.PaymentMethod,
+		TransactionID:   original.TransactionID,
+	}, creditNoteNumberPrefix)
+}
+
+// Render 產生發票 PDF
+func (s *invoiceService) Render(w io.Writer, inv *model.Invoice) error {
+	return s.renderer.Render(w, inv)
+}
+
+// issued 返回已開立的發票，尚未開立或開立中斷（沒有號碼）時返回 nil
+func (s *invoiceService) issued(ctx context.Context, id string) (*model.Invoice, error) {
+	inv, err := s.invoiceRepo.GetByID(ctx, id)
+	if err != nil || inv == nil || inv.Number == "" {
+		return nil, err
+	}
+	return inv, nil
+}
+
+// issue 為發票ID佔用號碼後寫入；號碼的佔用與分配在同一個 transaction 內完成，
+// 並發或重試的開立取得同一個號碼，先寫入者為準
+func (s *invoiceService) issue(ctx context.Context, draft *model.Invoice, prefix string) (*model.Invoice, error) {
+	now := time.Now()
+	series := fmt.Sprintf("%s-%d", prefix, now.Year())
+	seq, err := s.invoiceRepo.Claim(ctx, series, draft.ID)
+	if err != nil {
+		return nil, err
+	}
+	draft.Number = fmt.Sprintf("%s-%06d", series, seq)
+	draft.IssuedAt = now
+	return s.invoiceRepo.Complete(ctx, draft)
+}
+
+// payment 獲取訂單的支付資訊，尚未建立支付時返回 ErrInvoiceNotAvailable
+func (s *invoiceService) payment(ctx context.Context, orderID string) (*client.PaymentInfo, error) {
+	payment, err := s.paymentClient.GetPaymentByOrderID(ctx, orderID)
+	if err != nil {
+		if errors.Is(err, client.ErrPaymentNotFound) {
+			return nil, ErrInvoiceNotAvailable
+		}
+		return nil, fmt.Errorf("failed to get payment: %w", err)
+	}
+	return payment, nil
+}
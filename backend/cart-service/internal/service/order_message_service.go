@@ -0,0 +1,241 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/client"
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/model"
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/repository"
+)
+
+var (
+	ErrInvalidOrderMessage = errors.New("message body or attachments required")
+)
+
+// OrderMessageService 訂單訊息服務接口
+type OrderMessageService interface {
+	GetCustomerThread(ctx context.Context, userID, orderID string) (*model.OrderMessageThread, error)
+	PostCustomerMessage(ctx context.Context, userID, orderID string, req *model.PostOrderMessageRequest) (*model.OrderMessage, error)
+	ListCustomerThreads(ctx context.Context, userID string) ([]model.OrderThread, error)
+	GetStaffThread(ctx context.Context, orderID string) (*model.OrderMessageThread, error)
+	PostStaffMessage(ctx context.Context, staffID, orderID string, req *model.PostOrderMessageRequest) (*model.OrderMessage, error)
+	ListThreadsAwaitingStaff(ctx context.Context) ([]model.OrderThread, error)
+}
+
+// OrderMessageServiceConfig 訂單訊息配置
+type OrderMessageServiceConfig struct {
+	TemplateID    string // 對方回覆時的通知模板ID
+	SupportUserID string // 尚無客服回覆時，顧客訊息通知的客服帳號
+}
+
+type orderMessageService struct {
+	messageRepo        repository.OrderMessageRepository
+	orderRepo          repository.OrderRepository
+	notificationClient client.NotificationClient
+	config             *OrderMessageServiceConfig
+}
+
+// NewOrderMessageService 創建訂單訊息服務實例
+func NewOrderMessageService(messageRepo repository.OrderMessageRepository, orderRepo repository.OrderRepository, notificationClient client.NotificationClient, config *OrderMessageServiceConfig) OrderMessageService {
+	return &orderMessageService{
+		messageRepo:        messageRepo,
+		orderRepo:          orderRepo,
+		notificationClient: notificationClient,
+		config:             config,
+	}
+}
+
+// GetCustomerThread 顧客查看自己訂單的訊息並標示為已讀
+func (s *orderMessageService) GetCustomerThread(ctx context.Context, userID, orderID string) (*model.OrderMessageThread, error) {
+	if _, err := s.customerOrder(ctx, userID, orderID); err != nil {
+		return nil, err
+	}
+	return s.readThread(ctx, orderID, model.OrderMessageRoleCustomer)
+}
+
+// PostCustomerMessage 顧客在自己的訂單留言，通知最後回覆的客服
+func (s *orderMessageService) PostCustomerMessage(ctx context.Context, userID, orderID string, req *model.PostOrderMessageRequest) (*model.OrderMessage, error) {
+	order, err := s.customerOrder(ctx, userID, orderID)
+	if err != nil {
+		return nil, err
+	}
+	return s.post(ctx, order, userID, model.OrderMessageRoleCustomer, req)
+}
+
+// ListCustomerThreads 獲取用戶的訊息串與未讀數
+func (s *orderMessageService) ListCustomerThreads(ctx context.Context, userID string) ([]model.OrderThread, error) {
+	return s.messageRepo.ListThreadsByUserID(ctx, userID)
+}
+
+// GetStaffThread 客服查看訂單訊息並標示為已讀
+func (s *orderMessageService) GetStaffThread(ctx context.Context, orderID string) (*model.OrderMessageThread, error) {
+	order, err := s.orderRepo.GetByID(ctx, orderID)
+	if err != nil || order.ID == "" {
+		return nil, ErrOrderNotFound
+	}
+	return s.readThread(ctx, orderID, model.OrderMessageRoleStaff)
+}
+
+// PostStaffMessage 客服回覆訂單訊息，通知顧客
+func (s *orderMessageService) PostStaffMessage(ctx context.Context, staffID, orderID string, req *model.PostOrderMessageRequest) (*model.OrderMessage, error) {
+	order, err := s.orderRepo.GetByID(ctx, orderID)
+	if err != nil || order.ID == "" {
+		return nil, ErrOrderNotFound
+	}
+	return s.post(ctx, order, staffID, model.OrderMessageRoleStaff, req)
+}
+
+// ListThreadsAwaitingStaff 獲取客服尚有未讀訊息的訊息串
+func (s *orderMessageService) ListThreadsAwaitingStaff(ctx context.Context) ([]model.OrderThread, error) {
+	return s.messageRepo.ListThreadsAwaitingStaff(ctx)
+}
+
+// customerOrder 獲取訂單並驗證所有者
+func (s *orderMessageService) customerOrder(ctx context.Context, userID, orderID string) (*model.Order, error) {
+	order, err := s.orderRepo.GetByID(ctx, orderID)
+	if err != nil || order.ID == "" {
+		return nil, ErrOrderNotFound
+	}
+	if order.UserID != userID {
+		return nil, ErrOrderNotOwned
+	}
+	return order, nil
+}
+
+// readThread 獲取訊息並將 reader 一方的未讀數歸零
+func (s *orderMessageService) readThread(ctx context.Context, orderID string, reader model.OrderMessageRole) (*model.OrderMessageThread, error) {
+	thread, err := s.messageRepo.GetThread(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	messages, err := s.messageRepo.ListMessages(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	if thread != nil && unread(thread, reader) > 0 {
+		updated, err := s.messageRepo.UpdateThread(ctx, orderID, func(t *model.OrderThread) {
+			if reader == model.OrderMessageRoleCustomer {
+				t.CustomerUnread = 0
+			} else {
+				t.StaffUnread = 0
+			}
+		})
+		if err != nil {
+			log.Printf("Failed to mark order thread %s as read: %v", orderID, err)
+		} else {
+			thread = updated
+		}
+	}
+	return &model.OrderMessageThread{Thread: thread, Messages: messages}, nil
+}
+
+// post 新增訊息、遞增對方的未讀數並通知對方，通知失敗不影響留言
+func (s *orderMessageService) post(ctx context.Context, order *model.Order, authorID string, role model.OrderMessageRole, req *model.PostOrderMessageRequest) (*model.OrderMessage, error) {
+	body := strings.TrimSpace(req.Body)
+	if body == "" && len(req.Attachments) == 0 {
+		return nil, ErrInvalidOrderMessage
+	}
+
+	message := &model.OrderMessage{
+		ID:          uuid.New().String(),
+		OrderID:     order.ID,
+		AuthorID:    authorID,
+		Role:        role,
+		Body:        body,
+		Attachments: req.Attachments,
+		CreatedAt:   time.Now(),
+	}
+	if err := s.messageRepo.AddMessage(ctx, message); err != nil {
+		return nil, err
+	}
+
+	thread, err := s.messageRepo.UpdateThread(ctx, order.ID, func(t *model.OrderThread) {
+		t.UserID = order.UserID
+		t.MessageCount++
+		t.LastMessageAt = message.CreatedAt
+		t.LastRole = role
+		if role == model.OrderMessageRoleStaff {
+			t.StaffID = authorID
+			t.CustomerUnread++
+		} else {
+			t.StaffUnread++
+		}
+	})
+	if err != nil {
+		// 訊息已寫入，未讀數下次留言時仍會遞增
+		log.Printf("Failed to update order thread %s: %v", order.ID, err)
+		return message, nil
+	}
+	s.notify(ctx, thread, message)
+	return message, nil
+}
+
+// notify 通知對方有新訊息；顧客留言通知最後回覆的客服，尚無客服回覆時通知客服帳號
+func (s *orderMessageService) notify(ctx context.Context, thread *model.OrderThread, message *model.OrderMessage) {
+	if s.config.TemplateID == "" {
+		return
+	}
+	recipient := thread.UserID
+	if message.Role == model.OrderMessageRoleCustomer {
+		recipient = thread.StaffID
+		if recipient == "" {
+			recipient = s.config.SupportUserID
+		}
+	}
+	if recipient == "" {
+		return
+	}
+
+	err := s.notificationClient.SendTemplate(ctx, &client.TemplateNotificationRequest{
+		UserID:     recipient,
+		TemplateID: s.config.TemplateID,
+		Priority:   "normal",
+		Variables: map[string]interface{}{
+			"orderId":     message.OrderID,
+			"from":        string(message.Role),
+			"preview":     messagePreview(message.Body),
+			"attachments": len(message.Attachments),
+			"unread":      unread(thread, oppositeRole(message.Role)),
+		},
+		Metadata: map[string]interface{}{
+			"type":      "order_message",
+			"orderId":   message.OrderID,
+			"messageId": message.ID,
+		},
+	})
+	if err != nil {
+		log.Printf("Failed to send order message notification for order %s: %v", message.OrderID, err)
+	}
+}
+
+// unread 返回 role 一方的未讀數
+func unread(thread *model.OrderThread, role model.OrderMessageRole) int {
+	if role == model.OrderMessageRoleCustomer {
+		return thread.CustomerUnread
+	}
+	return thread.StaffUnread
+}
+
+// oppositeRole 返回對話的另一方
+func oppositeRole(role model.OrderMessageRole) model.OrderMessageRole {
+	if role == model.OrderMessageRoleCustomer {
+		return model.OrderMessageRoleStaff
+	}
+	return model.OrderMessageRoleCustomer
+}
+
+// messagePreview 截取訊息開頭作為通知預覽
+func messagePreview(body string) string {
+	const maxRunes = 100
+	if utf8.RuneCountInString(body) <= maxRunes {
+		return body
+	}
+	return string([]rune(body)[:maxRunes]) + "…"
+}
@@ -2,60 +2,274 @@ package service
 
 import (
 	"context"
+	"encoding/base64"
 	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
-	"github.com/google/uuid"
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/client"
 	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/model"
 	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/repository"
 )
 
 var (
-	ErrOrderNotFound      = errors.New("order not found")
-	ErrInvalidOrderStatus = errors.New("invalid order status")
+	ErrOrderNotFound       = errors.New("order not found")
+	ErrInvalidOrderStatus  = errors.New("invalid order status")
+	ErrStockUnavailable    = errors.New("insufficient stock")
+	ErrReservationExpired  = errors.New("stock reservation expired")
+	ErrInvalidTransition   = errors.New("invalid order status transition")
+	ErrOrderNotOwned       = errors.New("order does not belong to user")
+	ErrInvalidShippingInfo = errors.New("invalid shipping info")
+	ErrInvalidCursor       = errors.New("invalid cursor")
+	ErrOrderQueryRange     = errors.New("from and to are required when querying all users, up to 92 days")
 )
 
+// phonePattern 電話號碼允許數字、空白、連字號與開頭的 +
+var phonePattern = regexp.MustCompile(`^\+?[0-9][0-9 -]{6,19}$`)
+
+// 訂單查詢分頁大小
+const (
+	defaultOrderPageSize = 10
+	maxOrderPageSize     = 100
+)
+
+// maxAllOrdersQuerySpan 不指定用戶查詢時允許的建立時間範圍，避免讀取整個全域索引
+const maxAllOrdersQuerySpan = 92 * 24 * time.Hour
+
 // OrderService 訂單服務接口
 type OrderService interface {
-	CreateOrder(ctx context.Context, userID string, cartItems []model.OrderItem, shippingInfo model.ShippingInfo) (*model.Order, error)
+	SnapshotSelectedItems(ctx context.Context, userID string) ([]model.OrderItem, error)
+	SnapshotItems(ctx context.Context, items []model.OrderItem) ([]model.OrderItem, error)
+	ResolveShippingInfo(ctx context.Context, shippingInfo *model.ShippingInfo) (model.ShippingInfo, error)
+	ResolveEInvoiceInfo(eInvoiceInfo *model.EInvoiceInfo) (*model.EInvoiceInfo, error)
+	SplitOrder(order *model.Order) ([]model.Order, error)
 	GetOrder(ctx context.Context, orderID string) (*model.Order, error)
-	GetUserOrders(ctx context.Context, userID string, page, limit int) ([]model.Order, error)
-	UpdateOrderStatus(ctx context.Context, orderID string, status model.OrderStatus) error
+	GetOrderWithChildren(ctx context.Context, orderID string) (*model.Order, error)
+	QueryOrders(ctx context.Context, query *model.OrderQuery, cursor string) (*model.OrderPage, error)
+	UpdateOrderStatus(ctx context.Context, orderID string, status model.OrderStatus, actor, reason string) error
+	CancelOrder(ctx context.Context, userID, orderID, reason string) error
+	ExpirePendingOrders(ctx context.Context) (int, error)
 }
 
 // orderService 訂單服務實現
 type orderService struct {
-	orderRepo repository.OrderRepository
+	orderRepo     repository.OrderRepository
+	cartRepo      repository.CartRepository
+	productClient client.ProductClient
+	authClient    client.AuthClient
+	pricing       PricingService
+	analytics     AnalyticsService
 }
 
 // NewOrderService 創建新的訂單服務實例
-func NewOrderService(orderRepo repository.OrderRepository) OrderService {
+func NewOrderService(orderRepo repository.OrderRepository, cartRepo repository.CartRepository, productClient client.ProductClient, authClient client.AuthClient, pricing PricingService, analytics AnalyticsService) OrderService {
 	return &orderService{
-		orderRepo: orderRepo,
+		orderRepo:     orderRepo,
+		cartRepo:      cartRepo,
+		productClient: productClient,
+		authClient:    authClient,
+		pricing:       pricing,
+		analytics:     analytics,
+	}
+}
+
+// SnapshotSelectedItems 取得購物車中已選商品，並以商品服務的最新資料保存名稱、圖片、屬性與價格快照
+func (s *orderService) SnapshotSelectedItems(ctx context.Context, userID string) ([]model.OrderItem, error) {
+	cart, err := s.cartRepo.GetCart(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cart: %w", err)
+	}
+
+	var selected []model.OrderItem
+	for _, item := range cart.Items {
+		if item.Selected {
+			selected = append(selected, model.OrderItem{ProductID: item.ProductID, Quantity: item.Quantity})
+		}
+	}
+	if len(selected) == 0 {
+		return nil, ErrNoItemsSelected
 	}
+	return s.SnapshotItems(ctx, selected)
 }
 
-// CreateOrder 創建新訂單
-func (s *orderService) CreateOrder(ctx context.Context, userID string, cartItems []model.OrderItem, shippingInfo model.ShippingInfo) (*model.Order, error) {
-	// 計算訂單總金額
-	var totalAmount float64
-	for _, item := range cartItems {
-		totalAmount += item.TotalPrice
+// SnapshotItems 以商品服務的最新資料建立訂單商品快照，selected 只需提供 ProductID 與 Quantity；
+// 商品不存在或非上架狀態時返回 ErrStockUnavailable
+func (s *orderService) SnapshotItems(ctx context.Context, selected []model.OrderItem) ([]model.OrderItem, error) {
+	productIDs := make([]string, 0, len(selected))
+	for _, item := range selected {
+		productIDs = append(productIDs, item.ProductID)
+	}
+	// 快照的價格與狀態決定訂單內容，需略過快取
+	products, err := s.productClient.GetProducts(client.WithFreshProducts(ctx), productIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product info: %w", err)
 	}
 
-	order := &model.Order{
-		ID:           uuid.New().String(),
-		UserID:       userID,
-		Items:        cartItems,
-		TotalAmount:  totalAmount,
-		Status:       model.OrderStatusPending,
-		ShippingInfo: shippingInfo,
+	items := make([]model.OrderItem, 0, len(selected))
+	for _, item := range selected {
+		product := products[item.ProductID]
+		if product == nil {
+			return nil, fmt.Errorf("%w: %s", ErrStockUnavailable, item.ProductID)
+		}
+		// 下架或售完的商品不可下單，訂閱排程也經由此處
+		if product.Status != "" && product.Status != "active" {
+			return nil, fmt.Errorf("%w: %s is %s", ErrStockUnavailable, item.ProductID, product.Status)
+		}
+		var image string
+		if len(product.Images) > 0 {
+			image = product.Images[0].URL
+		}
+		items = append(items, model.OrderItem{
+			ProductID:   item.ProductID,
+			Name:        product.Name,
+			Image:       image,
+			Attributes:  product.Attributes,
+			Price:       product.Price,
+			Quantity:    item.Quantity,
+			Weight:      product.Weight,
+			CategoryID:  product.CategoryID,
+			SellerID:    product.SellerID,
+			WarehouseID: product.WarehouseID,
+			TotalPrice:  product.Price * float64(item.Quantity),
+		})
 	}
+	return items, nil
+}
 
-	if err := s.orderRepo.Create(ctx, order); err != nil {
+// ResolveShippingInfo 未提供配送資訊時使用 auth service 的預設地址，並驗證必填欄位
+func (s *orderService) ResolveShippingInfo(ctx context.Context, shippingInfo *model.ShippingInfo) (model.ShippingInfo, error) {
+	if shippingInfo == nil {
+		address, err := s.authClient.GetDefaultAddress(ctx)
+		if err != nil {
+			if errors.Is(err, client.ErrNoDefaultAddress) {
+				return model.ShippingInfo{}, fmt.Errorf("%w: shipping info is required when no default address is set", ErrInvalidShippingInfo)
+			}
+			return model.ShippingInfo{}, fmt.Errorf("failed to get default address: %w", err)
+		}
+		shippingInfo = &model.ShippingInfo{
+			RecipientName: address.Name,
+			PhoneNumber:   address.Phone,
+			Address: model.Address{
+				Street:     address.Street,
+				City:       address.City,
+				State:      address.District,
+				PostalCode: address.PostalCode,
+			},
+		}
+	}
+
+	info := *shippingInfo
+	info.RecipientName = strings.TrimSpace(info.RecipientName)
+	info.PhoneNumber = strings.TrimSpace(info.PhoneNumber)
+	info.Address.Street = strings.TrimSpace(info.Address.Street)
+	info.Address.City = strings.TrimSpace(info.Address.City)
+	info.Address.PostalCode = strings.TrimSpace(info.Address.PostalCode)
+
+	switch {
+	case info.RecipientName == "":
+		return info, fmt.Errorf("%w: recipient name is required", ErrInvalidShippingInfo)
+	case !phonePattern.MatchString(info.PhoneNumber):
+		return info, fmt.Errorf("%w: invalid phone number", ErrInvalidShippingInfo)
+	case info.Address.Street == "" || info.Address.City == "":
+		return info, fmt.Errorf("%w: street and city are required", ErrInvalidShippingInfo)
+	case info.Address.PostalCode == "":
+		return info, fmt.Errorf("%w: postal code is required", ErrInvalidShippingInfo)
+	}
+	return info, nil
+}
+
+// ResolveEInvoiceInfo 未指定電子發票開立方式時開立個人發票並存入會員載具，並驗證欄位格式
+func (s *orderService) ResolveEInvoiceInfo(eInvoiceInfo *model.EInvoiceInfo) (*model.EInvoiceInfo, error) {
+	if eInvoiceInfo == nil {
+		return model.DefaultEInvoiceInfo(), nil
+	}
+	info := *eInvoiceInfo
+	info.CarrierID = strings.ToUpper(strings.TrimSpace(info.CarrierID))
+	info.BuyerTaxID = strings.TrimSpace(info.BuyerTaxID)
+	info.BuyerName = strings.TrimSpace(info.BuyerName)
+	info.DonationCode = strings.TrimSpace(info.DonationCode)
+	if err := info.Validate(); err != nil {
 		return nil, err
 	}
+	if info.Type != model.EInvoiceTypeDonation && info.CarrierType == "" {
+		info.CarrierType = model.EInvoiceCarrierMember
+	}
+	return &info, nil
+}
 
-	return order, nil
+// SplitOrder 計算訂單金額並依商品的賣家與出貨倉庫拆單。只有一組時記錄於訂單本身並返回 nil；
+// 多組時返回子訂單，各自計算運費，父訂單金額為子訂單合計並記錄每筆子訂單的付款分攤
+func (s *orderService) SplitOrder(order *model.Order) ([]model.Order, error) {
+	lines := make([]model.PricingLine, 0, len(order.Items))
+	for i := range order.Items {
+		item := &order.Items[i]
+		lines = append(lines, model.PricingLine{
+			ProductID: item.ProductID,
+			Price:     item.Price,
+			Quantity:  item.Quantity,
+			Weight:    item.Weight,
+			Group:     item.FulfillmentGroup(),
+		})
+	}
+	pricing, groups, err := s.pricing.CalculateSplit(lines, order.ShippingInfo.ShippingMethod, order.ShippingInfo.Address.Country)
+	if err != nil {
+		return nil, err
+	}
+	order.Pricing = pricing
+	order.TotalAmount = pricing.GrandTotal
+	order.ShippingInfo.ShippingMethod = pricing.ShippingMethod
+	order.SubOrders = nil
+	if len(groups) <= 1 {
+		if len(order.Items) > 0 {
+			order.SellerID = order.Items[0].SellerID
+			order.WarehouseID = order.Items[0].WarehouseID
+		}
+		return nil, nil
+	}
+
+	keys := make([]string, 0, len(groups))
+	for key := range groups {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	children := make([]model.Order, 0, len(keys))
+	for i, key := range keys {
+		var items []model.OrderItem
+		for j := range order.Items {
+			if order.Items[j].FulfillmentGroup() == key {
+				items = append(items, order.Items[j])
+			}
+		}
+		childPricing := groups[key]
+		// 子訂單ID由父訂單ID推導，重複執行時覆寫同一筆子訂單
+		child := model.Order{
+			ID:             fmt.Sprintf("%s-%d", order.ID, i+1),
+			UserID:         order.UserID,
+			Items:          items,
+			TotalAmount:    childPricing.GrandTotal,
+			Pricing:        childPricing,
+			Status:         order.Status,
+			ShippingInfo:   order.ShippingInfo,
+			SubscriptionID: order.SubscriptionID,
+			SellerID:       items[0].SellerID,
+			WarehouseID:    items[0].WarehouseID,
+			ParentOrderID:  order.ID,
+		}
+		children = append(children, child)
+		order.SubOrders = append(order.SubOrders, model.SubOrder{
+			OrderID:     child.ID,
+			SellerID:    child.SellerID,
+			WarehouseID: child.WarehouseID,
+			Amount:      child.TotalAmount,
+		})
+	}
+	return children, nil
 }
 
 // GetOrder 獲取訂單詳情
@@ -67,26 +281,297 @@ func (s *orderService) GetOrder(ctx context.Context, orderID string) (*model.Ord
 	return order, nil
 }
 
-// GetUserOrders 獲取用戶的所有訂單
-func (s *orderService) GetUserOrders(ctx context.Context, userID string, page, limit int) ([]model.Order, error) {
-	// 計算偏移量
-	offset := (page - 1) * limit
-	return s.orderRepo.GetByUserID(ctx, userID, offset, limit)
+// GetOrderWithChildren 獲取訂單詳情，父訂單同時返回各子訂單
+func (s *orderService) GetOrderWithChildren(ctx context.Context, orderID string) (*model.Order, error) {
+	order, err := s.GetOrder(ctx, orderID)
+	if err != nil || order.ID == "" {
+		return order, err
+	}
+	for _, sub := range order.SubOrders {
+		child, err := s.orderRepo.GetByID(ctx, sub.OrderID)
+		if err != nil {
+			return nil, err
+		}
+		if child.ID != "" {
+			order.Children = append(order.Children, *child)
+		}
+	}
+	return order, nil
 }
 
-// UpdateOrderStatus 更新訂單狀態
-func (s *orderService) UpdateOrderStatus(ctx context.Context, orderID string, status model.OrderStatus) error {
-	// 驗證訂單狀態是否有效
+// QueryOrders 依條件查詢用戶訂單，以游標分頁；不指定用戶時須提供 92 天內的建立時間範圍
+func (s *orderService) QueryOrders(ctx context.Context, query *model.OrderQuery, cursor string) (*model.OrderPage, error) {
+	if query.UserID == "" && (query.CreatedFrom == nil || query.CreatedTo == nil ||
+		query.CreatedTo.Sub(*query.CreatedFrom) > maxAllOrdersQuerySpan) {
+		return nil, ErrOrderQueryRange
+	}
+	if cursor != "" {
+		after, err := decodeOrderCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+		query.After = after
+	}
+	if query.Limit <= 0 {
+		query.Limit = defaultOrderPageSize
+	}
+	if query.Limit > maxOrderPageSize {
+		query.Limit = maxOrderPageSize
+	}
+
+	orders, next, err := s.orderRepo.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	page := &model.OrderPage{
+		Orders: orders,
+		Limit:  query.Limit,
+	}
+	if next != nil {
+		page.NextCursor = encodeOrderCursor(next)
+	}
+	return page, nil
+}
+
+// encodeOrderCursor 將游標編碼為 URL 安全的字串
+func encodeOrderCursor(cursor *model.OrderCursor) string {
+	raw := strconv.FormatInt(cursor.CreatedAt, 10) + "|" + cursor.OrderID
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeOrderCursor 解析 encodeOrderCursor 產生的游標
+func decodeOrderCursor(cursor string) (*model.OrderCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	createdAt, orderID, found := strings.Cut(string(raw), "|")
+	if !found || orderID == "" {
+		return nil, ErrInvalidCursor
+	}
+	millis, err := strconv.ParseInt(createdAt, 10, 64)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return &model.OrderCursor{CreatedAt: millis, OrderID: orderID}, nil
+}
+
+// UpdateOrderStatus 依狀態機更新訂單狀態並記錄操作者與原因，已是目標狀態時不做任何事
+func (s *orderService) UpdateOrderStatus(ctx context.Context, orderID string, status model.OrderStatus, actor, reason string) error {
+	if !status.IsValid() {
+		return ErrInvalidOrderStatus
+	}
+
+	order, err := s.orderRepo.GetByID(ctx, orderID)
+	if err != nil || order.ID == "" {
+		return ErrOrderNotFound
+	}
+	return s.transition(ctx, order, status, actor, reason)
+}
+
+// CancelOrder 用戶取消自己的訂單，僅未付款的訂單可取消
+func (s *orderService) CancelOrder(ctx context.Context, userID, orderID, reason string) error {
+	order, err := s.orderRepo.GetByID(ctx, orderID)
+	if err != nil || order.ID == "" {
+		return ErrOrderNotFound
+	}
+	if order.UserID != userID {
+		return ErrOrderNotOwned
+	}
+	return s.transition(ctx, order, model.OrderStatusCancelled, userID, reason)
+}
+
+// transition 變更訂單狀態，並同步拆單的父子訂單：父訂單的付款、取消與退款套用至子訂單，
+// 子訂單的出貨、送達與退款推進父訂單
+func (s *orderService) transition(ctx context.Context, order *model.Order, status model.OrderStatus, actor, reason string) error {
+	if order.Status == status {
+		return nil
+	}
+	// 子訂單的付款與取消跟隨父訂單；父訂單不直接出貨，由各子訂單出貨
+	if order.ParentOrderID != "" && order.Status == model.OrderStatusPending {
+		return ErrInvalidTransition
+	}
+	if len(order.SubOrders) > 0 && (status == model.OrderStatusShipped || status == model.OrderStatusDelivered) {
+		return ErrInvalidTransition
+	}
+
+	if err := s.apply(ctx, order, status, actor, reason); err != nil {
+		return err
+	}
+	switch {
+	case len(order.SubOrders) > 0:
+		s.cascade(ctx, order, status, reason)
+	case order.ParentOrderID != "":
+		s.syncParent(ctx, order.ParentOrderID)
+	}
+	return nil
+}
+
+// cascade 將父訂單的付款、取消與退款套用至子訂單；子訂單已出貨而無法退款時僅記錄
+func (s *orderService) cascade(ctx context.Context, parent *model.Order, status model.OrderStatus, reason string) {
 	switch status {
-	case model.OrderStatusPending,
-		model.OrderStatusPaid,
-		model.OrderStatusShipped,
-		model.OrderStatusDelivered,
-		model.OrderStatusCancelled:
-		// 有效狀態
+	case model.OrderStatusPaid, model.OrderStatusCancelled, model.OrderStatusRefunded:
 	default:
-		return ErrInvalidOrderStatus
+		return
 	}
+	for _, sub := range parent.SubOrders {
+		child, err := s.orderRepo.GetByID(ctx, sub.OrderID)
+		if err != nil || child.ID == "" || child.Status == status {
+			continue
+		}
+		if err := s.apply(ctx, child, status, model.OrderActorSystem, "parent order "+parent.ID+": "+reason); err != nil {
+			log.Printf("Failed to update child order %s to %s: %v", child.ID, status, err)
+		}
+	}
+}
 
-	return s.orderRepo.UpdateStatus(ctx, orderID, status)
+// syncParent 所有子訂單都已出貨、送達或退款時推進父訂單狀態
+func (s *orderService) syncParent(ctx context.Context, parentID string) {
+	parent, err := s.orderRepo.GetByID(ctx, parentID)
+	if err != nil || parent.ID == "" {
+		log.Printf("Failed to get parent order %s: %v", parentID, err)
+		return
+	}
+
+	shipped, delivered, refunded := true, true, true
+	for _, sub := range parent.SubOrders {
+		child, err := s.orderRepo.GetByID(ctx, sub.OrderID)
+		if err != nil || child.ID == "" {
+			log.Printf("Failed to get child order %s: %v", sub.OrderID, err)
+			return
+		}
+		switch child.Status {
+		case model.OrderStatusShipped:
+			delivered, refunded = false, false
+		case model.OrderStatusDelivered:
+			refunded = false
+		case model.OrderStatusRefunded:
+		default:
+			shipped, delivered, refunded = false, false, false
+		}
+	}
+
+	var path []model.OrderStatus
+	switch {
+	case refunded:
+		path = []model.OrderStatus{model.OrderStatusRefunded}
+	case delivered:
+		path = []model.OrderStatus{model.OrderStatusShipped, model.OrderStatusDelivered}
+	case shipped:
+		path = []model.OrderStatus{model.OrderStatusShipped}
+	}
+	for _, next := range path {
+		if parent.Status == next || !parent.Status.CanTransitionTo(next) {
+			continue
+		}
+		if err := s.apply(ctx, parent, next, model.OrderActorSystem, "all child orders "+string(next)); err != nil {
+			log.Printf("Failed to update parent order %s to %s: %v", parent.ID, next, err)
+			return
+		}
+		parent.Status = next
+	}
+}
+
+// apply 檢查轉換是否合法、執行對應的庫存操作後寫入新狀態；付款時先確認預留，
+// 逾時的預留不可付款，確認後狀態比對失敗由 resolveStalePayment 補償
+func (s *orderService) apply(ctx context.Context, order *model.Order, status model.OrderStatus, actor, reason string) error {
+	if !order.Status.CanTransitionTo(status) {
+		return ErrInvalidTransition
+	}
+
+	if order.ReservationID != "" {
+		switch status {
+		case model.OrderStatusPaid:
+			// 付款成功：確認預留，逾時的預留已歸還庫存，不可再付款
+			if _, err := s.productClient.CommitReservation(ctx, order.ReservationID); err != nil {
+				if errors.Is(err, client.ErrReservationClosed) {
+					return ErrReservationExpired
+				}
+				return fmt.Errorf("failed to commit reservation: %w", err)
+			}
+		case model.OrderStatusCancelled:
+			// 取消訂單：釋放預留，失敗時交由 product service 逾時清理
+			if _, err := s.productClient.ReleaseReservation(ctx, order.ReservationID); err != nil {
+				log.Printf("Failed to release reservation %s for order %s: %v", order.ReservationID, order.ID, err)
+			}
+		}
+	}
+
+	_, err := s.orderRepo.UpdateStatus(ctx, order.ID, &model.OrderStatusChange{
+		From:   order.Status,
+		To:     status,
+		Actor:  actor,
+		Reason: reason,
+		At:     time.Now(),
+	})
+	if err == repository.ErrOrderStatusStale {
+		// 讀取後狀態已被其他請求變更
+		if order.ReservationID != "" && status == model.OrderStatusPaid {
+			return s.resolveStalePayment(ctx, order)
+		}
+		return ErrInvalidTransition
+	}
+	if err != nil {
+		return err
+	}
+	s.analytics.RecordTransition(ctx, order, order.Status, status)
+	return nil
+}
+
+// resolveStalePayment 處理確認預留後狀態比對失敗的付款：其他請求已完成付款時視為成功；
+// 訂單已被取消時預留已無法釋放，將確認的數量加回庫存
+func (s *orderService) resolveStalePayment(ctx context.Context, order *model.Order) error {
+	current, err := s.orderRepo.GetByID(ctx, order.ID)
+	if err != nil || current.ID == "" {
+		return ErrOrderNotFound
+	}
+	if current.Status != model.OrderStatusCancelled {
+		if current.Status == model.OrderStatusPaid {
+			return nil
+		}
+		return ErrInvalidTransition
+	}
+
+	// 請求已取消時仍須歸還庫存
+	ctx = context.WithoutCancel(ctx)
+	for _, item := range order.Items {
+		if err := s.productClient.RestockProduct(ctx, item.ProductID, item.Quantity); err != nil {
+			log.Printf("Failed to restock product %s (quantity %d) for cancelled order %s: %v", item.ProductID, item.Quantity, order.ID, err)
+		}
+	}
+	return ErrInvalidTransition
+}
+
+// ExpirePendingOrders 取消預留已逾時仍未付款的訂單，庫存由 product service 逾時歸還
+func (s *orderService) ExpirePendingOrders(ctx context.Context) (int, error) {
+	orders, err := s.orderRepo.ListByStatus(ctx, model.OrderStatusPending)
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	expired := 0
+	for _, order := range orders {
+		if order.ReservationExpiresAt == nil || now.Before(*order.ReservationExpiresAt) {
+			continue
+		}
+		_, err := s.orderRepo.UpdateStatus(ctx, order.ID, &model.OrderStatusChange{
+			From:   model.OrderStatusPending,
+			To:     model.OrderStatusCancelled,
+			Actor:  model.OrderActorSystem,
+			Reason: "stock reservation expired",
+			At:     now,
+		})
+		if err != nil {
+			if err != repository.ErrOrderStatusStale {
+				log.Printf("Failed to cancel expired order %s: %v", order.ID, err)
+			}
+			continue
+		}
+		order := order
+		s.analytics.RecordTransition(ctx, &order, model.OrderStatusPending, model.OrderStatusCancelled)
+		s.cascade(ctx, &order, model.OrderStatusCancelled, "stock reservation expired")
+		expired++
+	}
+	return expired, nil
 }
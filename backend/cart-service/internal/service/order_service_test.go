@@ -0,0 +1,189 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/model"
+)
+
+// newTestOrderService 建立以模擬依賴組成的訂單服務，訂單已預留庫存 reservation-1
+func newTestOrderService(orders ...model.Order) (OrderService, *fakeOrderRepo, *fakeProductClient, *callLog) {
+	log := &callLog{}
+	orderRepo := newFakeOrderRepo(orders...)
+	products := newFakeProductClient(log)
+	products.reservations["reservation-1"] = "pending"
+	return NewOrderService(orderRepo, &fakeCartRepo{}, products, nil, NewPricingService(nil), fakeAnalyticsService{}), orderRepo, products, log
+}
+
+func pendingOrder() model.Order {
+	return model.Order{
+		ID:            "order-1",
+		UserID:        testUserID,
+		Status:        model.OrderStatusPending,
+		ReservationID: "reservation-1",
+		Items: []model.OrderItem{
+			{ProductID: "p1", Quantity: 2},
+			{ProductID: "p2", Quantity: 1},
+		},
+	}
+}
+
+func TestPaymentRacingCancellationRestocksCommittedReservation(t *testing.T) {
+	svc, orders, products, log := newTestOrderService(pendingOrder())
+	// 確認預留後、寫入狀態前，訂單被取消且釋放預留失敗
+	orders.beforeUpdate = func(orderID string) {
+		orders.beforeUpdate = nil
+		orders.setStatus(orderID, model.OrderStatusCancelled)
+	}
+
+	err := svc.UpdateOrderStatus(context.Background(), "order-1", model.OrderStatusPaid, model.OrderActorSystem, "payment succeeded")
+	if err != ErrInvalidTransition {
+		t.Fatalf("UpdateOrderStatus error = %v, want ErrInvalidTransition", err)
+	}
+	if status := orders.status("order-1"); status != model.OrderStatusCancelled {
+		t.Errorf("order status = %s, want %s", status, model.OrderStatusCancelled)
+	}
+	if status := products.reservationStatus("reservation-1"); status != "committed" {
+		t.Fatalf("reservation status = %s, want committed", status)
+	}
+	assertCalls(t, log, []string{"commit_reservation", "restock p1", "restock p2"})
+}
+
+func TestPaymentRacingDuplicatePaymentSucceeds(t *testing.T) {
+	svc, orders, _, log := newTestOrderService(pendingOrder())
+	orders.beforeUpdate = func(orderID string) {
+		orders.beforeUpdate = nil
+		orders.setStatus(orderID, model.OrderStatusPaid)
+	}
+
+	if err := svc.UpdateOrderStatus(context.Background(), "order-1", model.OrderStatusPaid, model.OrderActorSystem, "payment succeeded"); err != nil {
+		t.Fatalf("UpdateOrderStatus: %v", err)
+	}
+	// 另一個請求已完成付款，確認的預留屬於該筆付款，不可加回庫存
+	assertCalls(t, log, []string{"commit_reservation"})
+}
+
+func TestPaymentWithExpiredReservationKeepsOrderPending(t *testing.T) {
+	svc, orders, products, log := newTestOrderService(pendingOrder())
+	products.expire("reservation-1")
+
+	err := svc.UpdateOrderStatus(context.Background(), "order-1", model.OrderStatusPaid, model.OrderActorSystem, "payment succeeded")
+	if err != ErrReservationExpired {
+		t.Fatalf("UpdateOrderStatus error = %v, want ErrReservationExpired", err)
+	}
+	if status := orders.status("order-1"); status != model.OrderStatusPending {
+		t.Errorf("order status = %s, want %s", status, model.OrderStatusPending)
+	}
+	assertCalls(t, log, []string{"commit_reservation"})
+}
+
+func TestUpdateOrderStatusFollowsTransitionTable(t *testing.T) {
+	statuses := []model.OrderStatus{
+		model.OrderStatusPending,
+		model.OrderStatusPaid,
+		model.OrderStatusShipped,
+		model.OrderStatusDelivered,
+		model.OrderStatusCancelled,
+		model.OrderStatusRefunded,
+	}
+	allowed := map[model.OrderStatus][]model.OrderStatus{
+		model.OrderStatusPending:   {model.OrderStatusPaid, model.OrderStatusCancelled},
+		model.OrderStatusPaid:      {model.OrderStatusShipped, model.OrderStatusRefunded},
+		model.OrderStatusShipped:   {model.OrderStatusDelivered},
+		model.OrderStatusDelivered: {model.OrderStatusRefunded},
+	}
+	// 只有付款確認預留、取消釋放預留
+	reservationCalls := map[model.OrderStatus][]string{
+		model.OrderStatusPaid:      {"commit_reservation"},
+		model.OrderStatusCancelled: {"release_reservation"},
+	}
+
+	for _, from := range statuses {
+		for _, to := range statuses {
+			if from == to {
+				continue
+			}
+			t.Run(string(from)+"->"+string(to), func(t *testing.T) {
+				order := pendingOrder()
+				order.Status = from
+				svc, orders, _, log := newTestOrderService(order)
+
+				err := svc.UpdateOrderStatus(context.Background(), order.ID, to, model.OrderActorSystem, "test")
+				want := false
+				for _, next := range allowed[from] {
+					want = want || next == to
+				}
+				if !want {
+					if err != ErrInvalidTransition {
+						t.Errorf("error = %v, want ErrInvalidTransition", err)
+					}
+					if status := orders.status(order.ID); status != from {
+						t.Errorf("status = %s, want unchanged %s", status, from)
+					}
+					assertCalls(t, log, nil)
+					return
+				}
+				if err != nil {
+					t.Fatalf("UpdateOrderStatus: %v", err)
+				}
+				if status := orders.status(order.ID); status != to {
+					t.Errorf("status = %s, want %s", status, to)
+				}
+				assertCalls(t, log, reservationCalls[to])
+			})
+		}
+	}
+}
+
+func TestUpdateOrderStatusIgnoresSameStatus(t *testing.T) {
+	svc, orders, _, log := newTestOrderService(pendingOrder())
+	if err := svc.UpdateOrderStatus(context.Background(), "order-1", model.OrderStatusPending, model.OrderActorSystem, "test"); err != nil {
+		t.Fatalf("UpdateOrderStatus: %v", err)
+	}
+	if history := orders.orders["order-1"].StatusHistory; len(history) != 0 {
+		t.Errorf("status history = %v, want no change recorded", history)
+	}
+	assertCalls(t, log, nil)
+}
+
+func TestSplitOrderTransitions(t *testing.T) {
+	parent := pendingOrder()
+	parent.SubOrders = []model.SubOrder{{OrderID: "order-1-1"}, {OrderID: "order-1-2"}}
+	children := []model.Order{
+		{ID: "order-1-1", Status: model.OrderStatusPending, ParentOrderID: "order-1"},
+		{ID: "order-1-2", Status: model.OrderStatusPending, ParentOrderID: "order-1"},
+	}
+	svc, orders, _, _ := newTestOrderService(append(children, parent)...)
+	ctx := context.Background()
+
+	// 子訂單的付款跟隨父訂單
+	if err := svc.UpdateOrderStatus(ctx, "order-1-1", model.OrderStatusPaid, model.OrderActorSystem, "test"); err != ErrInvalidTransition {
+		t.Errorf("paying a child order: error = %v, want ErrInvalidTransition", err)
+	}
+	if err := svc.UpdateOrderStatus(ctx, "order-1", model.OrderStatusPaid, model.OrderActorSystem, "test"); err != nil {
+		t.Fatalf("paying the parent order: %v", err)
+	}
+	for _, id := range []string{"order-1-1", "order-1-2"} {
+		if status := orders.status(id); status != model.OrderStatusPaid {
+			t.Errorf("child %s status = %s, want %s", id, status, model.OrderStatusPaid)
+		}
+	}
+
+	// 父訂單不直接出貨，所有子訂單出貨後才推進
+	if err := svc.UpdateOrderStatus(ctx, "order-1", model.OrderStatusShipped, model.OrderActorSystem, "test"); err != ErrInvalidTransition {
+		t.Errorf("shipping the parent order: error = %v, want ErrInvalidTransition", err)
+	}
+	for i, id := range []string{"order-1-1", "order-1-2"} {
+		if err := svc.UpdateOrderStatus(ctx, id, model.OrderStatusShipped, model.OrderActorSystem, "test"); err != nil {
+			t.Fatalf("shipping child %s: %v", id, err)
+		}
+		want := model.OrderStatusPaid
+		if i == 1 {
+			want = model.OrderStatusShipped
+		}
+		if status := orders.status("order-1"); status != want {
+			t.Errorf("after shipping %s parent status = %s, want %s", id, status, want)
+		}
+	}
+}
@@ -0,0 +1,228 @@
+package service
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/model"
+)
+
+var (
+	ErrInvalidShippingMethod = errors.New("invalid shipping method")
+)
+
+// PricingServiceConfig 計價規則配置
+type PricingServiceConfig struct {
+	DefaultShippingMethod string
+	DefaultTaxRegion      string
+	ShippingRules         []model.ShippingRule
+	TaxRules              []model.TaxRule
+	DiscountRules         []model.DiscountRule
+}
+
+// PricingService 計算小計、折扣、運費、稅額與總金額
+type PricingService interface {
+	Calculate(lines []model.PricingLine, shippingMethod, region string) (*model.PriceBreakdown, error)
+	CalculateSplit(lines []model.PricingLine, shippingMethod, region string) (*model.PriceBreakdown, map[string]*model.PriceBreakdown, error)
+}
+
+type pricingService struct {
+	config        *PricingServiceConfig
+	shippingRules map[string]model.ShippingRule
+	taxRules      map[string]model.TaxRule
+}
+
+// NewPricingService 創建計價服務實例
+func NewPricingService(config *PricingServiceConfig) PricingService {
+	if config == nil {
+		config = &PricingServiceConfig{
+			DefaultShippingMethod: "standard",
+			DefaultTaxRegion:      "TW",
+			ShippingRules: []model.ShippingRule{
+				{Method: "standard", Type: model.ShippingRuleFreeOver, Fee: 60, FreeThreshold: 1000},
+			},
+			TaxRules: []model.TaxRule{
+				{Region: "TW", Rate: 0.05, Included: true},
+			},
+		}
+	}
+
+	s := &pricingService{
+		config:        config,
+		shippingRules: make(map[string]model.ShippingRule),
+		taxRules:      make(map[string]model.TaxRule),
+	}
+	for _, rule := range config.ShippingRules {
+		s.shippingRules[rule.Method] = rule
+	}
+	for _, rule := range config.TaxRules {
+		s.taxRules[strings.ToUpper(rule.Region)] = rule
+	}
+	return s
+}
+
+// Calculate 依序套用小計 → 折扣 → 運費 → 稅額 → 總金額
+func (s *pricingService) Calculate(lines []model.PricingLine, shippingMethod, region string) (*model.PriceBreakdown, error) {
+	if shippingMethod == "" {
+		shippingMethod = s.config.DefaultShippingMethod
+	}
+	shippingRule, ok := s.shippingRules[shippingMethod]
+	if !ok {
+		return nil, ErrInvalidShippingMethod
+	}
+
+	breakdown := &model.PriceBreakdown{
+		ShippingMethod: shippingMethod,
+	}
+
+	// 小計與總重量
+	var weight float64
+	for _, line := range lines {
+		breakdown.Subtotal += line.Price * float64(line.Quantity)
+		weight += line.Weight * float64(line.Quantity)
+	}
+	breakdown.Subtotal = roundAmount(breakdown.Subtotal)
+
+	// 折扣：取折抵金額最高的規則
+	for _, rule := range s.config.DiscountRules {
+		if breakdown.Subtotal < rule.MinSubtotal {
+			continue
+		}
+		discount := rule.Amount + breakdown.Subtotal*rule.Percent
+		if discount > breakdown.Subtotal {
+			discount = breakdown.Subtotal
+		}
+		if discount > breakdown.Discount {
+			breakdown.Discount = roundAmount(discount)
+			breakdown.DiscountName = rule.Name
+		}
+	}
+
+	// 運費（空購物車不收運費）與稅額
+	applyShippingAndTax(breakdown, shippingRule, s.taxRule(region), weight, len(lines) > 0)
+	return breakdown, nil
+}
+
+// CalculateSplit 依出貨分組拆分金額：折扣以整筆訂單計算後依小計比例分攤，
+// 運費與稅額由各組分別計算；返回各組合計與每組的明細，只有一組時與 Calculate 相同
+func (s *pricingService) CalculateSplit(lines []model.PricingLine, shippingMethod, region string) (*model.PriceBreakdown, map[string]*model.PriceBreakdown, error) {
+	total, err := s.Calculate(lines, shippingMethod, region)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	byGroup := make(map[string][]model.PricingLine)
+	var groups []string
+	for _, line := range lines {
+		if _, ok := byGroup[line.Group]; !ok {
+			groups = append(groups, line.Group)
+		}
+		byGroup[line.Group] = append(byGroup[line.Group], line)
+	}
+	if len(groups) <= 1 {
+		result := make(map[string]*model.PriceBreakdown, len(groups))
+		for _, group := range groups {
+			result[group] = total
+		}
+		return total, result, nil
+	}
+	sort.Strings(groups)
+
+	shippingRule := s.shippingRules[total.ShippingMethod]
+	taxRule := s.taxRule(region)
+	combined := &model.PriceBreakdown{
+		Subtotal:       total.Subtotal,
+		Discount:       total.Discount,
+		DiscountName:   total.DiscountName,
+		ShippingMethod: total.ShippingMethod,
+		TaxRegion:      total.TaxRegion,
+		TaxRate:        total.TaxRate,
+		TaxIncluded:    total.TaxIncluded,
+	}
+	result := make(map[string]*model.PriceBreakdown, len(groups))
+	remaining := total.Discount
+	for i, group := range groups {
+		breakdown := &model.PriceBreakdown{ShippingMethod: total.ShippingMethod}
+		var weight float64
+		for _, line := range byGroup[group] {
+			breakdown.Subtotal += line.Price * float64(line.Quantity)
+			weight += line.Weight * float64(line.Quantity)
+		}
+		breakdown.Subtotal = roundAmount(breakdown.Subtotal)
+
+		// 最後一組取剩餘折扣，避免四捨五入造成合計不符
+		if i == len(groups)-1 {
+			breakdown.Discount = roundAmount(remaining)
+		} else if total.Subtotal > 0 {
+			breakdown.Discount = roundAmount(total.Discount * breakdown.Subtotal / total.Subtotal)
+		}
+		remaining -= breakdown.Discount
+		if breakdown.Discount > 0 {
+			breakdown.DiscountName = total.DiscountName
+		}
+
+		applyShippingAndTax(breakdown, shippingRule, taxRule, weight, true)
+		result[group] = breakdown
+		combined.ShippingFee += breakdown.ShippingFee
+		combined.Tax += breakdown.Tax
+		combined.GrandTotal += breakdown.GrandTotal
+	}
+	combined.ShippingFee = roundAmount(combined.ShippingFee)
+	combined.Tax = roundAmount(combined.Tax)
+	combined.GrandTotal = roundAmount(combined.GrandTotal)
+	return combined, result, nil
+}
+
+// applyShippingAndTax 以折扣後金額計算運費、稅額與總金額
+func applyShippingAndTax(breakdown *model.PriceBreakdown, shippingRule model.ShippingRule, taxRule model.TaxRule, weight float64, hasLines bool) {
+	discounted := breakdown.Subtotal - breakdown.Discount
+	if hasLines {
+		breakdown.ShippingFee = roundAmount(shippingFee(shippingRule, discounted, weight))
+	}
+
+	breakdown.TaxRegion = taxRule.Region
+	breakdown.TaxRate = taxRule.Rate
+	breakdown.TaxIncluded = taxRule.Included
+	taxable := discounted + breakdown.ShippingFee
+	if taxRule.Included {
+		// 內含稅：價格已含稅，僅拆出稅額
+		breakdown.Tax = roundAmount(taxable - taxable/(1+taxRule.Rate))
+		breakdown.GrandTotal = roundAmount(taxable)
+	} else {
+		breakdown.Tax = roundAmount(taxable * taxRule.Rate)
+		breakdown.GrandTotal = roundAmount(taxable + breakdown.Tax)
+	}
+}
+
+// taxRule 取得地區稅率，找不到時使用預設地區
+func (s *pricingService) taxRule(region string) model.TaxRule {
+	if rule, ok := s.taxRules[strings.ToUpper(region)]; ok {
+		return rule
+	}
+	if rule, ok := s.taxRules[strings.ToUpper(s.config.DefaultTaxRegion)]; ok {
+		return rule
+	}
+	return model.TaxRule{Region: s.config.DefaultTaxRegion}
+}
+
+// shippingFee 依規則類型計算運費
+func shippingFee(rule model.ShippingRule, subtotal, weight float64) float64 {
+	switch rule.Type {
+	case model.ShippingRuleWeight:
+		return rule.BaseFee + math.Ceil(weight)*rule.PerKg
+	case model.ShippingRuleFreeOver:
+		if subtotal >= rule.FreeThreshold {
+			return 0
+		}
+		return rule.Fee
+	default:
+		return rule.Fee
+	}
+}
+
+// roundAmount 四捨五入到小數點後兩位
+func roundAmount(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
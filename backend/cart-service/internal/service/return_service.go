@@ -0,0 +1,435 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/client"
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/model"
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/repository"
+)
+
+var (
+	ErrReturnNotFound          = errors.New("return request not found")
+	ErrReturnNotAllowed        = errors.New("order is not eligible for return")
+	ErrReturnWindowClosed      = errors.New("return window has closed")
+	ErrInvalidReturnItems      = errors.New("invalid return items")
+	ErrInvalidReturnTransition = errors.New("invalid return status transition")
+	ErrRefundFailed            = errors.New("refund failed")
+)
+
+// ReturnService 退貨流程：顧客申請、管理員審核、收貨回補庫存與退款
+type ReturnService interface {
+	CreateReturn(ctx context.Context, userID, orderID string, req *model.CreateReturnRequest) (*model.ReturnRequest, error)
+	ListReturns(ctx context.Context, userID, orderID string) ([]model.ReturnRequest, error)
+	ListOrderReturns(ctx context.Context, orderID string) ([]model.ReturnRequest, error)
+	ListReturnsByStatus(ctx context.Context, status model.ReturnStatus) ([]model.ReturnRequest, error)
+	ReviewReturn(ctx context.Context, returnID, adminID string, req *model.ReviewReturnRequest) (*model.ReturnRequest, error)
+	ReceiveReturn(ctx context.Context, returnID, adminID string, req *model.ReceiveReturnRequest) (*model.ReturnRequest, error)
+	RefundReturn(ctx context.Context, returnID, adminID string) (*model.ReturnRequest, error)
+}
+
+// ReturnServiceConfig 退貨服務配置
+type ReturnServiceConfig struct {
+	Window        time.Duration // 送達後可申請退貨的期間
+	ServiceSecret string        // 簽發退款用的服務 token
+}
+
+type returnService struct {
+	returnRepo    repository.ReturnRepository
+	orderRepo     repository.OrderRepository
+	orderService  OrderService
+	productClient client.ProductClient
+	paymentClient client.PaymentClient
+	analytics     AnalyticsService
+	config        *ReturnServiceConfig
+}
+
+// NewReturnService 創建退貨服務實例
+func NewReturnService(returnRepo repository.ReturnRepository, orderRepo repository.OrderRepository, orderService OrderService, productClient client.ProductClient, paymentClient client.PaymentClient, analytics AnalyticsService, config *ReturnServiceConfig) ReturnService {
+	return &returnService{
+		returnRepo:    returnRepo,
+		orderRepo:     orderRepo,
+		orderService:  orderService,
+		productClient: productClient,
+		paymentClient: paymentClient,
+		analytics:     analytics,
+		config:        config,
+	}
+}
+
+// CreateReturn 顧客為已送達且仍在退貨期限內的訂單申請退貨，
+// 每項商品的數量不可超過購買數量扣除其他未被拒絕的申請，以 transaction 佔用可退數量避免並發申請超退
+func (s *returnService) CreateReturn(ctx context.Context, userID, orderID string, req *model.CreateReturnRequest) (*model.ReturnRequest, error) {
+	order, err := s.orderRepo.GetByID(ctx, orderID)
+	if err != nil || order.ID == "" {
+		return nil, ErrOrderNotFound
+	}
+	if order.UserID != userID {
+		return nil, ErrOrderNotOwned
+	}
+	// 拆單的訂單依子訂單分別退貨
+	if order.Status != model.OrderStatusDelivered || len(order.SubOrders) > 0 {
+		return nil, ErrReturnNotAllowed
+	}
+	if time.Since(deliveredAt(order)) > s.config.Window {
+		return nil, ErrReturnWindowClosed
+	}
+
+	existing, err := s.returnRepo.ListByOrderID(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	items, err := s.returnRepo.ReserveItems(ctx, orderID, returnedQuantities(existing), func(returned map[string]int) ([]model.ReturnItem, error) {
+		return returnItems(order, returned, req.Items)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	ret := &model.ReturnRequest{
+		ID:           uuid.New().String(),
+		OrderID:      order.ID,
+		UserID:       order.UserID,
+		Items:        items,
+		Reason:       req.Reason,
+		Photos:       req.Photos,
+		Status:       model.ReturnStatusRequested,
+		RefundAmount: returnRefundAmount(order, items),
+		History: []model.ReturnStatusChange{{
+			To:    model.ReturnStatusRequested,
+			Actor: userID,
+			Note:  req.Reason,
+			At:    now,
+		}},
+	}
+	if err := s.returnRepo.Create(ctx, ret); err != nil {
+		s.releaseItems(ctx, ret)
+		return nil, err
+	}
+	return ret, nil
+}
+
+// ListReturns 顧客查看自己訂單的退貨申請
+func (s *returnService) ListReturns(ctx context.Context, userID, orderID string) ([]model.ReturnRequest, error) {
+	order, err := s.orderRepo.GetByID(ctx, orderID)
+	if err != nil || order.ID == "" {
+		return nil, ErrOrderNotFound
+	}
+	if order.UserID != userID {
+		return nil, ErrOrderNotOwned
+	}
+	return s.returnRepo.ListByOrderID(ctx, orderID)
+}
+
+// ListOrderReturns 管理員查看訂單的所有退貨申請
+func (s *returnService) ListOrderReturns(ctx context.Context, orderID string) ([]model.ReturnRequest, error) {
+	order, err := s.orderRepo.GetByID(ctx, orderID)
+	if err != nil || order.ID == "" {
+		return nil, ErrOrderNotFound
+	}
+	return s.returnRepo.ListByOrderID(ctx, orderID)
+}
+
+// ListReturnsByStatus 管理員依狀態查看退貨申請，例如待審核的申請
+func (s *returnService) ListReturnsByStatus(ctx context.Context, status model.ReturnStatus) ([]model.ReturnRequest, error) {
+	return s.returnRepo.ListByStatus(ctx, status)
+}
+
+// ReviewReturn 管理員核准或拒絕退貨申請
+func (s *returnService) ReviewReturn(ctx context.Context, returnID, adminID string, req *model.ReviewReturnRequest) (*model.ReturnRequest, error) {
+	ret, err := s.getReturn(ctx, returnID)
+	if err != nil {
+		return nil, err
+	}
+	status := model.ReturnStatusRejected
+	if *req.Approve {
+		status = model.ReturnStatusApproved
+	}
+	reviewed, err := s.transition(ctx, ret, status, adminID, req.Note, nil)
+	if err != nil {
+		return nil, err
+	}
+	// 被拒絕的申請不再佔用可退數量
+	if status == model.ReturnStatusRejected {
+		s.releaseItems(ctx, reviewed)
+	}
+	return reviewed, nil
+}
+
+// ReceiveReturn 管理員確認收到退貨，可選擇回補庫存，之後自動退款；
+// 回補庫存或退款失敗不影響收貨狀態，結果記錄於 RestockFailed 與 RefundError
+func (s *returnService) ReceiveReturn(ctx context.Context, returnID, adminID string, req *model.ReceiveReturnRequest) (*model.ReturnRequest, error) {
+	ret, err := s.getReturn(ctx, returnID)
+	if err != nil {
+		return nil, err
+	}
+	ret, err = s.transition(ctx, ret, model.ReturnStatusReceived, adminID, req.Note, func(r *model.ReturnRequest) {
+		r.Restock = req.Restock
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 狀態轉換成功後才回補庫存，確保同一申請只回補一次
+	if ret.Restock {
+		for _, item := range ret.Items {
+			if err := s.productClient.RestockProduct(ctx, item.ProductID, item.Quantity); err != nil {
+				log.Printf("Failed to restock product %s for return %s: %v", item.ProductID, ret.ID, err)
+				ret.RestockFailed = append(ret.RestockFailed, item.ProductID)
+			}
+		}
+	}
+
+	refunded, err := s.refund(ctx, ret, adminID)
+	if err != nil {
+		log.Printf("Failed to refund return %s: %v", ret.ID, err)
+		ret.RefundError = err.Error()
+	} else {
+		refunded.RestockFailed = ret.RestockFailed
+		ret = refunded
+	}
+	if len(ret.RestockFailed) > 0 || ret.RefundError != "" {
+		if err := s.returnRepo.UpdateOutcome(ctx, ret); err != nil {
+			log.Printf("Failed to save outcome of return %s: %v", ret.ID, err)
+		}
+	}
+	return ret, nil
+}
+
+// RefundReturn 管理員重試已收貨但退款失敗的退貨
+func (s *returnService) RefundReturn(ctx context.Context, returnID, adminID string) (*model.ReturnRequest, error) {
+	ret, err := s.getReturn(ctx, returnID)
+	if err != nil {
+		return nil, err
+	}
+	if ret.Status != model.ReturnStatusReceived {
+		return nil, ErrInvalidReturnTransition
+	}
+
+	refunded, err := s.refund(ctx, ret, adminID)
+	if err != nil {
+		if errors.Is(err, ErrInvalidReturnTransition) {
+			return nil, err
+		}
+		ret.RefundError = err.Error()
+		if saveErr := s.returnRepo.UpdateOutcome(ctx, ret); saveErr != nil {
+			log.Printf("Failed to save outcome of return %s: %v", ret.ID, saveErr)
+		}
+		return nil, fmt.Errorf("%w: %v", ErrRefundFailed, err)
+	}
+	return refunded, nil
+}
+
+// refund 經由 payment service 退還退貨金額並轉為 refunded，以退貨ID作為 Idempotency-Key 避免重複退款；
+// 轉為 refunded 後將實際退款金額計入報表，訂單商品全部退款後訂單轉為 refunded
+func (s *returnService) refund(ctx context.Context, ret *model.ReturnRequest, actor string) (*model.ReturnRequest, error) {
+	note := "nothing to refund"
+	var order *model.Order
+	if ret.RefundAmount > 0 {
+		var err error
+		order, err = s.orderRepo.GetByID(ctx, ret.OrderID)
+		if err != nil || order.ID == "" {
+			return nil, ErrOrderNotFound
+		}
+		// 子訂單的款項由父訂單支付
+		payment, err := s.paymentClient.GetPaymentByOrderID(ctx, order.PaymentOrderID())
+		if err != nil {
+			return nil, fmt.Errorf("failed to get payment: %w", err)
+		}
+		// 以固定主體的服務 token 退款：idempotency 依 token 主體與 key 去重，
+		// 不同管理員重試同一筆退貨時才不會重複退款
+		token, err := client.SignServiceToken(s.config.ServiceSecret, "cart-service")
+		if err != nil {
+			return nil, fmt.Errorf("sign service token failed: %w", err)
+		}
+		if err := s.paymentClient.RefundPayment(context.WithValue(ctx, client.TokenKey, token), &client.RefundPaymentRequest{
+			PaymentID:      payment.ID,
+			Amount:         ret.RefundAmount,
+			Reason:         "return " + ret.ID,
+			IdempotencyKey: "return-" + ret.ID,
+		}); err != nil {
+			return nil, err
+		}
+		note = fmt.Sprintf("refunded %.2f to payment %s", ret.RefundAmount, payment.ID)
+	}
+
+	refunded, err := s.transition(ctx, ret, model.ReturnStatusRefunded, actor, note, func(r *model.ReturnRequest) {
+		r.RefundError = ""
+	})
+	if err != nil {
+		return nil, err
+	}
+	// 狀態轉換只會成功一次，退款金額不會重複計入
+	if order != nil {
+		s.analytics.RecordRefund(ctx, order, ret.RefundAmount)
+	}
+	if err := s.reconcileOrder(ctx, ret.OrderID, actor); err != nil {
+		log.Printf("Failed to update order %s after return %s: %v", ret.OrderID, ret.ID, err)
+	}
+	return refunded, nil
+}
+
+// reconcileOrder 訂單所有商品皆已退款時將訂單轉為 refunded
+func (s *returnService) reconcileOrder(ctx context.Context, orderID, actor string) error {
+	order, err := s.orderRepo.GetByID(ctx, orderID)
+	if err != nil || order.ID == "" {
+		return ErrOrderNotFound
+	}
+	if order.Status != model.OrderStatusDelivered {
+		return nil
+	}
+	returns, err := s.returnRepo.ListByOrderID(ctx, orderID)
+	if err != nil {
+		return err
+	}
+
+	remaining := make(map[string]int)
+	for _, item := range order.Items {
+		remaining[item.ProductID] += item.Quantity
+	}
+	for _, ret := range returns {
+		if ret.Status != model.ReturnStatusRefunded {
+			continue
+		}
+		for _, item := range ret.Items {
+			remaining[item.ProductID] -= item.Quantity
+		}
+	}
+	for _, quantity := range remaining {
+		if quantity > 0 {
+			return nil
+		}
+	}
+	return s.orderService.UpdateOrderStatus(ctx, orderID, model.OrderStatusRefunded, actor, "all items returned")
+}
+
+// releaseItems 歸還申請佔用的可退數量，失敗時僅記錄
+func (s *returnService) releaseItems(ctx context.Context, ret *model.ReturnRequest) {
+	if err := s.returnRepo.ReleaseItems(context.WithoutCancel(ctx), ret.OrderID, ret.Items); err != nil {
+		log.Printf("Failed to release returnable quantities of return %s: %v", ret.ID, err)
+	}
+}
+
+// getReturn 獲取退貨申請，不存在時返回 ErrReturnNotFound
+func (s *returnService) getReturn(ctx context.Context, returnID string) (*model.ReturnRequest, error) {
+	ret, err := s.returnRepo.GetByID(ctx, returnID)
+	if err != nil {
+		return nil, err
+	}
+	if ret == nil {
+		return nil, ErrReturnNotFound
+	}
+	return ret, nil
+}
+
+// transition 經由狀態機變更退貨狀態，讀取後狀態已被其他請求變更時返回 ErrInvalidReturnTransition
+func (s *returnService) transition(ctx context.Context, ret *model.ReturnRequest, status model.ReturnStatus, actor, note string, apply func(r *model.ReturnRequest)) (*model.ReturnRequest, error) {
+	if !ret.Status.CanTransitionTo(status) {
+		return nil, ErrInvalidReturnTransition
+	}
+	updated, err := s.returnRepo.UpdateStatus(ctx, ret.ID, &model.ReturnStatusChange{
+		From:  ret.Status,
+		To:    status,
+		Actor: actor,
+		Note:  note,
+		At:    time.Now(),
+	}, apply)
+	switch err {
+	case nil:
+		return updated, nil
+	case repository.ErrReturnStatusStale:
+		return nil, ErrInvalidReturnTransition
+	case repository.ErrReturnNotFound:
+		return nil, ErrReturnNotFound
+	default:
+		return nil, err
+	}
+}
+
+// deliveredAt 訂單轉為 delivered 的時間，缺少紀錄時以最後更新時間代替
+func deliveredAt(order *model.Order) time.Time {
+	for i := len(order.StatusHistory) - 1; i >= 0; i-- {
+		if order.StatusHistory[i].To == model.OrderStatusDelivered {
+			return order.StatusHistory[i].At
+		}
+	}
+	return order.UpdatedAt
+}
+
+// returnedQuantities 統計未被拒絕的退貨申請已佔用的各商品數量
+func returnedQuantities(existing []model.ReturnRequest) map[string]int {
+	returned := make(map[string]int)
+	for _, ret := range existing {
+		if ret.Status == model.ReturnStatusRejected {
+			continue
+		}
+		for _, item := range ret.Items {
+			returned[item.ProductID] += item.Quantity
+		}
+	}
+	return returned
+}
+
+// returnItems 以訂單快照建立退貨商品，合併重複的商品並檢查數量不超過購買數量扣除 returned
+func returnItems(order *model.Order, returned map[string]int, requested []model.ReturnItemRequest) ([]model.ReturnItem, error) {
+	ordered := make(map[string]model.OrderItem)
+	remaining := make(map[string]int)
+	for _, item := range order.Items {
+		if _, ok := ordered[item.ProductID]; !ok {
+			ordered[item.ProductID] = item
+		}
+		remaining[item.ProductID] += item.Quantity
+	}
+	for productID, quantity := range returned {
+		remaining[productID] -= quantity
+	}
+
+	var items []model.ReturnItem
+	index := make(map[string]int)
+	for _, req := range requested {
+		orderItem, ok := ordered[req.ProductID]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s is not in the order", ErrInvalidReturnItems, req.ProductID)
+		}
+		if i, ok := index[req.ProductID]; ok {
+			items[i].Quantity += req.Quantity
+			continue
+		}
+		index[req.ProductID] = len(items)
+		items = append(items, model.ReturnItem{
+			ProductID: req.ProductID,
+			Name:      orderItem.Name,
+			Price:     orderItem.Price,
+			Quantity:  req.Quantity,
+			Reason:    req.Reason,
+		})
+	}
+	for _, item := range items {
+		if item.Quantity > remaining[item.ProductID] {
+			return nil, fmt.Errorf("%w: %s exceeds returnable quantity", ErrInvalidReturnItems, item.ProductID)
+		}
+	}
+	return items, nil
+}
+
+// returnRefundAmount 計算退款金額：商品金額依訂單折扣比例折算，未含稅時加計稅額，運費不退
+func returnRefundAmount(order *model.Order, items []model.ReturnItem) float64 {
+	amount := 0.0
+	for _, item := range items {
+		amount += item.Price * float64(item.Quantity)
+	}
+	if pricing := order.Pricing; pricing != nil && pricing.Subtotal > 0 {
+		amount = amount * (pricing.Subtotal - pricing.Discount) / pricing.Subtotal
+		if !pricing.TaxIncluded {
+			amount *= 1 + pricing.TaxRate
+		}
+	}
+	return math.Round(amount*100) / 100
+}
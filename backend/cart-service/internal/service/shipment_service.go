@@ -0,0 +1,301 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/carrier"
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/model"
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/repository"
+)
+
+var (
+	ErrShipmentNotAllowed    = errors.New("order is not ready for shipment")
+	ErrInvalidShipmentItems  = errors.New("invalid shipment items")
+	ErrUnknownCarrier        = carrier.ErrUnknownCarrier
+	ErrInvalidTrackingNumber = carrier.ErrUnknownTracking
+)
+
+// ShipmentService 貨件管理：建立（可部分出貨）、查詢追蹤與同步物流商狀態
+type ShipmentService interface {
+	CreateShipment(ctx context.Context, orderID, adminID string, req *model.CreateShipmentRequest) (*model.Shipment, error)
+	ListShipments(ctx context.Context, orderID string) ([]model.Shipment, error)
+	GetTracking(ctx context.Context, userID, orderID string) ([]model.Shipment, error)
+	SyncShipments(ctx context.Context) (int, error)
+}
+
+type shipmentService struct {
+	shipmentRepo repository.ShipmentRepository
+	orderRepo    repository.OrderRepository
+	orderService OrderService
+	carriers     *carrier.Registry
+}
+
+// NewShipmentService 創建貨件服務實例
+func NewShipmentService(shipmentRepo repository.ShipmentRepository, orderRepo repository.OrderRepository, orderService OrderService, carriers *carrier.Registry) ShipmentService {
+	return &shipmentService{
+		shipmentRepo: shipmentRepo,
+		orderRepo:    orderRepo,
+		orderService: orderService,
+		carriers:     carriers,
+	}
+}
+
+// CreateShipment 為已付款訂單建立貨件，未指定商品時出貨所有尚未出貨的商品；已出貨的訂單可為
+// failed 貨件的商品重新出貨。出貨數量以 transaction 佔用，避免同時建立的貨件超出訂單數量。
+// 所有商品皆已出貨後訂單轉為 shipped
+func (s *shipmentService) CreateShipment(ctx context.Context, orderID, adminID string, req *model.CreateShipmentRequest) (*model.Shipment, error) {
+	order, err := s.orderRepo.GetByID(ctx, orderID)
+	if err != nil || order.ID == "" {
+		return nil, ErrOrderNotFound
+	}
+	// 拆單的訂單由各子訂單分別出貨
+	if (order.Status != model.OrderStatusPaid && order.Status != model.OrderStatusShipped) || len(order.SubOrders) > 0 {
+		return nil, ErrShipmentNotAllowed
+	}
+
+	c, err := s.carriers.Get(req.Carrier)
+	if err != nil {
+		return nil, err
+	}
+
+	shipments, err := s.shipmentRepo.ListByOrderID(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	items, err := s.shipmentRepo.ReserveItems(ctx, orderID, shippedQuantities(shipments), func(shipped map[string]int) ([]model.ShipmentItem, error) {
+		return shipmentItems(order, shipped, req.Items)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	shipment := &model.Shipment{
+		ID:             uuid.New().String(),
+		OrderID:        order.ID,
+		UserID:         order.UserID,
+		Carrier:        c.Name(),
+		TrackingNumber: req.TrackingNumber,
+		Status:         model.ShipmentStatusLabelCreated,
+		Items:          items,
+		Events: []model.TrackingEvent{{
+			Status:      model.ShipmentStatusLabelCreated,
+			Description: "Shipment created",
+			OccurredAt:  now,
+		}},
+		ShippedAt: &now,
+		CreatedAt: now,
+	}
+	if shipment.TrackingNumber, err = c.Register(ctx, shipment); err != nil {
+		s.releaseItems(ctx, orderID, items)
+		return nil, fmt.Errorf("failed to register shipment with carrier: %w", err)
+	}
+	if err := s.shipmentRepo.Save(ctx, shipment); err != nil {
+		s.releaseItems(ctx, orderID, items)
+		return nil, err
+	}
+
+	if err := s.reconcileOrder(ctx, orderID, adminID); err != nil {
+		// 訂單狀態會在下次同步時修正
+		log.Printf("Failed to update order %s after shipment %s: %v", orderID, shipment.ID, err)
+	}
+	return shipment, nil
+}
+
+// releaseItems 歸還建立失敗的貨件所佔用的出貨數量
+func (s *shipmentService) releaseItems(ctx context.Context, orderID string, items []model.ShipmentItem) {
+	if err := s.shipmentRepo.ReleaseItems(context.WithoutCancel(ctx), orderID, items); err != nil {
+		log.Printf("Failed to release shipment items for order %s: %v", orderID, err)
+	}
+}
+
+// ListShipments 獲取訂單的所有貨件
+func (s *shipmentService) ListShipments(ctx context.Context, orderID string) ([]model.Shipment, error) {
+	order, err := s.orderRepo.GetByID(ctx, orderID)
+	if err != nil || order.ID == "" {
+		return nil, ErrOrderNotFound
+	}
+	return s.shipmentRepo.ListByOrderID(ctx, orderID)
+}
+
+// GetTracking 顧客查看自己訂單的貨件追蹤
+func (s *shipmentService) GetTracking(ctx context.Context, userID, orderID string) ([]model.Shipment, error) {
+	order, err := s.orderRepo.GetByID(ctx, orderID)
+	if err != nil || order.ID == "" {
+		return nil, ErrOrderNotFound
+	}
+	if order.UserID != userID {
+		return nil, ErrOrderNotOwned
+	}
+	return s.shipmentRepo.ListByOrderID(ctx, orderID)
+}
+
+// SyncShipments 向物流商同步未送達貨件的追蹤事件，返回有更新的貨件數
+func (s *shipmentService) SyncShipments(ctx context.Context) (int, error) {
+	updated := 0
+	for _, status := range model.ActiveShipmentStatuses {
+		shipments, err := s.shipmentRepo.ListByStatus(ctx, status)
+		if err != nil {
+			return updated, err
+		}
+		for i := range shipments {
+			changed, err := s.syncShipment(ctx, &shipments[i])
+			if err != nil {
+				log.Printf("Failed to sync shipment %s: %v", shipments[i].ID, err)
+				continue
+			}
+			if changed {
+				updated++
+			}
+		}
+	}
+	return updated, nil
+}
+
+// syncShipment 以物流商的追蹤事件更新貨件，送達時同步訂單狀態；
+// 物流商查無追蹤號碼時標記為 failed，不再重複同步，並歸還其出貨數量以便重新出貨
+func (s *shipmentService) syncShipment(ctx context.Context, shipment *model.Shipment) (bool, error) {
+	c, err := s.carriers.Get(shipment.Carrier)
+	if err != nil {
+		return false, err
+	}
+	events, err := c.Track(ctx, shipment.TrackingNumber)
+	if err != nil {
+		if !errors.Is(err, carrier.ErrUnknownTracking) {
+			return false, err
+		}
+		shipment.Status = model.ShipmentStatusFailed
+		shipment.Events = append(shipment.Events, model.TrackingEvent{
+			Status:      model.ShipmentStatusFailed,
+			Description: "Tracking number not recognized by carrier",
+			OccurredAt:  time.Now(),
+		})
+		if err := s.shipmentRepo.Save(ctx, shipment); err != nil {
+			return false, err
+		}
+		s.releaseItems(ctx, shipment.OrderID, shipment.Items)
+		return true, nil
+	}
+	if len(events) == 0 {
+		return false, nil
+	}
+
+	latest := events[len(events)-1]
+	if latest.Status == shipment.Status && len(events) == len(shipment.Events) {
+		return false, nil
+	}
+	shipment.Events = events
+	shipment.Status = latest.Status
+	if latest.Status == model.ShipmentStatusDelivered {
+		deliveredAt := latest.OccurredAt
+		shipment.DeliveredAt = &deliveredAt
+	}
+	if err := s.shipmentRepo.Save(ctx, shipment); err != nil {
+		return false, err
+	}
+
+	if err := s.reconcileOrder(ctx, shipment.OrderID, model.OrderActorSystem); err != nil {
+		log.Printf("Failed to update order %s after syncing shipment %s: %v", shipment.OrderID, shipment.ID, err)
+	}
+	return true, nil
+}
+
+// reconcileOrder 依貨件推進訂單狀態：全部商品出貨後轉為 shipped，所有貨件送達後轉為 delivered；
+// failed 的貨件不計入出貨數量，也不阻擋送達
+func (s *shipmentService) reconcileOrder(ctx context.Context, orderID, actor string) error {
+	order, err := s.orderRepo.GetByID(ctx, orderID)
+	if err != nil || order.ID == "" {
+		return ErrOrderNotFound
+	}
+	shipments, err := s.shipmentRepo.ListByOrderID(ctx, orderID)
+	if err != nil {
+		return err
+	}
+
+	remaining, err := shipmentItems(order, shippedQuantities(shipments), nil)
+	if err == nil && len(remaining) > 0 {
+		// 仍有商品未出貨
+		return nil
+	}
+
+	if order.Status == model.OrderStatusPaid {
+		if err := s.orderService.UpdateOrderStatus(ctx, orderID, model.OrderStatusShipped, actor, "all items shipped"); err != nil {
+			return err
+		}
+		order.Status = model.OrderStatusShipped
+	}
+	if order.Status != model.OrderStatusShipped {
+		return nil
+	}
+	for _, shipment := range shipments {
+		if shipment.Status != model.ShipmentStatusDelivered && shipment.Status != model.ShipmentStatusFailed {
+			return nil
+		}
+	}
+	return s.orderService.UpdateOrderStatus(ctx, orderID, model.OrderStatusDelivered, model.OrderActorSystem, "all shipments delivered")
+}
+
+// shippedQuantities 統計貨件中各商品的出貨數量，failed 的貨件不計入
+func shippedQuantities(shipments []model.Shipment) map[string]int {
+	shipped := make(map[string]int)
+	for _, shipment := range shipments {
+		if shipment.Status == model.ShipmentStatusFailed {
+			continue
+		}
+		for _, item := range shipment.Items {
+			shipped[item.ProductID] += item.Quantity
+		}
+	}
+	return shipped
+}
+
+// shipmentItems 依已出貨數量計算本次出貨的商品：requested 為空時返回所有尚未出貨的商品，
+// 否則檢查每項數量不超過尚未出貨的數量；沒有可出貨商品時返回 ErrInvalidShipmentItems
+func shipmentItems(order *model.Order, shipped map[string]int, requested []model.ShipmentItem) ([]model.ShipmentItem, error) {
+	remaining := make(map[string]int)
+	var productIDs []string
+	for _, item := range order.Items {
+		if _, ok := remaining[item.ProductID]; !ok {
+			productIDs = append(productIDs, item.ProductID)
+		}
+		remaining[item.ProductID] += item.Quantity
+	}
+	for productID, quantity := range shipped {
+		remaining[productID] -= quantity
+	}
+
+	if len(requested) == 0 {
+		var items []model.ShipmentItem
+		for _, productID := range productIDs {
+			if remaining[productID] > 0 {
+				items = append(items, model.ShipmentItem{ProductID: productID, Quantity: remaining[productID]})
+			}
+		}
+		if len(items) == 0 {
+			return nil, fmt.Errorf("%w: all items already shipped", ErrInvalidShipmentItems)
+		}
+		return items, nil
+	}
+
+	// 合併重複的商品
+	quantities := make(map[string]int)
+	var items []model.ShipmentItem
+	for _, item := range requested {
+		if _, ok := quantities[item.ProductID]; !ok {
+			items = append(items, model.ShipmentItem{ProductID: item.ProductID})
+		}
+		quantities[item.ProductID] += item.Quantity
+	}
+	for i := range items {
+		items[i].Quantity = quantities[items[i].ProductID]
+		if items[i].Quantity > remaining[items[i].ProductID] {
+			return nil, fmt.Errorf("%w: %s exceeds unshipped quantity", ErrInvalidShipmentItems, items[i].ProductID)
+		}
+	}
+	return items, nil
+}
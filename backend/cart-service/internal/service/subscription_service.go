@@ -0,0 +1,479 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/client"
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/model"
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/repository"
+)
+
+var (
+	ErrSubscriptionNotFound    = repository.ErrSubscriptionNotFound
+	ErrSubscriptionNotOwned    = errors.New("subscription does not belong to user")
+	ErrInvalidSubscription     = errors.New("invalid subscription")
+	ErrSubscriptionCancelled   = errors.New("subscription is cancelled")
+	ErrInvalidSubscriptionStep = errors.New("subscription status does not allow this action")
+
+	// errSubscriptionNotDue 其他實例已處理該次排程
+	errSubscriptionNotDue = errors.New("subscription run already handled")
+)
+
+// maxSubscriptionRuns 訂閱保留的執行紀錄數量
+const maxSubscriptionRuns = 10
+
+// SubscriptionServiceConfig 訂閱服務配置
+type SubscriptionServiceConfig struct {
+	ServiceSecret      string        // 排程下單時簽發代表用戶的服務 token
+	RemindBefore       time.Duration // 執行前多久發送提醒
+	RetryAfter         time.Duration // 失敗後多久重試
+	MaxFailures        int           // 連續失敗次數上限，達到後暫停訂閱
+	ReminderTemplateID string        // 執行前提醒的通知模板，空白時不發送
+	FailureTemplateID  string        // 執行失敗的通知模板，空白時不發送
+}
+
+// SubscriptionService 定期訂購：依頻率以當下價格建立訂單並付款，執行前提醒、失敗時通知
+type SubscriptionService interface {
+	CreateSubscription(ctx context.Context, userID string, req *model.CreateSubscriptionRequest) (*model.Subscription, error)
+	ListSubscriptions(ctx context.Context, userID string) ([]model.Subscription, error)
+	GetSubscription(ctx context.Context, userID, id string) (*model.Subscription, error)
+	UpdateSubscription(ctx context.Context, userID, id string, req *model.UpdateSubscriptionRequest) (*model.Subscription, error)
+	SkipNextRun(ctx context.Context, userID, id string) (*model.Subscription, error)
+	PauseSubscription(ctx context.Context, userID, id string) (*model.Subscription, error)
+	ResumeSubscription(ctx context.Context, userID, id string) (*model.Subscription, error)
+	CancelSubscription(ctx context.Context, userID, id string) (*model.Subscription, error)
+	RunDue(ctx context.Context) (int, error)
+}
+
+type subscriptionService struct {
+	subRepo            repository.SubscriptionRepository
+	orderService       OrderService
+	checkoutService    CheckoutSagaService
+	productClient      client.ProductClient
+	notificationClient client.NotificationClient
+	config             *SubscriptionServiceConfig
+}
+
+// NewSubscriptionService 創建訂閱服務實例
+func NewSubscriptionService(
+	subRepo repository.SubscriptionRepository,
+	orderService OrderService,
+	checkoutService CheckoutSagaService,
+	productClient client.ProductClient,
+	notificationClient client.NotificationClient,
+	config *SubscriptionServiceConfig,
+) SubscriptionService {
+	if config.RetryAfter <= 0 {
+		config.RetryAfter = 24 * time.Hour
+	}
+	if config.MaxFailures <= 0 {
+		config.MaxFailures = 3
+	}
+	return &subscriptionService{
+		subRepo:            subRepo,
+		orderService:       orderService,
+		checkoutService:    checkoutService,
+		productClient:      productClient,
+		notificationClient: notificationClient,
+		config:             config,
+	}
+}
+
+// CreateSubscription 建立訂閱，未指定開始時間時於一個週期後第一次執行
+func (s *subscriptionService) CreateSubscription(ctx context.Context, userID string, req *model.CreateSubscriptionRequest) (*model.Subscription, error) {
+	if !req.Frequency.IsValid() {
+		return nil, fmt.Errorf("%w: unknown frequency %q", ErrInvalidSubscription, req.Frequency)
+	}
+	now := time.Now()
+	nextRunAt := req.Frequency.Next(now, 0)
+	if req.StartAt != nil {
+		if !req.StartAt.After(now) {
+			return nil, fmt.Errorf("%w: start time must be in the future", ErrInvalidSubscription)
+		}
+		nextRunAt = *req.StartAt
+	}
+
+	items, err := s.validateItems(ctx, req.Items)
+	if err != nil {
+		return nil, err
+	}
+	shippingInfo, err := s.orderService.ResolveShippingInfo(ctx, req.ShippingInfo)
+	if err != nil {
+		return nil, err
+	}
+	einvoiceInfo, err := s.orderService.ResolveEInvoiceInfo(req.EInvoice)
+	if err != nil {
+		return nil, err
+	}
+
+	sub := &model.Subscription{
+		ID:            uuid.New().String(),
+		UserID:        userID,
+		Items:         items,
+		Frequency:     req.Frequency,
+		NextRunAt:     nextRunAt,
+		AnchorDay:     nextRunAt.Day(),
+		ShippingInfo:  shippingInfo,
+		PaymentMethod: req.PaymentMethod,
+		Currency:      req.Currency,
+		EInvoiceInfo:  einvoiceInfo,
+		Status:        model.SubscriptionStatusActive,
+	}
+	if err := s.subRepo.Create(ctx, sub); err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+// ListSubscriptions 獲取用戶的所有訂閱
+func (s *subscriptionService) ListSubscriptions(ctx context.Context, userID string) ([]model.Subscription, error) {
+	return s.subRepo.ListByUserID(ctx, userID)
+}
+
+// GetSubscription 獲取用戶自己的訂閱
+func (s *subscriptionService) GetSubscription(ctx context.Context, userID, id string) (*model.Subscription, error) {
+	sub, err := s.subRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if sub == nil {
+		return nil, ErrSubscriptionNotFound
+	}
+	if sub.UserID != userID {
+		return nil, ErrSubscriptionNotOwned
+	}
+	return sub, nil
+}
+
+// UpdateSubscription 更新訂閱商品、頻率、下次執行時間、配送與付款方式
+func (s *subscriptionService) UpdateSubscription(ctx context.Context, userID, id string, req *model.UpdateSubscriptionRequest) (*model.Subscription, error) {
+	if req.Frequency != "" && !req.Frequency.IsValid() {
+		return nil, fmt.Errorf("%w: unknown frequency %q", ErrInvalidSubscription, req.Frequency)
+	}
+	if req.NextRunAt != nil && !req.NextRunAt.After(time.Now()) {
+		return nil, fmt.Errorf("%w: next run time must be in the future", ErrInvalidSubscription)
+	}
+
+	var items []model.SubscriptionItem
+	if len(req.Items) > 0 {
+		var err error
+		if items, err = s.validateItems(ctx, req.Items); err != nil {
+			return nil, err
+		}
+	}
+	var shippingInfo *model.ShippingInfo
+	if req.ShippingInfo != nil {
+		info, err := s.orderService.ResolveShippingInfo(ctx, req.ShippingInfo)
+		if err != nil {
+			return nil, err
+		}
+		shippingInfo = &info
+	}
+	var einvoiceInfo *model.EInvoiceInfo
+	if req.EInvoice != nil {
+		var err error
+		if einvoiceInfo, err = s.orderService.ResolveEInvoiceInfo(req.EInvoice); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.update(ctx, userID, id, func(sub *model.Subscription) error {
+		if items != nil {
+			sub.Items = items
+		}
+		if req.Frequency != "" {
+			sub.Frequency = req.Frequency
+		}
+		if req.NextRunAt != nil && !req.NextRunAt.Equal(sub.NextRunAt) {
+			sub.NextRunAt = *req.NextRunAt
+			sub.AnchorDay = req.NextRunAt.Day()
+			sub.RetryOf = nil
+			sub.RemindedFor = nil
+		}
+		if shippingInfo != nil {
+			sub.ShippingInfo = *shippingInfo
+		}
+		if req.PaymentMethod != "" {
+			sub.PaymentMethod = req.PaymentMethod
+		}
+		if einvoiceInfo != nil {
+			sub.EInvoiceInfo = einvoiceInfo
+		}
+		return nil
+	})
+}
+
+// SkipNextRun 略過下一次執行（或失敗後的重試），從原定排程順延一個週期
+func (s *subscriptionService) SkipNextRun(ctx context.Context, userID, id string) (*model.Subscription, error) {
+	return s.update(ctx, userID, id, func(sub *model.Subscription) error {
+		scheduledAt := sub.ScheduledRunAt()
+		sub.Runs = appendRun(sub.Runs, model.SubscriptionRun{
+			ScheduledAt: scheduledAt,
+			Status:      model.SubscriptionRunSkipped,
+			At:          time.Now(),
+		})
+		sub.NextRunAt = sub.Frequency.Next(scheduledAt, sub.AnchorDay)
+		sub.RetryOf = nil
+		sub.RemindedFor = nil
+		return nil
+	})
+}
+
+// PauseSubscription 暫停訂閱
+func (s *subscriptionService) PauseSubscription(ctx context.Context, userID, id string) (*model.Subscription, error) {
+	return s.update(ctx, userID, id, func(sub *model.Subscription) error {
+		if sub.Status != model.SubscriptionStatusActive {
+			return ErrInvalidSubscriptionStep
+		}
+		sub.Status = model.SubscriptionStatusPaused
+		return nil
+	})
+}
+
+// ResumeSubscription 恢復已暫停的訂閱，暫停期間錯過的排程不補單
+func (s *subscriptionService) ResumeSubscription(ctx context.Context, userID, id string) (*model.Subscription, error) {
+	return s.update(ctx, userID, id, func(sub *model.Subscription) error {
+		if sub.Status != model.SubscriptionStatusPaused {
+			return ErrInvalidSubscriptionStep
+		}
+		sub.Status = model.SubscriptionStatusActive
+		sub.Failures = 0
+		sub.NextRunAt = nextRunAfter(sub.Frequency, sub.ScheduledRunAt(), sub.AnchorDay, time.Now())
+		sub.RetryOf = nil
+		sub.RemindedFor = nil
+		return nil
+	})
+}
+
+// CancelSubscription 取消訂閱，取消後無法恢復
+func (s *subscriptionService) CancelSubscription(ctx context.Context, userID, id string) (*model.Subscription, error) {
+	return s.update(ctx, userID, id, func(sub *model.Subscription) error {
+		sub.Status = model.SubscriptionStatusCancelled
+		return nil
+	})
+}
+
+// RunDue 執行已到期的訂閱，並為即將執行的訂閱發送提醒，返回執行數量
+func (s *subscriptionService) RunDue(ctx context.Context) (int, error) {
+	subs, err := s.subRepo.ListByStatus(ctx, model.SubscriptionStatusActive)
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	ran := 0
+	for i := range subs {
+		sub := &subs[i]
+		switch {
+		case !sub.NextRunAt.After(now):
+			if err := s.run(ctx, sub); err != nil {
+				if err != errSubscriptionNotDue {
+					log.Printf("Failed to run subscription %s: %v", sub.ID, err)
+				}
+				continue
+			}
+			ran++
+		case s.config.ReminderTemplateID != "" && sub.NextRunAt.Sub(now) <= s.config.RemindBefore &&
+			(sub.RemindedFor == nil || !sub.RemindedFor.Equal(sub.NextRunAt)):
+			s.remind(ctx, sub)
+		}
+	}
+	return ran, nil
+}
+
+// run 先推進下次執行時間佔用本次排程，避免多個實例重複下單，再以結帳流程建立訂單並付款；
+// 下次執行時間一律從原定排程推算，重試不會讓之後的排程跟著延後
+func (s *subscriptionService) run(ctx context.Context, sub *model.Subscription) error {
+	dueAt := sub.NextRunAt
+	scheduledAt := sub.ScheduledRunAt()
+	claimed, err := s.subRepo.Update(ctx, sub.ID, func(cur *model.Subscription) error {
+		if cur.Status != model.SubscriptionStatusActive || !cur.NextRunAt.Equal(dueAt) {
+			return errSubscriptionNotDue
+		}
+		if cur.AnchorDay == 0 {
+			cur.AnchorDay = scheduledAt.Day()
+		}
+		cur.NextRunAt = nextRunAfter(cur.Frequency, scheduledAt, cur.AnchorDay, time.Now())
+		cur.RetryOf = nil
+		cur.RemindedFor = nil
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	token, err := client.SignServiceToken(s.config.ServiceSecret, claimed.UserID)
+	if err != nil {
+		return fmt.Errorf("sign service token failed: %w", err)
+	}
+	ctx = context.WithValue(ctx, client.TokenKey, token)
+
+	// 流程仍為 running 時（暫時性錯誤或通過確認訂單後的步驟失敗）由結帳流程續跑，視為已下單
+	saga, checkoutErr := s.checkoutService.CheckoutSubscription(ctx, claimed)
+	run := model.SubscriptionRun{
+		ScheduledAt: scheduledAt,
+		Status:      model.SubscriptionRunSucceeded,
+		At:          time.Now(),
+	}
+	if saga != nil {
+		run.SagaID = saga.ID
+		run.OrderID = saga.OrderID
+	}
+	if checkoutErr != nil && (saga == nil || saga.Status != model.SagaStatusRunning) {
+		run.Status = model.SubscriptionRunFailed
+		run.Error = checkoutErr.Error()
+	}
+
+	updated, err := s.subRepo.Update(ctx, sub.ID, func(cur *model.Subscription) error {
+		cur.Runs = appendRun(cur.Runs, run)
+		if run.Status == model.SubscriptionRunSucceeded {
+			cur.Failures = 0
+			return nil
+		}
+		cur.Failures++
+		if cur.Failures >= s.config.MaxFailures {
+			cur.Status = model.SubscriptionStatusPaused
+		} else if retryAt := time.Now().Add(s.config.RetryAfter); retryAt.Before(cur.NextRunAt) {
+			cur.NextRunAt = retryAt
+			cur.RetryOf = &scheduledAt
+			cur.RemindedFor = &retryAt // 重試不再提醒
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record subscription run: %w", err)
+	}
+
+	if run.Status == model.SubscriptionRunFailed {
+		log.Printf("Subscription %s run failed: %s", sub.ID, run.Error)
+		s.notifyFailure(ctx, updated, &run)
+	}
+	return nil
+}
+
+// remind 發送執行前提醒，先記錄已提醒的排程時間以免多個實例重複發送
+func (s *subscriptionService) remind(ctx context.Context, sub *model.Subscription) {
+	scheduledAt := sub.NextRunAt
+	_, err := s.subRepo.Update(ctx, sub.ID, func(cur *model.Subscription) error {
+		if cur.Status != model.SubscriptionStatusActive || !cur.NextRunAt.Equal(scheduledAt) ||
+			(cur.RemindedFor != nil && cur.RemindedFor.Equal(scheduledAt)) {
+			return errSubscriptionNotDue
+		}
+		cur.RemindedFor = &scheduledAt
+		return nil
+	})
+	if err != nil {
+		if err != errSubscriptionNotDue {
+			log.Printf("Failed to mark subscription %s as reminded: %v", sub.ID, err)
+		}
+		return
+	}
+
+	err = s.notificationClient.SendTemplate(ctx, &client.TemplateNotificationRequest{
+		UserID:     sub.UserID,
+		TemplateID: s.config.ReminderTemplateID,
+		Priority:   "normal",
+		Variables: map[string]interface{}{
+			"subscriptionId": sub.ID,
+			"nextRunAt":      scheduledAt.Format(time.RFC3339),
+			"itemCount":      len(sub.Items),
+		},
+		Metadata: map[string]interface{}{
+			"type":           "subscription_reminder",
+			"subscriptionId": sub.ID,
+		},
+	})
+	if err != nil {
+		log.Printf("Failed to send reminder for subscription %s: %v", sub.ID, err)
+	}
+}
+
+// notifyFailure 通知用戶訂閱執行失敗，連續失敗達上限時同時告知已暫停
+func (s *subscriptionService) notifyFailure(ctx context.Context, sub *model.Subscription, run *model.SubscriptionRun) {
+	if s.config.FailureTemplateID == "" {
+		return
+	}
+	err := s.notificationClient.SendTemplate(ctx, &client.TemplateNotificationRequest{
+		UserID:     sub.UserID,
+		TemplateID: s.config.FailureTemplateID,
+		Priority:   "high",
+		Variables: map[string]interface{}{
+			"subscriptionId": sub.ID,
+			"scheduledAt":    run.ScheduledAt.Format(time.RFC3339),
+			"error":          run.Error,
+			"paused":         sub.Status == model.SubscriptionStatusPaused,
+			"nextRunAt":      sub.NextRunAt.Format(time.RFC3339),
+		},
+		Metadata: map[string]interface{}{
+			"type":           "subscription_failed",
+			"subscriptionId": sub.ID,
+		},
+	})
+	if err != nil {
+		log.Printf("Failed to send failure notice for subscription %s: %v", sub.ID, err)
+	}
+}
+
+// update 以 transaction 修改用戶自己的訂閱，已取消的訂閱不可修改
+func (s *subscriptionService) update(ctx context.Context, userID, id string, apply func(sub *model.Subscription) error) (*model.Subscription, error) {
+	return s.subRepo.Update(ctx, id, func(sub *model.Subscription) error {
+		if sub.UserID != userID {
+			return ErrSubscriptionNotOwned
+		}
+		if sub.Status == model.SubscriptionStatusCancelled {
+			return ErrSubscriptionCancelled
+		}
+		return apply(sub)
+	})
+}
+
+// validateItems 合併重複商品並確認商品仍在販售
+func (s *subscriptionService) validateItems(ctx context.Context, items []model.SubscriptionItem) ([]model.SubscriptionItem, error) {
+	var merged []model.SubscriptionItem
+	positions := make(map[string]int, len(items))
+	productIDs := make([]string, 0, len(items))
+	for _, item := range items {
+		if i, ok := positions[item.ProductID]; ok {
+			merged[i].Quantity += item.Quantity
+			continue
+		}
+		positions[item.ProductID] = len(merged)
+		merged = append(merged, item)
+		productIDs = append(productIDs, item.ProductID)
+	}
+
+	products, err := s.productClient.GetProducts(client.WithFreshProducts(ctx), productIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product info: %w", err)
+	}
+	for _, item := range merged {
+		product := products[item.ProductID]
+		if product == nil || (product.Status != "" && product.Status != "active") {
+			return nil, fmt.Errorf("%w: product %s is not available", ErrInvalidSubscription, item.ProductID)
+		}
+	}
+	return merged, nil
+}
+
+// nextRunAfter 從 from 依頻率順延，直到晚於 now
+func nextRunAfter(frequency model.SubscriptionFrequency, from time.Time, anchorDay int, now time.Time) time.Time {
+	next := from
+	for !next.After(now) {
+		next = frequency.Next(next, anchorDay)
+	}
+	return next
+}
+
+// appendRun 追加執行紀錄，只保留最近幾筆
+func appendRun(runs []model.SubscriptionRun, run model.SubscriptionRun) []model.SubscriptionRun {
+	runs = append(runs, run)
+	if len(runs) > maxSubscriptionRuns {
+		runs = runs[len(runs)-maxSubscriptionRuns:]
+	}
+	return runs
+}
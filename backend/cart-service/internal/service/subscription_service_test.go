@@ -0,0 +1,90 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/model"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 9, 0, 0, 0, time.UTC)
+}
+
+func TestSubscriptionFrequencyNext(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		frequency model.SubscriptionFrequency
+		from      time.Time
+		anchorDay int
+		want      time.Time
+	}{
+		{name: "weekly", frequency: model.SubscriptionFrequencyWeekly, from: date(2026, 1, 29), want: date(2026, 2, 5)},
+		{name: "biweekly", frequency: model.SubscriptionFrequencyBiweekly, from: date(2026, 12, 25), want: date(2027, 1, 8)},
+		{name: "monthly", frequency: model.SubscriptionFrequencyMonthly, from: date(2026, 1, 15), anchorDay: 15, want: date(2026, 2, 15)},
+		{name: "monthly across year end", frequency: model.SubscriptionFrequencyMonthly, from: date(2026, 12, 10), anchorDay: 10, want: date(2027, 1, 10)},
+		{name: "bimonthly", frequency: model.SubscriptionFrequencyBimonthly, from: date(2026, 11, 5), anchorDay: 5, want: date(2027, 1, 5)},
+		{name: "month end clamped to february", frequency: model.SubscriptionFrequencyMonthly, from: date(2026, 1, 31), anchorDay: 31, want: date(2026, 2, 28)},
+		{name: "month end clamped to leap february", frequency: model.SubscriptionFrequencyMonthly, from: date(2028, 1, 31), anchorDay: 31, want: date(2028, 2, 29)},
+		{name: "clamped day returns to anchor", frequency: model.SubscriptionFrequencyMonthly, from: date(2026, 2, 28), anchorDay: 31, want: date(2026, 3, 31)},
+		{name: "clamped to 30 day month", frequency: model.SubscriptionFrequencyMonthly, from: date(2026, 3, 31), anchorDay: 31, want: date(2026, 4, 30)},
+		{name: "bimonthly month end", frequency: model.SubscriptionFrequencyBimonthly, from: date(2026, 12, 31), anchorDay: 31, want: date(2027, 2, 28)},
+		{name: "missing anchor uses current day", frequency: model.SubscriptionFrequencyMonthly, from: date(2026, 3, 20), want: date(2026, 4, 20)},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.frequency.Next(tc.from, tc.anchorDay); !got.Equal(tc.want) {
+				t.Errorf("Next(%s) = %s, want %s", tc.from.Format(time.DateOnly), got.Format(time.DateOnly), tc.want.Format(time.DateOnly))
+			}
+		})
+	}
+}
+
+func TestNextRunAfter(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		frequency model.SubscriptionFrequency
+		from      time.Time
+		anchorDay int
+		now       time.Time
+		want      time.Time
+	}{
+		{name: "next period", frequency: model.SubscriptionFrequencyMonthly, from: date(2026, 1, 31), anchorDay: 31, now: date(2026, 1, 31), want: date(2026, 2, 28)},
+		{name: "skips missed periods", frequency: model.SubscriptionFrequencyWeekly, from: date(2026, 3, 2), now: date(2026, 3, 20), want: date(2026, 3, 23)},
+		{name: "keeps anchor across clamped months", frequency: model.SubscriptionFrequencyMonthly, from: date(2026, 1, 31), anchorDay: 31, now: date(2026, 3, 1), want: date(2026, 3, 31)},
+		{name: "future schedule kept", frequency: model.SubscriptionFrequencyBiweekly, from: date(2026, 5, 1), now: date(2026, 4, 1), want: date(2026, 5, 1)},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := nextRunAfter(tc.frequency, tc.from, tc.anchorDay, tc.now); !got.Equal(tc.want) {
+				t.Errorf("nextRunAfter = %s, want %s", got.Format(time.DateOnly), tc.want.Format(time.DateOnly))
+			}
+		})
+	}
+}
+
+func TestRetryAdvancesFromOriginalSchedule(t *testing.T) {
+	scheduledAt := date(2026, 1, 31)
+	retryAt := date(2026, 2, 1)
+	sub := model.Subscription{
+		Frequency: model.SubscriptionFrequencyMonthly,
+		AnchorDay: 31,
+		NextRunAt: retryAt,
+		RetryOf:   &scheduledAt,
+	}
+
+	if got := sub.ScheduledRunAt(); !got.Equal(scheduledAt) {
+		t.Fatalf("ScheduledRunAt = %s, want the original schedule %s", got.Format(time.DateOnly), scheduledAt.Format(time.DateOnly))
+	}
+	// 重試成功後從原定排程推算，不因重試延後
+	if got := nextRunAfter(sub.Frequency, sub.ScheduledRunAt(), sub.AnchorDay, retryAt); !got.Equal(date(2026, 2, 28)) {
+		t.Errorf("next run after retry = %s, want 2026-02-28", got.Format(time.DateOnly))
+	}
+	// 重試拖過下一期時直接跳到之後的排程
+	if got := nextRunAfter(sub.Frequency, sub.ScheduledRunAt(), sub.AnchorDay, date(2026, 3, 1)); !got.Equal(date(2026, 3, 31)) {
+		t.Errorf("next run after late retry = %s, want 2026-03-31", got.Format(time.DateOnly))
+	}
+
+	sub.RetryOf = nil
+	if got := sub.ScheduledRunAt(); !got.Equal(retryAt) {
+		t.Errorf("ScheduledRunAt without retry = %s, want NextRunAt %s", got.Format(time.DateOnly), retryAt.Format(time.DateOnly))
+	}
+}
@@ -0,0 +1,381 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/client"
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/model"
+	"github.com/kevinsuu/OrderManagerSystem/cart-service/internal/repository"
+)
+
+var (
+	ErrWishlistListNotFound = repository.ErrWishlistListNotFound
+	ErrWishlistItemNotFound = errors.New("product not found in wishlist list")
+	ErrWishlistListPrivate  = errors.New("wishlist list is private")
+	ErrWishlistListEmpty    = errors.New("wishlist list has no items")
+	ErrWishlistListLimit    = repository.ErrWishlistListLimit
+	ErrWishlistListDefault  = errors.New("default wishlist list cannot be deleted")
+	ErrInvalidWishlistList  = errors.New("wishlist list name cannot be empty")
+)
+
+// maxWishlistLists 每位用戶可建立的具名清單上限，不含預設收藏清單
+const maxWishlistLists = 50
+
+// WishlistListService 具名收藏清單服務接口
+type WishlistListService interface {
+	CreateList(ctx context.Context, userID string, req *model.CreateWishlistListRequest) (*model.WishlistListResponse, error)
+	GetLists(ctx context.Context, userID string) ([]model.WishlistListResponse, error)
+	GetList(ctx context.Context, userID, listID string) (*model.WishlistListResponse, error)
+	UpdateList(ctx context.Context, userID, listID string, req *model.UpdateWishlistListRequest) (*model.WishlistListResponse, error)
+	DeleteList(ctx context.Context, userID, listID string) error
+	AddItem(ctx context.Context, userID, listID string, req *model.AddWishlistListItemRequest) (*model.WishlistListResponse, error)
+	UpdateItem(ctx context.Context, userID, listID, productID string, req *model.UpdateWishlistListItemRequest) (*model.WishlistListResponse, error)
+	RemoveItem(ctx context.Context, userID, listID, productID string) (*model.WishlistListResponse, error)
+	RotateShareToken(ctx context.Context, userID, listID string) (*model.WishlistListResponse, error)
+	GetSharedList(ctx context.Context, token string) (*model.WishlistListResponse, error)
+	AddSharedListToCart(ctx context.Context, userID, token string) (*model.ReorderResponse, error)
+}
+
+type wishlistListService struct {
+	listRepo      repository.WishlistListRepository
+	productClient client.ProductClient
+	cartService   CartService
+}
+
+// NewWishlistListService 創建具名收藏清單服務實例
+func NewWishlistListService(listRepo repository.WishlistListRepository, productClient client.ProductClient, cartService CartService) WishlistListService {
+	return &wishlistListService{
+		listRepo:      listRepo,
+		productClient: productClient,
+		cartService:   cartService,
+	}
+}
+
+// CreateList 建立具名清單，公開清單同時產生分享連結
+func (s *wishlistListService) CreateList(ctx context.Context, userID string, req *model.CreateWishlistListRequest) (*model.WishlistListResponse, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, ErrInvalidWishlistList
+	}
+
+	now := time.Now()
+	list := &model.WishlistList{
+		ID:          uuid.New().String(),
+		UserID:      userID,
+		Name:        name,
+		Description: strings.TrimSpace(req.Description),
+		Visibility:  model.WishlistVisibilityPrivate,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if req.Visibility == model.WishlistVisibilityPublic {
+		token, err := newShareToken()
+		if err != nil {
+			return nil, err
+		}
+		list.Visibility = model.WishlistVisibilityPublic
+		list.ShareToken = token
+	}
+	if err := s.listRepo.Create(ctx, list, maxWishlistLists); err != nil {
+		return nil, err
+	}
+	return s.toResponse(ctx, list, false), nil
+}
+
+// GetLists 獲取用戶的具名清單，不包含商品詳細資訊
+func (s *wishlistListService) GetLists(ctx context.Context, userID string) ([]model.WishlistListResponse, error) {
+	lists, err := s.listRepo.ListByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]model.WishlistListResponse, 0, len(lists))
+	for i := range lists {
+		result = append(result, *toWishlistListResponse(&lists[i], nil, false))
+	}
+	return result, nil
+}
+
+// GetList 獲取具名清單與商品詳細資訊
+func (s *wishlistListService) GetList(ctx context.Context, userID, listID string) (*model.WishlistListResponse, error) {
+	list, err := s.listRepo.GetByID(ctx, userID, listID)
+	if err != nil {
+		return nil, err
+	}
+	if list == nil {
+		return nil, ErrWishlistListNotFound
+	}
+	return s.toResponse(ctx, list, false), nil
+}
+
+// UpdateList 更新名稱、說明與公開設定；改為公開時產生分享連結，改為私人時刪除分享連結
+func (s *wishlistListService) UpdateList(ctx context.Context, userID, listID string, req *model.UpdateWishlistListRequest) (*model.WishlistListResponse, error) {
+	var previousToken string
+	list, err := s.listRepo.Update(ctx, userID, listID, func(list *model.WishlistList) error {
+		previousToken = list.ShareToken
+		if req.Name != nil {
+			name := strings.TrimSpace(*req.Name)
+			if name == "" {
+				return ErrInvalidWishlistList
+			}
+			list.Name = name
+		}
+		if req.Description != nil {
+			list.Description = strings.TrimSpace(*req.Description)
+		}
+		switch req.Visibility {
+		case model.WishlistVisibilityPublic:
+			list.Visibility = model.WishlistVisibilityPublic
+			if list.ShareToken == "" {
+				token, err := newShareToken()
+				if err != nil {
+					return err
+				}
+				list.ShareToken = token
+			}
+		case model.WishlistVisibilityPrivate:
+			list.Visibility = model.WishlistVisibilityPrivate
+			list.ShareToken = ""
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.listRepo.SetShare(ctx, list, previousToken); err != nil {
+		return nil, err
+	}
+	return s.toResponse(ctx, list, false), nil
+}
+
+// DeleteList 刪除具名清單與其分享連結，預設收藏清單無法刪除
+func (s *wishlistListService) DeleteList(ctx context.Context, userID, listID string) error {
+	if listID == model.DefaultWishlistListID {
+		return ErrWishlistListDefault
+	}
+	list, err := s.listRepo.GetByID(ctx, userID, listID)
+	if err != nil {
+		return err
+	}
+	if list == nil {
+		return ErrWishlistListNotFound
+	}
+	return s.listRepo.Delete(ctx, list)
+}
+
+// AddItem 加入商品至具名清單，已存在時更新備註與數量，數量預設為 1
+func (s *wishlistListService) AddItem(ctx context.Context, userID, listID string, req *model.AddWishlistListItemRequest) (*model.WishlistListResponse, error) {
+	if _, err := s.productClient.GetProductById(ctx, req.ProductID); err != nil {
+		log.Printf("Error checking product %s: %v", req.ProductID, err)
+		return nil, ErrProductNotFound
+	}
+
+	quantity := req.Quantity
+	if quantity <= 0 {
+		quantity = 1
+	}
+	list, err := s.listRepo.Update(ctx, userID, listID, func(list *model.WishlistList) error {
+		if list.Items == nil {
+			list.Items = make(map[string]model.WishlistListItem)
+		}
+		item, ok := list.Items[req.ProductID]
+		if !ok {
+			item = model.WishlistListItem{ProductID: req.ProductID, AddedAt: time.Now()}
+		}
+		item.Note = strings.TrimSpace(req.Note)
+		item.Quantity = quantity
+		list.Items[req.ProductID] = item
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.toResponse(ctx, list, false), nil
+}
+
+// UpdateItem 更新具名清單商品的備註或數量
+func (s *wishlistListService) UpdateItem(ctx context.Context, userID, listID, productID string, req *model.UpdateWishlistListItemRequest) (*model.WishlistListResponse, error) {
+	list, err := s.listRepo.Update(ctx, userID, listID, func(list *model.WishlistList) error {
+		item, ok := list.Items[productID]
+		if !ok {
+			return ErrWishlistItemNotFound
+		}
+		if req.Note != nil {
+			item.Note = strings.TrimSpace(*req.Note)
+		}
+		if req.Quantity != nil {
+			item.Quantity = *req.Quantity
+		}
+		list.Items[productID] = item
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.toResponse(ctx, list, false), nil
+}
+
+// RemoveItem 從具名清單移除商品
+func (s *wishlistListService) RemoveItem(ctx context.Context, userID, listID, productID string) (*model.WishlistListResponse, error) {
+	list, err := s.listRepo.Update(ctx, userID, listID, func(list *model.WishlistList) error {
+		if _, ok := list.Items[productID]; !ok {
+			return ErrWishlistItemNotFound
+		}
+		delete(list.Items, productID)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.toResponse(ctx, list, false), nil
+}
+
+// RotateShareToken 重新產生公開清單的分享連結，舊連結隨即失效
+func (s *wishlistListService) RotateShareToken(ctx context.Context, userID, listID string) (*model.WishlistListResponse, error) {
+	var previousToken string
+	list, err := s.listRepo.Update(ctx, userID, listID, func(list *model.WishlistList) error {
+		if list.Visibility != model.WishlistVisibilityPublic {
+			return ErrWishlistListPrivate
+		}
+		token, err := newShareToken()
+		if err != nil {
+			return err
+		}
+		previousToken = list.ShareToken
+		list.ShareToken = token
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.listRepo.SetShare(ctx, list, previousToken); err != nil {
+		return nil, err
+	}
+	return s.toResponse(ctx, list, false), nil
+}
+
+// GetSharedList 依分享連結獲取公開清單，不返回擁有者與分享連結
+func (s *wishlistListService) GetSharedList(ctx context.Context, token string) (*model.WishlistListResponse, error) {
+	list, err := s.sharedList(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	return s.toResponse(ctx, list, true), nil
+}
+
+// AddSharedListToCart 將公開清單的商品依想要的數量加入檢視者自己的購物車
+func (s *wishlistListService) AddSharedListToCart(ctx context.Context, userID, token string) (*model.ReorderResponse, error) {
+	list, err := s.sharedList(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	if len(list.Items) == 0 {
+		return nil, ErrWishlistListEmpty
+	}
+
+	entries := sortedWishlistListItems(list.Items)
+	items := make([]model.OrderItem, 0, len(entries))
+	for _, entry := range entries {
+		items = append(items, model.OrderItem{
+			ProductID: entry.ProductID,
+			Quantity:  entry.Quantity,
+		})
+	}
+	return s.cartService.AddItems(ctx, userID, items)
+}
+
+// sharedList 依分享連結獲取清單；連結已失效或清單已改為私人時視為不存在
+func (s *wishlistListService) sharedList(ctx context.Context, token string) (*model.WishlistList, error) {
+	share, err := s.listRepo.GetShare(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	if share == nil {
+		return nil, ErrWishlistListNotFound
+	}
+	list, err := s.listRepo.GetByID(ctx, share.UserID, share.ListID)
+	if err != nil {
+		return nil, err
+	}
+	if list == nil || list.Visibility != model.WishlistVisibilityPublic || list.ShareToken != token {
+		return nil, ErrWishlistListNotFound
+	}
+	return list, nil
+}
+
+// toResponse 轉換為響應並補上商品詳細資訊，查詢失敗時只返回清單本身
+func (s *wishlistListService) toResponse(ctx context.Context, list *model.WishlistList, shared bool) *model.WishlistListResponse {
+	var products map[string]*client.ProductInfo
+	if len(list.Items) > 0 {
+		productIDs := make([]string, 0, len(list.Items))
+		for productID := range list.Items {
+			productIDs = append(productIDs, productID)
+		}
+		var err error
+		products, err = s.productClient.GetProducts(ctx, productIDs)
+		if err != nil {
+			log.Printf("Error getting product details for wishlist list %s: %v", list.ID, err)
+		}
+	}
+	return toWishlistListResponse(list, products, shared)
+}
+
+// toWishlistListResponse 轉換為響應，shared 為 true 時隱藏擁有者與分享連結
+func toWishlistListResponse(list *model.WishlistList, products map[string]*client.ProductInfo, shared bool) *model.WishlistListResponse {
+	items := sortedWishlistListItems(list.Items)
+	for i, item := range items {
+		if product, ok := products[item.ProductID]; ok {
+			items[i].Product = product.ToModel()
+		}
+	}
+	response := &model.WishlistListResponse{
+		ID:          list.ID,
+		UserID:      list.UserID,
+		Name:        list.Name,
+		Description: list.Description,
+		Visibility:  list.Visibility,
+		ShareToken:  list.ShareToken,
+		Items:       items,
+		ItemCount:   len(items),
+		CreatedAt:   list.CreatedAt,
+		UpdatedAt:   list.UpdatedAt,
+	}
+	if shared {
+		response.UserID = ""
+		response.ShareToken = ""
+	}
+	return response
+}
+
+// sortedWishlistListItems 依加入時間由新到舊排列，時間相同時以商品ID排序
+func sortedWishlistListItems(items map[string]model.WishlistListItem) []model.WishlistListItem {
+	result := make([]model.WishlistListItem, 0, len(items))
+	for productID, item := range items {
+		item.ProductID = productID
+		result = append(result, item)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].AddedAt.Equal(result[j].AddedAt) {
+			return result[i].AddedAt.After(result[j].AddedAt)
+		}
+		return result[i].ProductID < result[j].ProductID
+	})
+	return result
+}
+
+// newShareToken 產生無法猜測的分享連結代碼
+func newShareToken() (string, error) {
+	buf := make([]byte, 18)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("error generating share token: %v", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
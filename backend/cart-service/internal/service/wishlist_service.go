@@ -17,6 +17,7 @@ type WishlistService interface {
 	GetWishlist(ctx context.Context, userId string, page, limit int) (*model.WishlistResponse, error)
 	GetWishlistWithProductDetails(ctx context.Context, userId string, page, limit int) (*model.WishlistResponse, error)
 	IsProductInWishlist(ctx context.Context, userId, productId string) (bool, error)
+	GetWishlistIds(ctx context.Context, userId string) (*model.WishlistIdsResponse, error)
 }
 
 // wishlistService 實現 WishlistService 接口
@@ -78,16 +79,27 @@ func (s *wishlistService) GetWishlistWithProductDetails(ctx context.Context, use
 		return wishlistResp, nil
 	}
 
+	// 一次查詢所有商品詳細資訊
+	productIDs := make([]string, 0, len(wishlistResp.Wishlist))
+	for _, item := range wishlistResp.Wishlist {
+		productIDs = append(productIDs, item.ProductId)
+	}
+	products, err := s.productClient.GetProducts(ctx, productIDs)
+	if err != nil {
+		log.Printf("Error getting product details for wishlist: %v", err)
+		return wishlistResp, nil
+	}
+
 	// 豐富商品詳細資訊
 	for i, item := range wishlistResp.Wishlist {
-		product, err := s.productClient.GetProductById(ctx, item.ProductId)
-		if err != nil {
-			log.Printf("Error getting product details for %s: %v", item.ProductId, err)
+		product, ok := products[item.ProductId]
+		if !ok {
+			log.Printf("Product %s in wishlist not found", item.ProductId)
 			continue
 		}
 
 		// 在這裡可以設置更多商品詳細資訊
-		wishlistResp.Wishlist[i].Product = product
+		wishlistResp.Wishlist[i].Product = product.ToModel()
 	}
 
 	return wishlistResp, nil
@@ -97,3 +109,12 @@ func (s *wishlistService) GetWishlistWithProductDetails(ctx context.Context, use
 func (s *wishlistService) IsProductInWishlist(ctx context.Context, userId, productId string) (bool, error) {
 	return s.wishlistRepo.IsProductInWishlist(ctx, userId, productId)
 }
+
+// GetWishlistIds 獲取使用者收藏的商品ID，供商品列表標示收藏狀態
+func (s *wishlistService) GetWishlistIds(ctx context.Context, userId string) (*model.WishlistIdsResponse, error) {
+	ids, err := s.wishlistRepo.GetWishlistIds(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	return &model.WishlistIdsResponse{WishlistIds: ids}, nil
+}
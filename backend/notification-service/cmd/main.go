@@ -43,7 +43,7 @@ func main() {
 	notificationHandler := handler.NewHandler(notificationService)
 
 	// 設置 Gin 路由
-	router := setupRouter(notificationHandler, cfg.JWT.Secret)
+	router := setupRouter(notificationHandler, cfg.JWT.Secret, cfg.JWT.ServiceSecret)
 
 	// 創建 HTTP 服務器
 	srv := &http.Server{
@@ -80,7 +80,7 @@ func main() {
 }
 
 // setupRouter 設置路由
-func setupRouter(h *handler.Handler, jwtSecret string) *gin.Engine {
+func setupRouter(h *handler.Handler, jwtSecret, serviceSecret string) *gin.Engine {
 	router := gin.Default()
 
 	// 中間件
@@ -104,7 +104,7 @@ func setupRouter(h *handler.Handler, jwtSecret string) *gin.Engine {
 	api := router.Group("/api/v1")
 	{
 		// 添加認證中間件
-		api.Use(middleware.AuthMiddleware(jwtSecret))
+		api.Use(middleware.AuthMiddleware(jwtSecret, serviceSecret))
 
 		notifications := api.Group("/notifications")
 		{
@@ -27,7 +27,8 @@ type FirebaseConfig struct {
 
 // JWTConfig JWT配置
 type JWTConfig struct {
-	Secret string
+	Secret        string
+	ServiceSecret string // 服務間調用的 token 密鑰，與用戶 token 分開
 }
 
 // LoadConfig 加載配置
@@ -48,7 +49,8 @@ func LoadConfig() *Config {
 // loadJWTConfig 加載 JWT 配置
 func loadJWTConfig() JWTConfig {
 	return JWTConfig{
-		Secret: os.Getenv("JWT_SECRET"),
+		Secret:        os.Getenv("JWT_SECRET"),
+		ServiceSecret: os.Getenv("SERVICE_JWT_SECRET"),
 	}
 }
 
@@ -17,6 +17,7 @@ import (
 	"github.com/kevinsuu/OrderManagerSystem/payment-service/internal/middleware"
 	"github.com/kevinsuu/OrderManagerSystem/payment-service/internal/repository"
 	"github.com/kevinsuu/OrderManagerSystem/payment-service/internal/service"
+	"github.com/kevinsuu/OrderManagerSystem/pkg/idempotency"
 )
 
 func main() {
@@ -35,6 +36,7 @@ func main() {
 
 	// 初始化依賴
 	paymentRepo := repository.NewPaymentRepository(fb.Database)
+	idempotencyStore := idempotency.NewStore(fb.Database)
 	paymentService := service.NewPaymentService(paymentRepo)
 	paymentHandler := handler.NewHandler(paymentService)
 
@@ -49,8 +51,8 @@ func main() {
 	router.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"*"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Length", "Content-Type", "Authorization", "Accept"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowHeaders:     []string{"Origin", "Content-Length", "Content-Type", "Authorization", "Accept", idempotency.KeyHeader},
+		ExposeHeaders:    []string{"Content-Length", "Idempotent-Replayed"},
 		AllowCredentials: false,
 		MaxAge:           12 * time.Hour,
 	}))
@@ -62,17 +64,19 @@ func main() {
 	api := router.Group("/api/v1")
 	{
 		// 添加認證中間件
-		api.Use(middleware.AuthMiddleware(cfg.JWT.Secret))
+		api.Use(middleware.AuthMiddleware(cfg.JWT.Secret, cfg.JWT.ServiceSecret))
+		idempotent := idempotency.Middleware(idempotencyStore, cfg.Idempotency.TTL)
 
 		payments := api.Group("/payments")
 		{
 			// 支付管理（按具體到通用的順序排列）
 			payments.GET("/order/:orderId", paymentHandler.GetPaymentByOrderID) // 最具體的路由放在前面
+			payments.POST("/order/batch", middleware.RequireRole("admin", "service"), paymentHandler.GetPaymentsByOrderIDs)
 			payments.GET("/user/:userId", paymentHandler.GetUserPayments)
-			payments.POST("/refund", paymentHandler.RefundPayment)
+			payments.POST("/refund", middleware.RequireRole("admin", "service"), idempotent, paymentHandler.RefundPayment) // 只允許管理員與內部服務退款
 			payments.POST("/:id/process", paymentHandler.ProcessPayment)
 			payments.POST("/:id/cancel", paymentHandler.CancelPayment)
-			payments.POST("/", paymentHandler.CreatePayment)
+			payments.POST("/", idempotent, paymentHandler.CreatePayment)
 			payments.GET("/", paymentHandler.ListPayments)
 			payments.GET("/:id", paymentHandler.GetPayment) // 最通用的路由放在最後
 		}
@@ -4,18 +4,27 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strconv"
+	"time"
 )
 
 // Config 應用配置
 type Config struct {
-	Server   ServerConfig
-	Firebase FirebaseConfig
-	JWT      JWTConfig
+	Server      ServerConfig
+	Firebase    FirebaseConfig
+	JWT         JWTConfig
+	Idempotency IdempotencyConfig
 }
 
 // JWTConfig JWT 配置
 type JWTConfig struct {
-	Secret string
+	Secret        string
+	ServiceSecret string // 服務間調用的 token 密鑰，與用戶 token 分開
+}
+
+// IdempotencyConfig Idempotency-Key 記錄配置
+type IdempotencyConfig struct {
+	TTL time.Duration // 記錄保留時間，期間內的重試會重播原響應
 }
 
 // ServerConfig 服務器配置
@@ -40,6 +49,10 @@ func LoadConfig() *Config {
 			CredentialsFile: os.Getenv("FIREBASE_CREDENTIALS"),
 			ProjectID:       os.Getenv("FIREBASE_PROJECT_ID"),
 		},
+		JWT: loadJWTConfig(),
+		Idempotency: IdempotencyConfig{
+			TTL: time.Duration(getEnvAsInt("IDEMPOTENCY_TTL_HOURS", 24)) * time.Hour,
+		},
 	}
 }
 
@@ -59,8 +72,19 @@ func loadJWTConfig() JWTConfig {
 		log.Printf("Warning: JWT_SECRET environment variable is not set")
 	}
 	return JWTConfig{
-		Secret: secret,
+		Secret:        secret,
+		ServiceSecret: os.Getenv("SERVICE_JWT_SECRET"),
+	}
+}
+
+// getEnvAsInt 獲取整數環境變量，如果不存在或格式錯誤則返回默認值
+func getEnvAsInt(key string, defaultValue int) int {
+	if value, exists := os.LookupEnv(key); exists {
+		if intVal, err := strconv.Atoi(value); err == nil {
+			return intVal
+		}
 	}
+	return defaultValue
 }
 
 // getEnv 獲取環境變量，如果不存在則返回默認值
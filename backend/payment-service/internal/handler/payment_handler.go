@@ -106,6 +106,23 @@ func (h *Handler) ListPayments(c *gin.Context) {
 	c.JSON(http.StatusOK, payments)
 }
 
+// GetPaymentsByOrderIDs 依訂單ID批次獲取支付
+func (h *Handler) GetPaymentsByOrderIDs(c *gin.Context) {
+	var req model.BatchPaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	payments, err := h.paymentService.GetPaymentsByOrderIDs(c.Request.Context(), req.OrderIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, payments)
+}
+
 // ProcessPayment 處理支付
 func (h *Handler) ProcessPayment(c *gin.Context) {
 	id := c.Param("id")
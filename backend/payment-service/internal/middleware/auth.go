@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"errors"
 	"net/http"
 	"strings"
 
@@ -8,7 +9,14 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 )
 
-func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
+// serviceRole 服務間調用 token 的角色，只接受以服務密鑰簽發
+const serviceRole = "service"
+
+var errInvalidToken = errors.New("invalid token")
+
+// AuthMiddleware 驗證以 jwtSecret 簽發的用戶 token，或以 serviceSecret 簽發的服務 token；
+// 以用戶密鑰簽發卻帶有 service 角色的 token 一律拒絕
+func AuthMiddleware(jwtSecret, serviceSecret string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -24,23 +32,13 @@ func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
 			return
 		}
 
-		token, err := jwt.Parse(tokenParts[1], func(token *jwt.Token) (interface{}, error) {
-			return []byte(jwtSecret), nil
-		})
-
-		if err != nil || !token.Valid {
+		claims, err := parseToken(tokenParts[1], jwtSecret, serviceSecret)
+		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
 			c.Abort()
 			return
 		}
 
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token claims"})
-			c.Abort()
-			return
-		}
-
 		userID, ok := claims["sub"].(string)
 		if !ok {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid user id"})
@@ -48,7 +46,60 @@ func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
 			return
 		}
 
+		role, _ := claims["role"].(string)
 		c.Set("userID", userID)
+		c.Set("role", role)
 		c.Next()
 	}
 }
+
+// parseToken 先以用戶密鑰驗證，失敗時改以服務密鑰驗證且角色必須為 service
+func parseToken(tokenString, jwtSecret, serviceSecret string) (jwt.MapClaims, error) {
+	if claims, err := verifyToken(tokenString, jwtSecret); err == nil {
+		if claims["role"] == serviceRole {
+			return nil, errInvalidToken
+		}
+		return claims, nil
+	}
+	if serviceSecret == "" {
+		return nil, errInvalidToken
+	}
+	claims, err := verifyToken(tokenString, serviceSecret)
+	if err != nil {
+		return nil, err
+	}
+	if claims["role"] != serviceRole {
+		return nil, errInvalidToken
+	}
+	return claims, nil
+}
+
+func verifyToken(tokenString, secret string) (jwt.MapClaims, error) {
+	claims := jwt.MapClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		return []byte(secret), nil
+	}, jwt.WithValidMethods([]string{"HS256"}))
+	if err != nil {
+		return nil, err
+	}
+	if !token.Valid {
+		return nil, errInvalidToken
+	}
+	return claims, nil
+}
+
+// RequireRole 限制只有指定角色可以訪問，需在 AuthMiddleware 之後使用
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := c.GetString("role")
+		for _, r := range roles {
+			if role == r {
+				c.Next()
+				return
+			}
+		}
+
+		c.JSON(http.StatusForbidden, gin.H{"error": "forbidden"})
+		c.Abort()
+	}
+}
@@ -8,11 +8,12 @@ import (
 type PaymentStatus string
 
 const (
-	PaymentStatusPending   PaymentStatus = "pending"
-	PaymentStatusSuccess   PaymentStatus = "success"
-	PaymentStatusFailed    PaymentStatus = "failed"
-	PaymentStatusRefunded  PaymentStatus = "refunded"
-	PaymentStatusCancelled PaymentStatus = "cancelled"
+	PaymentStatusPending           PaymentStatus = "pending"
+	PaymentStatusSuccess           PaymentStatus = "success"
+	PaymentStatusFailed            PaymentStatus = "failed"
+	PaymentStatusRefunded          PaymentStatus = "refunded"
+	PaymentStatusCancelled         PaymentStatus = "cancelled"
+	PaymentStatusPartiallyRefunded PaymentStatus = "partially_refunded"
 )
 
 // PaymentMethod 支付方式
@@ -27,19 +28,20 @@ const (
 
 // Payment 支付模型
 type Payment struct {
-	ID            string        `json:"id"`
-	OrderID       string        `json:"orderId"`
-	UserID        string        `json:"userId"`
-	Amount        float64       `json:"amount"`
-	Currency      string        `json:"currency"`
-	Status        PaymentStatus `json:"status"`
-	Method        PaymentMethod `json:"method"`
-	TransactionID string        `json:"transactionId"`
-	ErrorMessage  string        `json:"errorMessage,omitempty"`
-	Metadata      string        `json:"metadata,omitempty"` // JSON 字符串，存儲額外信息
-	CreatedAt     time.Time     `json:"createdAt"`
-	UpdatedAt     time.Time     `json:"updatedAt"`
-	DeletedAt     *time.Time    `json:"deletedAt,omitempty"`
+	ID             string        `json:"id"`
+	OrderID        string        `json:"orderId"`
+	UserID         string        `json:"userId"`
+	Amount         float64       `json:"amount"`
+	RefundedAmount float64       `json:"refundedAmount,omitempty"` // 累計退款金額
+	Currency       string        `json:"currency"`
+	Status         PaymentStatus `json:"status"`
+	Method         PaymentMethod `json:"method"`
+	TransactionID  string        `json:"transactionId"`
+	ErrorMessage   string        `json:"errorMessage,omitempty"`
+	Metadata       string        `json:"metadata,omitempty"` // JSON 字符串，存儲額外信息
+	CreatedAt      time.Time     `json:"createdAt"`
+	UpdatedAt      time.Time     `json:"updatedAt"`
+	DeletedAt      *time.Time    `json:"deletedAt,omitempty"`
 }
 
 // Refund 退款模型
@@ -70,6 +72,16 @@ type RefundRequest struct {
 	Reason    string  `json:"reason" binding:"required"`
 }
 
+// BatchPaymentRequest 依訂單ID批次查詢支付請求
+type BatchPaymentRequest struct {
+	OrderIDs []string `json:"orderIds" binding:"required,min=1,max=100"`
+}
+
+// BatchPaymentResponse 依訂單ID批次查詢支付響應，沒有支付的訂單不會出現在結果中
+type BatchPaymentResponse struct {
+	Payments map[string]Payment `json:"payments"` // 訂單ID 對應的支付
+}
+
 // PaymentResponse 支付響應
 type PaymentResponse struct {
 	Payment
@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"fmt"
+	"sync"
 
 	"firebase.google.com/go/db"
 	"github.com/kevinsuu/OrderManagerSystem/payment-service/internal/model"
@@ -14,9 +15,11 @@ type PaymentRepository interface {
 	GetByID(ctx context.Context, id string) (*model.Payment, error)
 	Update(ctx context.Context, payment *model.Payment) error
 	GetByOrderID(ctx context.Context, orderID string) (*model.Payment, error)
+	GetByOrderIDs(ctx context.Context, orderIDs []string) (map[string]*model.Payment, error)
 	GetByUserID(ctx context.Context, userID string, page, limit int) ([]model.Payment, int64, error)
 	List(ctx context.Context, page, limit int) ([]model.Payment, int64, error)
 	CreateRefund(ctx context.Context, refund *model.Refund) error
+	ApplyRefund(ctx context.Context, paymentID string, refund *model.Refund, apply func(payment *model.Payment) error) (*model.Payment, error)
 	GetRefundsByPaymentID(ctx context.Context, paymentID string) ([]model.Refund, error)
 }
 
@@ -67,6 +70,41 @@ func (r *paymentRepository) GetByOrderID(ctx context.Context, orderID string) (*
 	return nil, nil
 }
 
+// GetByOrderIDs 並行查詢多筆訂單的支付記錄，返回訂單ID 對應的支付，沒有支付的訂單不會出現在結果中
+func (r *paymentRepository) GetByOrderIDs(ctx context.Context, orderIDs []string) (map[string]*model.Payment, error) {
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		firstErr error
+	)
+	payments := make(map[string]*model.Payment, len(orderIDs))
+	for _, orderID := range orderIDs {
+		wg.Add(1)
+		go func(orderID string) {
+			defer wg.Done()
+			payment, err := r.GetByOrderID(ctx, orderID)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				return
+			}
+			if payment != nil {
+				payments[orderID] = payment
+			}
+		}(orderID)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return payments, nil
+}
+
 // GetByUserID 獲取用戶的支付記錄
 func (r *paymentRepository) GetByUserID(ctx context.Context, userID string, page, limit int) ([]model.Payment, int64, error) {
 	var result map[string]model.Payment
@@ -136,6 +174,48 @@ func (r *paymentRepository) CreateRefund(ctx context.Context, refund *model.Refu
 	return ref.Child(refund.ID).Set(ctx, refund)
 }
 
+// paymentNode 支付節點的完整內容，退款記錄存放於其 refunds 子節點
+type paymentNode struct {
+	model.Payment
+	Refunds map[string]model.Refund `json:"refunds,omitempty"`
+}
+
+// ApplyRefund 以 transaction 在同一個支付節點內檢查並更新支付、寫入退款記錄；
+// 支付不存在時 apply 收到 nil，apply 返回的錯誤原樣返回
+func (r *paymentRepository) ApplyRefund(ctx context.Context, paymentID string, refund *model.Refund, apply func(payment *model.Payment) error) (*model.Payment, error) {
+	var applyErr error
+	var updated model.Payment
+	ref := r.db.NewRef("payments").Child(paymentID)
+	err := ref.Transaction(ctx, func(tn db.TransactionNode) (interface{}, error) {
+		var node paymentNode
+		if err := tn.Unmarshal(&node); err != nil {
+			return nil, err
+		}
+
+		current := &node.Payment
+		if node.ID == "" {
+			current = nil
+		}
+		if applyErr = apply(current); applyErr != nil {
+			return nil, applyErr
+		}
+
+		if node.Refunds == nil {
+			node.Refunds = make(map[string]model.Refund)
+		}
+		node.Refunds[refund.ID] = *refund
+		updated = node.Payment
+		return &node, nil
+	})
+	if applyErr != nil {
+		return nil, applyErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error applying refund: %v", err)
+	}
+	return &updated, nil
+}
+
 // GetRefundsByPaymentID 獲取支付的退款記錄
 func (r *paymentRepository) GetRefundsByPaymentID(ctx context.Context, paymentID string) ([]model.Refund, error) {
 	var result map[string]model.Refund
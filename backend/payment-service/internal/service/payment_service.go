@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math"
 	"time"
 
 	"github.com/google/uuid"
@@ -22,6 +23,7 @@ type PaymentService interface {
 	CreatePayment(ctx context.Context, req *model.CreatePaymentRequest) (*model.Payment, error)
 	GetPayment(ctx context.Context, id string) (*model.PaymentResponse, error)
 	GetPaymentByOrderID(ctx context.Context, orderID string) (*model.PaymentResponse, error)
+	GetPaymentsByOrderIDs(ctx context.Context, orderIDs []string) (*model.BatchPaymentResponse, error)
 	GetUserPayments(ctx context.Context, userID string, page, limit int) (*model.PaymentListResponse, error)
 	ListPayments(ctx context.Context, page, limit int) (*model.PaymentListResponse, error)
 	ProcessPayment(ctx context.Context, id string) error
@@ -106,6 +108,20 @@ func (s *paymentService) GetPaymentByOrderID(ctx context.Context, orderID string
 	}, nil
 }
 
+// GetPaymentsByOrderIDs 依訂單ID批次獲取支付
+func (s *paymentService) GetPaymentsByOrderIDs(ctx context.Context, orderIDs []string) (*model.BatchPaymentResponse, error) {
+	payments, err := s.repo.GetByOrderIDs(ctx, orderIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get payments by order IDs: %w", err)
+	}
+
+	resp := &model.BatchPaymentResponse{Payments: make(map[string]model.Payment, len(payments))}
+	for orderID, payment := range payments {
+		resp.Payments[orderID] = *payment
+	}
+	return resp, nil
+}
+
 // GetUserPayments 獲取用戶支付記錄
 func (s *paymentService) GetUserPayments(ctx context.Context, userID string, page, limit int) (*model.PaymentListResponse, error) {
 	payments, total, err := s.repo.GetByUserID(ctx, userID, page, limit)
@@ -194,28 +210,11 @@ func (s *paymentService) ProcessPayment(ctx context.Context, id string) error {
 	return nil
 }
 
-// RefundPayment 退款
+// RefundPayment 退款，可分次退款直到累計金額等於支付金額
 func (s *paymentService) RefundPayment(ctx context.Context, req *model.RefundRequest) error {
-	payment, err := s.repo.GetByID(ctx, req.PaymentID)
-	if err != nil {
-		return fmt.Errorf("failed to get payment: %w", err)
-	}
-	if payment == nil {
-		return ErrPaymentNotFound
-	}
-
-	if payment.Status != model.PaymentStatusSuccess {
-		return ErrInvalidPaymentStatus
-	}
-
-	if req.Amount > payment.Amount {
-		return ErrInvalidRefundAmount
-	}
-
-	// 創建退款記錄
 	refund := &model.Refund{
 		ID:        uuid.New().String(),
-		PaymentID: payment.ID,
+		PaymentID: req.PaymentID,
 		Amount:    req.Amount,
 		Reason:    req.Reason,
 		Status:    "success", // 簡化處理，直接設置為成功
@@ -223,16 +222,35 @@ func (s *paymentService) RefundPayment(ctx context.Context, req *model.RefundReq
 		UpdatedAt: time.Now(),
 	}
 
-	if err := s.repo.CreateRefund(ctx, refund); err != nil {
-		return fmt.Errorf("failed to create refund: %w", err)
-	}
+	// 檢查可退金額與累加退款金額在同一個 transaction 內完成，避免並發退款超過支付金額
+	_, err := s.repo.ApplyRefund(ctx, req.PaymentID, refund, func(payment *model.Payment) error {
+		if payment == nil {
+			return ErrPaymentNotFound
+		}
+		if payment.Status != model.PaymentStatusSuccess && payment.Status != model.PaymentStatusPartiallyRefunded {
+			return ErrInvalidPaymentStatus
+		}
 
-	// 更新支付狀態
-	payment.Status = model.PaymentStatusRefunded
-	payment.UpdatedAt = time.Now()
+		// 以分為單位比較，避免浮點誤差
+		remaining := math.Round((payment.Amount-payment.RefundedAmount)*100) / 100
+		if math.Round(req.Amount*100) > math.Round(remaining*100) {
+			return ErrInvalidRefundAmount
+		}
 
-	if err := s.repo.Update(ctx, payment); err != nil {
-		return fmt.Errorf("failed to update payment: %w", err)
+		// 更新累計退款金額與支付狀態
+		payment.RefundedAmount = math.Round((payment.RefundedAmount+req.Amount)*100) / 100
+		payment.Status = model.PaymentStatusPartiallyRefunded
+		if math.Round(payment.RefundedAmount*100) >= math.Round(payment.Amount*100) {
+			payment.Status = model.PaymentStatusRefunded
+		}
+		payment.UpdatedAt = time.Now()
+		return nil
+	})
+	if err == ErrPaymentNotFound || err == ErrInvalidPaymentStatus || err == ErrInvalidRefundAmount {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("failed to refund payment: %w", err)
 	}
 
 	return nil
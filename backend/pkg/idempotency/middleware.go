@@ -0,0 +1,135 @@
+// Package idempotency 提供各服務共用的 Idempotency-Key 中間件與 Firebase 記錄存儲
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// KeyHeader 客戶端提供的冪等鍵
+const KeyHeader = "Idempotency-Key"
+
+// Store 冪等請求記錄存儲
+type Store interface {
+	Reserve(ctx context.Context, record *Record) (*Record, bool, error)
+	Complete(ctx context.Context, record *Record) error
+	Delete(ctx context.Context, userID, key string) error
+}
+
+// Middleware 依用戶與 Idempotency-Key 去除重複請求：
+// 相同請求重試時重播先前的響應，同一個 key 搭配不同請求內容時返回 409
+func Middleware(store Store, ttl time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(KeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > 255 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key is too long"})
+			c.Abort()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read request body"})
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		hash := sha256.New()
+		hash.Write([]byte(c.Request.Method + " " + c.FullPath() + "\n"))
+		hash.Write(body)
+
+		now := time.Now()
+		userID := c.GetString("userID")
+		record, created, err := store.Reserve(c.Request.Context(), &Record{
+			UserID:      userID,
+			Key:         key,
+			RequestHash: hex.EncodeToString(hash.Sum(nil)),
+			Status:      StatusProcessing,
+			CreatedAt:   now,
+			ExpiresAt:   now.Add(ttl),
+		})
+		if err != nil {
+			log.Printf("Idempotency store error: %v", err)
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "idempotency store unavailable"})
+			c.Abort()
+			return
+		}
+
+		if !created {
+			requestHash := hex.EncodeToString(hash.Sum(nil))
+			switch {
+			case record.RequestHash != requestHash:
+				c.JSON(http.StatusConflict, gin.H{"error": "Idempotency-Key was already used with a different request"})
+			case record.Status != StatusCompleted:
+				c.JSON(http.StatusConflict, gin.H{"error": "a request with this Idempotency-Key is still in progress"})
+			default:
+				c.Header("Idempotent-Replayed", "true")
+				c.Data(record.ResponseCode, record.ContentType, []byte(record.ResponseBody))
+			}
+			c.Abort()
+			return
+		}
+
+		// handler panic 時釋放 key，避免 key 停留在 processing 直到過期
+		defer func() {
+			if r := recover(); r != nil {
+				release(store, userID, key)
+				panic(r)
+			}
+		}()
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		// 伺服器錯誤或未寫出響應時不記錄，允許客戶端以同一個 key 重試
+		if !recorder.Written() || recorder.Status() >= http.StatusInternalServerError {
+			release(store, userID, key)
+			return
+		}
+
+		record.Status = StatusCompleted
+		record.ResponseCode = recorder.Status()
+		record.ContentType = recorder.Header().Get("Content-Type")
+		record.ResponseBody = recorder.body.String()
+		if err := store.Complete(context.Background(), record); err != nil {
+			log.Printf("Failed to save idempotency record: %v", err)
+		}
+	}
+}
+
+// release 刪除處理中的記錄；請求可能已取消，因此不使用請求的 context
+func release(store Store, userID, key string) {
+	if err := store.Delete(context.Background(), userID, key); err != nil {
+		log.Printf("Failed to release idempotency key: %v", err)
+	}
+}
+
+// responseRecorder 記錄響應內容以便重播
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,24 @@
+package idempotency
+
+import "time"
+
+// Status 冪等請求處理狀態
+type Status string
+
+const (
+	StatusProcessing Status = "processing"
+	StatusCompleted  Status = "completed"
+)
+
+// Record 以用戶與 Idempotency-Key 記錄的請求摘要與響應
+type Record struct {
+	UserID       string    `json:"userId"`
+	Key          string    `json:"key"`
+	RequestHash  string    `json:"requestHash"`
+	Status       Status    `json:"status"`
+	ResponseCode int       `json:"responseCode,omitempty"`
+	ContentType  string    `json:"contentType,omitempty"`
+	ResponseBody string    `json:"responseBody,omitempty"`
+	CreatedAt    time.Time `json:"createdAt"`
+	ExpiresAt    time.Time `json:"expiresAt"`
+}
@@ -0,0 +1,70 @@
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"firebase.google.com/go/db"
+)
+
+type firebaseStore struct {
+	client *db.Client
+}
+
+// NewStore 創建以 Firebase Realtime Database 保存記錄的存儲實例
+func NewStore(client *db.Client) Store {
+	return &firebaseStore{
+		client: client,
+	}
+}
+
+// Reserve 以 transaction 佔用 key；已有未過期記錄時返回該記錄與 false
+func (s *firebaseStore) Reserve(ctx context.Context, record *Record) (*Record, bool, error) {
+	var existing Record
+	created := false
+	err := s.ref(record.UserID, record.Key).Transaction(ctx, func(tn db.TransactionNode) (interface{}, error) {
+		existing = Record{}
+		if err := tn.Unmarshal(&existing); err != nil {
+			return nil, err
+		}
+		// 不存在或已過期時寫入新記錄
+		if existing.Key == "" || time.Now().After(existing.ExpiresAt) {
+			created = true
+			return record, nil
+		}
+		created = false
+		return &existing, nil
+	})
+	if err != nil {
+		return nil, false, fmt.Errorf("error reserving idempotency key: %v", err)
+	}
+	if created {
+		return record, true, nil
+	}
+	return &existing, false, nil
+}
+
+// Complete 保存處理完成的響應
+func (s *firebaseStore) Complete(ctx context.Context, record *Record) error {
+	if err := s.ref(record.UserID, record.Key).Set(ctx, record); err != nil {
+		return fmt.Errorf("error saving idempotency record: %v", err)
+	}
+	return nil
+}
+
+// Delete 刪除記錄，讓失敗的請求可以用同一個 key 重試
+func (s *firebaseStore) Delete(ctx context.Context, userID, key string) error {
+	if err := s.ref(userID, key).Delete(ctx); err != nil {
+		return fmt.Errorf("error deleting idempotency record: %v", err)
+	}
+	return nil
+}
+
+// ref 以雜湊作為節點名稱，避免 key 含有 RTDB 不允許的字元
+func (s *firebaseStore) ref(userID, key string) *db.Ref {
+	sum := sha256.Sum256([]byte(userID + ":" + key))
+	return s.client.NewRef("idempotency_keys").Child(hex.EncodeToString(sum[:]))
+}
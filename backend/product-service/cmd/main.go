@@ -18,6 +18,25 @@ import (
 	"github.com/kevinsuu/OrderManagerSystem/product-service/internal/service"
 )
 
+// startReservationSweeper 定期釋放逾時的庫存預留
+func startReservationSweeper(reservationService service.ReservationService, interval time.Duration) {
+	// 間隔設為 0 時停用清理
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	for range ticker.C {
+		expired, err := reservationService.ExpireReservations(context.Background())
+		if err != nil {
+			log.Printf("Failed to expire stock reservations: %v", err)
+			continue
+		}
+		if expired > 0 {
+			log.Printf("Expired %d stock reservations", expired)
+		}
+	}
+}
+
 func main() {
 	// 加載配置
 	cfg := config.LoadConfig()
@@ -32,13 +51,18 @@ func main() {
 	// 初始化存儲層
 	productRepo := repository.NewProductRepository(fb.Database)
 	categoryRepo := repository.NewCategoryRepository(fb.Database)
+	reservationRepo := repository.NewReservationRepository(fb.Database)
 
 	// 初始化服務層
 	productService := service.NewProductService(productRepo)
 	categoryService := service.NewCategoryService(categoryRepo)
+	reservationService := service.NewReservationService(productRepo, reservationRepo, &service.ReservationServiceConfig{
+		DefaultTTL: cfg.Reservation.DefaultTTL,
+		MaxTTL:     cfg.Reservation.MaxTTL,
+	})
 
 	// 初始化 HTTP 處理器
-	handler := handler.NewHandler(productService, categoryService)
+	handler := handler.NewHandler(productService, categoryService, reservationService)
 
 	// 設置 Gin 路由
 	router := gin.Default()
@@ -69,6 +93,7 @@ func main() {
 		{
 			products.GET("/", handler.ListProducts)
 			products.GET("/search", handler.SearchProducts)
+			products.POST("/batch", handler.GetProductsBatch)
 			products.GET("/:id", handler.GetProduct)
 			products.GET("/category/:id", handler.GetProductsByCategory)
 		}
@@ -84,7 +109,7 @@ func main() {
 
 	// 需要驗證的路由
 	protected := api.Group("")
-	protected.Use(middleware.AuthMiddleware(cfg.JWT.Secret))
+	protected.Use(middleware.AuthMiddleware(cfg.JWT.Secret, cfg.JWT.ServiceSecret))
 	{
 		// 產品管理路由
 		products := protected.Group("/products")
@@ -102,8 +127,21 @@ func main() {
 			categories.PUT("/:id", handler.UpdateCategory)
 			categories.DELETE("/:id", handler.DeleteCategory)
 		}
+
+		// 庫存預留路由，只供 cart service 等內部服務與管理員調用
+		reservations := protected.Group("/stock/reservations")
+		reservations.Use(middleware.RequireRole("service", "admin"))
+		{
+			reservations.POST("/", handler.ReserveStock)
+			reservations.GET("/:id", handler.GetReservation)
+			reservations.POST("/:id/commit", handler.CommitReservation)
+			reservations.POST("/:id/release", handler.ReleaseReservation)
+		}
 	}
 
+	// 背景清理逾時的庫存預留
+	go startReservationSweeper(reservationService, cfg.Reservation.SweepInterval)
+
 	// 啟動服務器
 	go func() {
 		if err := router.Run(cfg.Server.Address); err != nil {
@@ -3,13 +3,16 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
+	"time"
 )
 
 // Config 應用配置
 type Config struct {
-	Server   ServerConfig
-	Firebase FirebaseConfig
-	JWT      JWTConfig
+	Server      ServerConfig
+	Firebase    FirebaseConfig
+	JWT         JWTConfig
+	Reservation ReservationConfig
 }
 
 // ServerConfig 服務器配置
@@ -26,7 +29,15 @@ type FirebaseConfig struct {
 
 // JWTConfig JWT配置
 type JWTConfig struct {
-	Secret string
+	Secret        string
+	ServiceSecret string // 服務間調用的 token 密鑰，與用戶 token 分開
+}
+
+// ReservationConfig 庫存預留配置
+type ReservationConfig struct {
+	DefaultTTL    time.Duration // 未指定 TTL 時的預留時間
+	MaxTTL        time.Duration // 允許的最長預留時間
+	SweepInterval time.Duration // 清理逾時預留的間隔
 }
 
 // LoadConfig 加載配置
@@ -40,7 +51,26 @@ func LoadConfig() *Config {
 			ProjectID:       os.Getenv("FIREBASE_PROJECT_ID"),
 			DatabaseURL:     os.Getenv("FIREBASE_DATABASE_URL"),
 		},
+		JWT: JWTConfig{
+			Secret:        os.Getenv("JWT_SECRET"),
+			ServiceSecret: os.Getenv("SERVICE_JWT_SECRET"),
+		},
+		Reservation: ReservationConfig{
+			DefaultTTL:    time.Duration(getEnvAsInt("RESERVATION_TTL_SECONDS", 15*60)) * time.Second,
+			MaxTTL:        time.Duration(getEnvAsInt("RESERVATION_MAX_TTL_SECONDS", 60*60)) * time.Second,
+			SweepInterval: time.Duration(getEnvAsInt("RESERVATION_SWEEP_SECONDS", 60)) * time.Second,
+		},
+	}
+}
+
+// getEnvAsInt 獲取整數環境變量，如果不存在或格式錯誤則返回默認值
+func getEnvAsInt(key string, defaultValue int) int {
+	if value, exists := os.LookupEnv(key); exists {
+		if intVal, err := strconv.Atoi(value); err == nil {
+			return intVal
+		}
 	}
+	return defaultValue
 }
 
 // getEnv 獲取環境變量，如果不存在則返回默認值
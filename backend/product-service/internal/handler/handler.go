@@ -11,15 +11,17 @@ import (
 
 // Handler 處理所有HTTP請求的結構體
 type Handler struct {
-	productService  service.ProductService
-	categoryService service.CategoryService
+	productService     service.ProductService
+	categoryService    service.CategoryService
+	reservationService service.ReservationService
 }
 
 // NewHandler 創建新的Handler實例
-func NewHandler(productService service.ProductService, categoryService service.CategoryService) *Handler {
+func NewHandler(productService service.ProductService, categoryService service.CategoryService, reservationService service.ReservationService) *Handler {
 	return &Handler{
-		productService:  productService,
-		categoryService: categoryService,
+		productService:     productService,
+		categoryService:    categoryService,
+		reservationService: reservationService,
 	}
 }
 
@@ -69,6 +71,35 @@ func (h *Handler) GetProduct(c *gin.Context) {
 	})
 }
 
+// GetProductsBatch 批次獲取產品
+func (h *Handler) GetProductsBatch(c *gin.Context) {
+	var req model.BatchProductRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+			"message": "請求格式錯誤",
+		})
+		return
+	}
+
+	result, err := h.productService.GetByIDs(c.Request.Context(), req.IDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+			"message": "批次獲取產品失敗",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "批次獲取產品成功",
+		"data":    result,
+	})
+}
+
 // ListProducts 獲取產品列表
 func (h *Handler) ListProducts(c *gin.Context) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
@@ -196,6 +227,14 @@ func (h *Handler) UpdateStock(c *gin.Context) {
 			})
 			return
 		}
+		if err == service.ErrInsufficientStock {
+			c.JSON(http.StatusConflict, gin.H{
+				"success": false,
+				"error":   err.Error(),
+				"message": "庫存不足",
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
 			"error":   err.Error(),
@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kevinsuu/OrderManagerSystem/product-service/internal/model"
+	"github.com/kevinsuu/OrderManagerSystem/product-service/internal/service"
+)
+
+// ReserveStock 預留庫存
+func (h *Handler) ReserveStock(c *gin.Context) {
+	var req model.ReserveStockRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+			"message": "請求格式錯誤",
+		})
+		return
+	}
+
+	reservation, err := h.reservationService.Reserve(c.Request.Context(), &req)
+	if err != nil {
+		h.handleReservationError(c, err, "預留庫存失敗")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "預留庫存成功",
+		"data":    reservation,
+	})
+}
+
+// GetReservation 獲取庫存預留
+func (h *Handler) GetReservation(c *gin.Context) {
+	reservation, err := h.reservationService.GetReservation(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.handleReservationError(c, err, "獲取庫存預留失敗")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "獲取庫存預留成功",
+		"data":    reservation,
+	})
+}
+
+// CommitReservation 確認庫存預留
+func (h *Handler) CommitReservation(c *gin.Context) {
+	reservation, err := h.reservationService.Commit(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.handleReservationError(c, err, "確認庫存預留失敗")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "確認庫存預留成功",
+		"data":    reservation,
+	})
+}
+
+// ReleaseReservation 釋放庫存預留
+func (h *Handler) ReleaseReservation(c *gin.Context) {
+	reservation, err := h.reservationService.Release(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.handleReservationError(c, err, "釋放庫存預留失敗")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "釋放庫存預留成功",
+		"data":    reservation,
+	})
+}
+
+// handleReservationError 將預留相關錯誤轉換為 HTTP 響應
+func (h *Handler) handleReservationError(c *gin.Context, err error, message string) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, service.ErrReservationNotFound), errors.Is(err, service.ErrProductNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, service.ErrInsufficientStock), errors.Is(err, service.ErrReservationClosed):
+		status = http.StatusConflict
+	}
+
+	c.JSON(status, gin.H{
+		"success": false,
+		"error":   err.Error(),
+		"message": message,
+	})
+}
@@ -20,8 +20,11 @@ type Product struct {
 	Description string        `json:"description" db:"description"`
 	Price       float64       `json:"price" db:"price"`
 	Stock       int           `json:"stock" db:"stock"`
+	Weight      float64       `json:"weight,omitempty" db:"weight"` // 單件重量（公斤），用於計算運費
 	Status      ProductStatus `json:"status" db:"status"`
 	Category    string        `json:"category" db:"category"`
+	SellerID    string        `json:"sellerId,omitempty" db:"seller_id"`       // 賣家，不同賣家的商品結帳時拆成不同子訂單
+	WarehouseID string        `json:"warehouseId,omitempty" db:"warehouse_id"` // 出貨倉庫，不同倉庫的商品結帳時拆成不同子訂單
 	Images      []Image       `json:"images" gorm:"foreignKey:ProductID"`
 	Attributes  []Attribute   `json:"attributes,omitempty" gorm:"foreignKey:ProductID"`
 	CreatedAt   time.Time     `json:"created_at" db:"created_at"`
@@ -56,7 +59,10 @@ type CreateProductRequest struct {
 	Description string      `json:"description" binding:"required"`
 	Price       float64     `json:"price" binding:"required,gt=0"`
 	Stock       int         `json:"stock" binding:"required,gte=0"`
+	Weight      float64     `json:"weight" binding:"gte=0"`
 	CategoryID  string      `json:"categoryId" binding:"required"`
+	SellerID    string      `json:"sellerId"`
+	WarehouseID string      `json:"warehouseId"`
 	Images      []Image     `json:"images" binding:"required,min=1"`
 	Attributes  []Attribute `json:"attributes"`
 }
@@ -67,8 +73,11 @@ type UpdateProductRequest struct {
 	Description *string        `json:"description,omitempty"`
 	Price       *float64       `json:"price,omitempty" binding:"omitempty,gt=0"`
 	Stock       *int           `json:"stock,omitempty" binding:"omitempty,gte=0"`
+	Weight      *float64       `json:"weight,omitempty" binding:"omitempty,gte=0"`
 	Status      *ProductStatus `json:"status,omitempty"`
 	Category    *string        `json:"category,omitempty"`
+	SellerID    *string        `json:"sellerId,omitempty"`
+	WarehouseID *string        `json:"warehouseId,omitempty"`
 	Images      []Image        `json:"images,omitempty"`
 	Attributes  []Attribute    `json:"attributes,omitempty"`
 }
@@ -87,6 +96,17 @@ type ProductListResponse struct {
 	Limit    int               `json:"limit"`
 }
 
+// BatchProductRequest 批次查詢產品請求
+type BatchProductRequest struct {
+	IDs []string `json:"ids" binding:"required,min=1,max=100"`
+}
+
+// BatchProductResponse 批次查詢產品響應
+type BatchProductResponse struct {
+	Products []Product `json:"products"`
+	Missing  []string  `json:"missing"` // 不存在的產品ID
+}
+
 // StockUpdateRequest 庫存更新請求
 type StockUpdateRequest struct {
 	Quantity int `json:"quantity" binding:"required"`
@@ -0,0 +1,37 @@
+package model
+
+import "time"
+
+// ReservationStatus 庫存預留狀態
+type ReservationStatus string
+
+const (
+	ReservationStatusPending   ReservationStatus = "pending"   // 已扣除可售庫存，等待付款結果
+	ReservationStatusCommitted ReservationStatus = "committed" // 付款完成，庫存正式售出
+	ReservationStatusReleased  ReservationStatus = "released"  // 取消或付款失敗，庫存已歸還
+	ReservationStatusExpired   ReservationStatus = "expired"   // 逾時未確認，庫存已歸還
+)
+
+// StockReservation 庫存預留
+type StockReservation struct {
+	ID        string            `json:"id"`
+	OrderID   string            `json:"orderId"`
+	Items     []ReservationItem `json:"items"`
+	Status    ReservationStatus `json:"status"`
+	ExpiresAt time.Time         `json:"expiresAt"`
+	CreatedAt time.Time         `json:"createdAt"`
+	UpdatedAt time.Time         `json:"updatedAt"`
+}
+
+// ReservationItem 預留的商品與數量
+type ReservationItem struct {
+	ProductID string `json:"productId" binding:"required"`
+	Quantity  int    `json:"quantity" binding:"required,gt=0"`
+}
+
+// ReserveStockRequest 預留庫存請求
+type ReserveStockRequest struct {
+	OrderID    string            `json:"orderId"`
+	Items      []ReservationItem `json:"items" binding:"required,min=1,dive"`
+	TTLSeconds int               `json:"ttlSeconds" binding:"gte=0"` // 0 表示使用預設值
+}
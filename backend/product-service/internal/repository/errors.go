@@ -0,0 +1,10 @@
+package repository
+
+import "errors"
+
+var (
+	ErrProductNotFound   = errors.New("product not found")
+	ErrInsufficientStock = errors.New("insufficient stock")
+	// ErrReservationConflict 預留狀態已被其他請求改變
+	ErrReservationConflict = errors.New("reservation status conflict")
+)
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	"firebase.google.com/go/db"
@@ -14,11 +15,13 @@ import (
 type ProductRepository interface {
 	Create(ctx context.Context, product *model.Product) error
 	GetByID(ctx context.Context, id string) (*model.Product, error)
+	GetByIDs(ctx context.Context, ids []string) (map[string]*model.Product, error)
 	Update(ctx context.Context, product *model.Product) error
 	Delete(ctx context.Context, id string) error
 	List(ctx context.Context, page, limit int) ([]model.Product, int64, error)
 	GetByCategoryID(ctx context.Context, categoryID string, page, limit int) ([]model.Product, int64, error)
 	UpdateStock(ctx context.Context, id string, quantity int) error
+	AdjustStock(ctx context.Context, id string, delta int) error
 	SearchProducts(ctx context.Context, query string, page, limit int) ([]model.Product, int64, error)
 }
 
@@ -71,6 +74,41 @@ func (r *productRepository) GetByID(ctx context.Context, id string) (*model.Prod
 	return &product, nil
 }
 
+// GetByIDs 並行獲取多個產品，不存在的產品不會出現在結果中
+func (r *productRepository) GetByIDs(ctx context.Context, ids []string) (map[string]*model.Product, error) {
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		firstErr error
+	)
+	products := make(map[string]*model.Product, len(ids))
+	for _, id := range ids {
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			product, err := r.GetByID(ctx, id)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				return
+			}
+			if product != nil {
+				products[id] = product
+			}
+		}(id)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return products, nil
+}
+
 // List 獲取產品列表
 func (r *productRepository) List(ctx context.Context, page, limit int) ([]model.Product, int64, error) {
 	ref := r.db.NewRef("products")
@@ -143,23 +181,36 @@ func (r *productRepository) GetByCategoryID(ctx context.Context, categoryID stri
 
 // UpdateStock 更新庫存
 func (r *productRepository) UpdateStock(ctx context.Context, id string, quantity int) error {
-	ref := r.db.NewRef("products").Child(id)
-	var product model.Product
-	if err := ref.Get(ctx, &product); err != nil {
-		return fmt.Errorf("error getting product: %v", err)
-	}
-
-	if product.Stock+quantity < 0 {
-		return fmt.Errorf("insufficient stock")
-	}
-
-	product.Stock += quantity
-	product.UpdatedAt = time.Now()
+	return r.AdjustStock(ctx, id, quantity)
+}
 
-	if err := ref.Set(ctx, &product); err != nil {
+// AdjustStock 以 RTDB transaction 原子性增減庫存，庫存不足時返回 ErrInsufficientStock
+func (r *productRepository) AdjustStock(ctx context.Context, id string, delta int) error {
+	ref := r.db.NewRef("products").Child(id)
+	err := ref.Child("stock").Transaction(ctx, func(tn db.TransactionNode) (interface{}, error) {
+		var stock *int
+		if err := tn.Unmarshal(&stock); err != nil {
+			return nil, err
+		}
+		// 商品不存在時 stock 節點為空
+		if stock == nil {
+			return nil, ErrProductNotFound
+		}
+		if *stock+delta < 0 {
+			return nil, ErrInsufficientStock
+		}
+		return *stock + delta, nil
+	})
+	if err != nil {
+		if err == ErrProductNotFound || err == ErrInsufficientStock {
+			return err
+		}
 		return fmt.Errorf("error updating stock: %v", err)
 	}
 
+	if err := ref.Update(ctx, map[string]interface{}{"updated_at": time.Now()}); err != nil {
+		return fmt.Errorf("error updating product timestamp: %v", err)
+	}
 	return nil
 }
 
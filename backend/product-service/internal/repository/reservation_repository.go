@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"firebase.google.com/go/db"
+	"github.com/kevinsuu/OrderManagerSystem/product-service/internal/model"
+)
+
+// ReservationRepository 庫存預留存儲接口
+type ReservationRepository interface {
+	Create(ctx context.Context, reservation *model.StockReservation) error
+	GetByID(ctx context.Context, id string) (*model.StockReservation, error)
+	UpdateStatus(ctx context.Context, id string, from, to model.ReservationStatus) (*model.StockReservation, error)
+	ListExpired(ctx context.Context, before time.Time) ([]model.StockReservation, error)
+}
+
+type reservationRepository struct {
+	db *db.Client
+}
+
+// NewReservationRepository 創建庫存預留存儲實例
+func NewReservationRepository(db *db.Client) ReservationRepository {
+	return &reservationRepository{db: db}
+}
+
+// Create 保存預留記錄
+func (r *reservationRepository) Create(ctx context.Context, reservation *model.StockReservation) error {
+	ref := r.db.NewRef("stock_reservations").Child(reservation.ID)
+	if err := ref.Set(ctx, reservation); err != nil {
+		return fmt.Errorf("error saving reservation: %v", err)
+	}
+	return nil
+}
+
+// GetByID 獲取預留記錄，不存在時返回 nil
+func (r *reservationRepository) GetByID(ctx context.Context, id string) (*model.StockReservation, error) {
+	ref := r.db.NewRef("stock_reservations").Child(id)
+	var reservation model.StockReservation
+	if err := ref.Get(ctx, &reservation); err != nil {
+		return nil, fmt.Errorf("error getting reservation: %v", err)
+	}
+	if reservation.ID == "" {
+		return nil, nil
+	}
+	return &reservation, nil
+}
+
+// UpdateStatus 以 transaction 將預留狀態由 from 改為 to，狀態不符時返回 ErrReservationConflict
+func (r *reservationRepository) UpdateStatus(ctx context.Context, id string, from, to model.ReservationStatus) (*model.StockReservation, error) {
+	ref := r.db.NewRef("stock_reservations").Child(id)
+	var updated model.StockReservation
+	err := ref.Transaction(ctx, func(tn db.TransactionNode) (interface{}, error) {
+		var reservation model.StockReservation
+		if err := tn.Unmarshal(&reservation); err != nil {
+			return nil, err
+		}
+		if reservation.ID == "" {
+			return nil, ErrReservationConflict
+		}
+		if reservation.Status != from {
+			return nil, ErrReservationConflict
+		}
+		reservation.Status = to
+		reservation.UpdatedAt = time.Now()
+		updated = reservation
+		return &reservation, nil
+	})
+	if err != nil {
+		if err == ErrReservationConflict {
+			return nil, err
+		}
+		return nil, fmt.Errorf("error updating reservation status: %v", err)
+	}
+	return &updated, nil
+}
+
+// ListExpired 獲取已過期但仍為 pending 的預留
+func (r *reservationRepository) ListExpired(ctx context.Context, before time.Time) ([]model.StockReservation, error) {
+	var reservations map[string]model.StockReservation
+	ref := r.db.NewRef("stock_reservations")
+	if err := ref.OrderByChild("expiresAt").EndAt(before.UTC().Format(time.RFC3339Nano)).Get(ctx, &reservations); err != nil {
+		return nil, fmt.Errorf("error getting expired reservations: %v", err)
+	}
+
+	result := make([]model.StockReservation, 0, len(reservations))
+	for _, reservation := range reservations {
+		if reservation.Status == model.ReservationStatusPending && reservation.ExpiresAt.Before(before) {
+			result = append(result, reservation)
+		}
+	}
+	return result, nil
+}
@@ -20,6 +20,7 @@ var (
 type ProductService interface {
 	Create(ctx context.Context, req *model.CreateProductRequest) (*model.Product, error)
 	GetByID(ctx context.Context, id string) (*model.Product, error)
+	GetByIDs(ctx context.Context, ids []string) (*model.BatchProductResponse, error)
 	List(ctx context.Context, page, limit int) ([]model.Product, int64, error)
 	Update(ctx context.Context, id string, req *model.UpdateProductRequest) (*model.Product, error)
 	Delete(ctx context.Context, id string) error
@@ -54,8 +55,11 @@ func (s *productService) Create(ctx context.Context, req *model.CreateProductReq
 		Description: req.Description,
 		Price:       req.Price,
 		Stock:       req.Stock,
+		Weight:      req.Weight,
 		Status:      model.ProductStatusActive,
 		Category:    req.CategoryID,
+		SellerID:    req.SellerID,
+		WarehouseID: req.WarehouseID,
 		Images:      req.Images,
 		Attributes:  req.Attributes,
 		CreatedAt:   time.Now(),
@@ -108,6 +112,39 @@ func (s *productService) GetByID(ctx context.Context, id string) (*model.Product
 	return product, nil
 }
 
+// GetByIDs 批次獲取產品，依請求順序返回並列出不存在的產品ID
+func (s *productService) GetByIDs(ctx context.Context, ids []string) (*model.BatchProductResponse, error) {
+	// 去除重複ID
+	seen := make(map[string]bool, len(ids))
+	uniqueIDs := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		uniqueIDs = append(uniqueIDs, id)
+	}
+
+	products, err := s.repo.GetByIDs(ctx, uniqueIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get products: %w", err)
+	}
+
+	response := &model.BatchProductResponse{
+		Products: make([]model.Product, 0, len(products)),
+		Missing:  []string{},
+	}
+	for _, id := range uniqueIDs {
+		if product, ok := products[id]; ok {
+			response.Products = append(response.Products, *product)
+		} else {
+			response.Missing = append(response.Missing, id)
+		}
+	}
+
+	return response, nil
+}
+
 // List 獲取產品列表
 func (s *productService) List(ctx context.Context, page, limit int) ([]model.Product, int64, error) {
 	return s.repo.List(ctx, page, limit)
@@ -136,12 +173,21 @@ func (s *productService) Update(ctx context.Context, id string, req *model.Updat
 	if req.Stock != nil {
 		product.Stock = *req.Stock
 	}
+	if req.Weight != nil {
+		product.Weight = *req.Weight
+	}
 	if req.Status != nil {
 		product.Status = *req.Status
 	}
 	if req.Category != nil {
 		product.Category = *req.Category
 	}
+	if req.SellerID != nil {
+		product.SellerID = *req.SellerID
+	}
+	if req.WarehouseID != nil {
+		product.WarehouseID = *req.WarehouseID
+	}
 	if len(req.Images) > 0 {
 		// 驗證每個圖片至少有一個來源（URL 或 base64）
 		for _, img := range req.Images {
@@ -201,7 +247,13 @@ func (s *productService) GetByCategoryID(ctx context.Context, categoryID string,
 
 // UpdateStock 更新庫存
 func (s *productService) UpdateStock(ctx context.Context, id string, quantity int) error {
-	return s.repo.UpdateStock(ctx, id, quantity)
+	if err := s.repo.UpdateStock(ctx, id, quantity); err != nil {
+		if err == repository.ErrProductNotFound {
+			return ErrProductNotFound
+		}
+		return err
+	}
+	return nil
 }
 
 // SearchProducts 搜索產品
@@ -0,0 +1,213 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/kevinsuu/OrderManagerSystem/product-service/internal/model"
+	"github.com/kevinsuu/OrderManagerSystem/product-service/internal/repository"
+)
+
+var (
+	ErrInsufficientStock   = repository.ErrInsufficientStock
+	ErrReservationNotFound = errors.New("reservation not found")
+	ErrReservationClosed   = errors.New("reservation is no longer pending")
+)
+
+// ReservationServiceConfig 庫存預留配置
+type ReservationServiceConfig struct {
+	DefaultTTL time.Duration
+	MaxTTL     time.Duration
+}
+
+// ReservationService 庫存預留服務：預留（含 TTL）、確認、釋放
+type ReservationService interface {
+	Reserve(ctx context.Context, req *model.ReserveStockRequest) (*model.StockReservation, error)
+	GetReservation(ctx context.Context, id string) (*model.StockReservation, error)
+	Commit(ctx context.Context, id string) (*model.StockReservation, error)
+	Release(ctx context.Context, id string) (*model.StockReservation, error)
+	ExpireReservations(ctx context.Context) (int, error)
+}
+
+type reservationService struct {
+	productRepo     repository.ProductRepository
+	reservationRepo repository.ReservationRepository
+	config          *ReservationServiceConfig
+}
+
+// NewReservationService 創建庫存預留服務實例
+func NewReservationService(productRepo repository.ProductRepository, reservationRepo repository.ReservationRepository, config *ReservationServiceConfig) ReservationService {
+	if config == nil {
+		config = &ReservationServiceConfig{
+			DefaultTTL: 15 * time.Minute,
+			MaxTTL:     time.Hour,
+		}
+	}
+	return &reservationService{
+		productRepo:     productRepo,
+		reservationRepo: reservationRepo,
+		config:          config,
+	}
+}
+
+// Reserve 原子性扣除每項商品的可售庫存，任一商品不足時回滾已扣除的部分
+func (s *reservationService) Reserve(ctx context.Context, req *model.ReserveStockRequest) (*model.StockReservation, error) {
+	// 合併相同商品並依商品ID排序，避免不同請求以不同順序扣庫存
+	quantities := make(map[string]int, len(req.Items))
+	for _, item := range req.Items {
+		quantities[item.ProductID] += item.Quantity
+	}
+	items := make([]model.ReservationItem, 0, len(quantities))
+	for productID, quantity := range quantities {
+		items = append(items, model.ReservationItem{ProductID: productID, Quantity: quantity})
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].ProductID < items[j].ProductID })
+
+	reserved := make([]model.ReservationItem, 0, len(items))
+	for _, item := range items {
+		if err := s.productRepo.AdjustStock(ctx, item.ProductID, -item.Quantity); err != nil {
+			s.restoreStock(ctx, reserved)
+			switch err {
+			case repository.ErrProductNotFound:
+				return nil, fmt.Errorf("%w: %s", ErrProductNotFound, item.ProductID)
+			case repository.ErrInsufficientStock:
+				return nil, fmt.Errorf("%w: %s", ErrInsufficientStock, item.ProductID)
+			}
+			return nil, fmt.Errorf("failed to reserve stock: %w", err)
+		}
+		reserved = append(reserved, item)
+	}
+
+	ttl := s.config.DefaultTTL
+	if req.TTLSeconds > 0 {
+		ttl = time.Duration(req.TTLSeconds) * time.Second
+	}
+	if s.config.MaxTTL > 0 && ttl > s.config.MaxTTL {
+		ttl = s.config.MaxTTL
+	}
+
+	now := time.Now().UTC()
+	reservation := &model.StockReservation{
+		ID:        uuid.New().String(),
+		OrderID:   req.OrderID,
+		Items:     items,
+		Status:    model.ReservationStatusPending,
+		ExpiresAt: now.Add(ttl),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := s.reservationRepo.Create(ctx, reservation); err != nil {
+		s.restoreStock(ctx, reserved)
+		return nil, fmt.Errorf("failed to save reservation: %w", err)
+	}
+
+	return reservation, nil
+}
+
+// GetReservation 獲取預留
+func (s *reservationService) GetReservation(ctx context.Context, id string) (*model.StockReservation, error) {
+	reservation, err := s.reservationRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if reservation == nil {
+		return nil, ErrReservationNotFound
+	}
+	return reservation, nil
+}
+
+// Commit 確認預留，庫存正式售出；重複確認視為成功
+func (s *reservationService) Commit(ctx context.Context, id string) (*model.StockReservation, error) {
+	reservation, err := s.GetReservation(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if reservation.Status == model.ReservationStatusCommitted {
+		return reservation, nil
+	}
+	// 已過期但尚未被清理的預留不可確認
+	if reservation.Status == model.ReservationStatusPending && time.Now().After(reservation.ExpiresAt) {
+		return s.expire(ctx, reservation)
+	}
+
+	updated, err := s.reservationRepo.UpdateStatus(ctx, id, model.ReservationStatusPending, model.ReservationStatusCommitted)
+	if err != nil {
+		if err == repository.ErrReservationConflict {
+			return nil, ErrReservationClosed
+		}
+		return nil, err
+	}
+	return updated, nil
+}
+
+// Release 釋放預留並歸還庫存；重複釋放視為成功
+func (s *reservationService) Release(ctx context.Context, id string) (*model.StockReservation, error) {
+	reservation, err := s.GetReservation(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	switch reservation.Status {
+	case model.ReservationStatusReleased, model.ReservationStatusExpired:
+		return reservation, nil
+	case model.ReservationStatusCommitted:
+		return nil, ErrReservationClosed
+	}
+
+	return s.close(ctx, reservation, model.ReservationStatusReleased)
+}
+
+// ExpireReservations 釋放所有已逾時的預留，返回處理數量
+func (s *reservationService) ExpireReservations(ctx context.Context) (int, error) {
+	reservations, err := s.reservationRepo.ListExpired(ctx, time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for i := range reservations {
+		if _, err := s.close(ctx, &reservations[i], model.ReservationStatusExpired); err != nil {
+			if err != ErrReservationClosed {
+				log.Printf("Failed to expire reservation %s: %v", reservations[i].ID, err)
+			}
+			continue
+		}
+		expired++
+	}
+	return expired, nil
+}
+
+// expire 將逾時預留標記為過期並返回 ErrReservationClosed
+func (s *reservationService) expire(ctx context.Context, reservation *model.StockReservation) (*model.StockReservation, error) {
+	if _, err := s.close(ctx, reservation, model.ReservationStatusExpired); err != nil && err != ErrReservationClosed {
+		return nil, err
+	}
+	return nil, ErrReservationClosed
+}
+
+// close 將 pending 預留改為釋放或過期狀態並歸還庫存，狀態轉換成功者才會歸還，避免重複加回
+func (s *reservationService) close(ctx context.Context, reservation *model.StockReservation, status model.ReservationStatus) (*model.StockReservation, error) {
+	updated, err := s.reservationRepo.UpdateStatus(ctx, reservation.ID, model.ReservationStatusPending, status)
+	if err != nil {
+		if err == repository.ErrReservationConflict {
+			return nil, ErrReservationClosed
+		}
+		return nil, err
+	}
+
+	s.restoreStock(ctx, updated.Items)
+	return updated, nil
+}
+
+// restoreStock 歸還庫存，失敗時記錄日誌
+func (s *reservationService) restoreStock(ctx context.Context, items []model.ReservationItem) {
+	for _, item := range items {
+		if err := s.productRepo.AdjustStock(ctx, item.ProductID, item.Quantity); err != nil {
+			log.Printf("Failed to restore stock for product %s (quantity %d): %v", item.ProductID, item.Quantity, err)
+		}
+	}
+}